@@ -5,6 +5,7 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/hashicorp/hil/ast"
 	"github.com/hashicorp/terraform/helper/hilmapstructure"
 	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/registry/regsrc"
 	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/reflectwalk"
 )
@@ -20,6 +22,20 @@ import (
 // resources, etc.) must follow.
 var NameRegexp = regexp.MustCompile(`(?i)\A[A-Z0-9_][A-Z0-9\-\_]*\z`)
 
+// reservedResourceTypes are the names that can't be used as managed
+// resource types, because interpolations that start with them refer to
+// other kinds of object. They are mapped to a description of those objects.
+var reservedResourceTypes = map[string]string{
+	"count":     "the count of the current resource",
+	"data":      "data resources",
+	"local":     "local values",
+	"module":    "module outputs",
+	"path":      "filesystem paths",
+	"self":      "the resource itself",
+	"terraform": "Terraform metadata",
+	"var":       "variables",
+}
+
 // Config is the configuration that comes from loading a collection
 // of Terraform templates.
 type Config struct {
@@ -59,6 +75,10 @@ type Module struct {
 	Version   string
 	Providers map[string]string
 	RawConfig *RawConfig
+
+	// DeclRange is the position of the start of the module block, if
+	// the configuration was loaded from an HCL file.
+	DeclRange tfdiags.SourceRange
 }
 
 // ProviderConfig is the configuration for a resource provider.
@@ -70,6 +90,10 @@ type ProviderConfig struct {
 	Alias     string
 	Version   string
 	RawConfig *RawConfig
+
+	// DeclRange is the position of the start of the provider block, if
+	// the configuration was loaded from an HCL file.
+	DeclRange tfdiags.SourceRange
 }
 
 // A resource represents a single Terraform resource in the configuration.
@@ -86,6 +110,28 @@ type Resource struct {
 	Provider     string
 	DependsOn    []string
 	Lifecycle    ResourceLifecycle
+
+	// DeclRange is the position of the start of the resource block, if
+	// the configuration was loaded from an HCL file.
+	DeclRange tfdiags.SourceRange
+
+	// CountRange is the position of the start of the count expression, if
+	// it is set and the configuration was loaded from an HCL file.
+	CountRange tfdiags.SourceRange
+
+	// AttributeRanges are the positions of the arguments set in the
+	// resource block, by name, if the configuration was loaded from an HCL
+	// file. Both managed and data resources record them.
+	AttributeRanges map[string]tfdiags.SourceRange
+
+	// DependsOnRanges are the positions of the entries of DependsOn, in
+	// the same order, if the configuration was loaded from an HCL file.
+	DependsOnRanges []tfdiags.SourceRange
+
+	// IgnoreChangesRange is the position of the start of the ignore_changes
+	// list in the lifecycle block, if it is set and the configuration was
+	// loaded from an HCL file.
+	IgnoreChangesRange tfdiags.SourceRange
 }
 
 // Copy returns a copy of this Resource. Helpful for avoiding shared
@@ -102,11 +148,25 @@ func (r *Resource) Copy() *Resource {
 		Provider:     r.Provider,
 		DependsOn:    make([]string, len(r.DependsOn)),
 		Lifecycle:    *r.Lifecycle.Copy(),
+		DeclRange:    r.DeclRange,
+		CountRange:   r.CountRange,
+
+		IgnoreChangesRange: r.IgnoreChangesRange,
+	}
+	if r.AttributeRanges != nil {
+		n.AttributeRanges = make(map[string]tfdiags.SourceRange, len(r.AttributeRanges))
+		for k, v := range r.AttributeRanges {
+			n.AttributeRanges[k] = v
+		}
 	}
 	for _, p := range r.Provisioners {
 		n.Provisioners = append(n.Provisioners, p.Copy())
 	}
 	copy(n.DependsOn, r.DependsOn)
+	if r.DependsOnRanges != nil {
+		n.DependsOnRanges = make([]tfdiags.SourceRange, len(r.DependsOnRanges))
+		copy(n.DependsOnRanges, r.DependsOnRanges)
+	}
 	return n
 }
 
@@ -137,17 +197,36 @@ type Provisioner struct {
 
 	When      ProvisionerWhen
 	OnFailure ProvisionerOnFailure
+
+	// DeclRange is the position of the start of the provisioner block, if
+	// the configuration was loaded from an HCL file.
+	DeclRange tfdiags.SourceRange
+
+	// ValueRanges are the positions of the opening quotes of the
+	// single-line quoted string values in the provisioner block, keyed by
+	// the keys leading to each value joined with dots, such as "command"
+	// or "environment.FOO", if the configuration was loaded from an HCL
+	// file.
+	ValueRanges map[string]tfdiags.SourceRange
 }
 
 // Copy returns a copy of this Provisioner
 func (p *Provisioner) Copy() *Provisioner {
-	return &Provisioner{
+	n := &Provisioner{
 		Type:      p.Type,
 		RawConfig: p.RawConfig.Copy(),
 		ConnInfo:  p.ConnInfo.Copy(),
 		When:      p.When,
 		OnFailure: p.OnFailure,
+		DeclRange: p.DeclRange,
 	}
+	if p.ValueRanges != nil {
+		n.ValueRanges = make(map[string]tfdiags.SourceRange, len(p.ValueRanges))
+		for k, v := range p.ValueRanges {
+			n.ValueRanges[k] = v
+		}
+	}
+	return n
 }
 
 // Variable is a module argument defined within the configuration.
@@ -156,12 +235,20 @@ type Variable struct {
 	DeclaredType string `mapstructure:"type"`
 	Default      interface{}
 	Description  string
+
+	// DeclRange is the position of the start of the variable block, if
+	// the configuration was loaded from an HCL file.
+	DeclRange tfdiags.SourceRange
 }
 
 // Local is a local value defined within the configuration.
 type Local struct {
 	Name      string
 	RawConfig *RawConfig
+
+	// DeclRange is the position of the start of the local value's
+	// declaration, if the configuration was loaded from an HCL file.
+	DeclRange tfdiags.SourceRange
 }
 
 // Output is an output defined within the configuration. An output is
@@ -174,6 +261,10 @@ type Output struct {
 	Description string
 	Sensitive   bool
 	RawConfig   *RawConfig
+
+	// DeclRange is the position of the start of the output block, if
+	// the configuration was loaded from an HCL file.
+	DeclRange tfdiags.SourceRange
 }
 
 // VariableType is the type of value a variable is holding, and returned
@@ -405,14 +496,11 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 
 	// Check that providers aren't declared multiple times and that their
 	// version constraints, where present, are syntactically valid.
-	providerSet := make(map[string]bool)
+	providerSet := make(map[string]*ProviderConfig)
 	for _, p := range c.ProviderConfigs {
 		name := p.FullName()
-		if _, ok := providerSet[name]; ok {
-			diags = diags.Append(fmt.Errorf(
-				"provider.%s: multiple configurations present; only one configuration is allowed per provider",
-				name,
-			))
+		if existing, ok := providerSet[name]; ok {
+			diags = diags.Append(duplicateProviderDiagnostic(existing, p))
 			continue
 		}
 
@@ -433,7 +521,25 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 			}
 		}
 
-		providerSet[name] = true
+		// An alias of "default" or of the provider's own name looks like
+		// the default configuration, but only the resources that select
+		// it explicitly use it.
+		if p.Alias == "default" || p.Alias == p.Name {
+			diag := &hcl2.Diagnostic{
+				Severity: hcl2.DiagWarning,
+				Summary:  "Redundant provider configuration alias",
+				Detail: fmt.Sprintf(
+					"The configuration for provider %q has the alias %q, so it isn't the default configuration for the provider: only resources that set provider = \"%s\" use it. If this is meant to be the default configuration, remove the alias argument.",
+					p.Name, p.Alias, name,
+				),
+			}
+			if p.DeclRange.Filename != "" {
+				diag.Subject = p.DeclRange.ToHCL().Ptr()
+			}
+			diags = diags.Append(diag)
+		}
+
+		providerSet[name] = p
 	}
 
 	// Check that all references to modules are valid
@@ -471,6 +577,16 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 				"module %q: module source cannot contain interpolations",
 				m.Id(),
 			))
+		} else if m.Version != "" {
+			// Only modules from a registry are selected by version. Others
+			// are installed from their source as given, so the constraint
+			// would have no effect.
+			if _, err := regsrc.ParseModuleSource(m.Source); err != nil {
+				diags = diags.Append(fmt.Errorf(
+					"module %q: version can only be set for modules from a module registry, but %q is not a registry address; to select a revision of a module from a repository, include it in the source address",
+					m.Id(), m.Source,
+				))
+			}
 		}
 
 		// Check that the name matches our regexp
@@ -535,7 +651,7 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 
 		// check that all named providers actually exist
 		for _, p := range m.Providers {
-			if !providerSet[p] {
+			if providerSet[p] == nil {
 				diags = diags.Append(fmt.Errorf(
 					"module %q: cannot pass non-existent provider %q",
 					m.Name, p,
@@ -585,6 +701,14 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 
 	// Validate resources
 	for n, r := range resources {
+		// Verify that references to the resource can be written
+		if refersTo, ok := reservedResourceTypes[r.Type]; ok && r.Mode == ManagedResourceMode {
+			diags = diags.Append(fmt.Errorf(
+				"%s: resource type %q is reserved, because interpolations that start with %q refer to %s, so the resource could never be referred to",
+				n, r.Type, r.Type+".", refersTo,
+			))
+		}
+
 		// Verify count variables
 		for _, v := range r.RawCount.Variables {
 			switch v.(type) {
@@ -676,6 +800,7 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		}
 
 		// Verify ignore_changes contains valid entries
+		wildcard := false
 		for _, v := range r.Lifecycle.IgnoreChanges {
 			if strings.Contains(v, "*") && v != "*" {
 				diags = diags.Append(fmt.Errorf(
@@ -683,6 +808,15 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 					n, v,
 				))
 			}
+			if v == "*" {
+				wildcard = true
+			}
+		}
+		if wildcard && len(r.Lifecycle.IgnoreChanges) > 1 {
+			diags = diags.Append(fmt.Errorf(
+				"%s: ignore_changes cannot combine the wildcard \"*\" with specific attributes, since it already ignores changes to all of them",
+				n,
+			))
 		}
 
 		// Verify ignore_changes has no interpolations
@@ -701,13 +835,12 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 			))
 		}
 
-		// If it is a data source then it can't have provisioners
+		// If it is a data source then it can't have provisioners. The data
+		// source loader doesn't decode provisioner blocks, so they're still
+		// in the raw configuration, keyed by provisioner type.
 		if r.Mode == DataResourceMode {
-			if _, ok := r.RawConfig.Raw["provisioner"]; ok {
-				diags = diags.Append(fmt.Errorf(
-					"%s: data sources cannot have provisioners",
-					n,
-				))
+			if raw, ok := r.RawConfig.Raw["provisioner"]; ok {
+				diags = diags.Append(dataSourceProvisionerDiagnostic(n, r, raw))
 			}
 		}
 	}
@@ -720,7 +853,8 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 			}
 
 			id := rv.ResourceId()
-			if _, ok := resources[id]; !ok {
+			r, ok := resources[id]
+			if !ok {
 				diags = diags.Append(fmt.Errorf(
 					"%s: unknown resource '%s' referenced in variable %s",
 					source,
@@ -729,6 +863,30 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 				))
 				continue
 			}
+
+			// An index into a resource whose count is a literal number
+			// can be checked now. Counts that are interpolated aren't
+			// known until the count is evaluated.
+			if !rv.Multi || rv.Index < 0 || len(r.RawCount.Interpolations) > 0 {
+				continue
+			}
+			count, err := r.Count()
+			if err != nil || rv.Index < count {
+				continue
+			}
+			valid := fmt.Sprintf("the valid indices are 0 to %d", count-1)
+			if count == 0 {
+				valid = "it has no instances"
+			}
+			diags = diags.Append(fmt.Errorf(
+				"%s: index %d in variable %s is out of range; %s has count = %d, so %s",
+				source,
+				rv.Index,
+				rv.FullKey(),
+				id,
+				count,
+				valid,
+			))
 		}
 	}
 
@@ -891,6 +1049,9 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		}
 	}
 
+	// Check that every function called exists
+	diags = diags.Append(c.validateFunctionCalls())
+
 	return diags
 }
 
@@ -975,6 +1136,15 @@ func (c *Config) validateDependsOn(
 
 		// Check resources
 		if _, ok := resources[d]; !ok {
+			// An attribute of an existing resource is a common mistake, and
+			// deserves a clearer message than the resource not existing.
+			if addr := dependsOnAttrResource(d, resources); addr != "" {
+				errs = append(errs, fmt.Errorf(
+					"%s: depends_on takes resource addresses, not attributes; use '%s' instead of '%s'",
+					n, addr, d))
+				continue
+			}
+
 			errs = append(errs, fmt.Errorf(
 				"%s: resource depends on non-existent resource '%s'",
 				n, d))
@@ -984,6 +1154,90 @@ func (c *Config) validateDependsOn(
 	return errs
 }
 
+// dependsOnAttrResource returns the id of the resource whose attribute the
+// depends_on entry d refers to, such as aws_instance.foo for
+// aws_instance.foo.id, or an empty string if it doesn't refer to an
+// attribute of any of the given resources.
+func dependsOnAttrResource(d string, resources map[string]*Resource) string {
+	parts := strings.Split(d, ".")
+	for i := len(parts) - 1; i >= 2; i-- {
+		id := strings.Join(parts[:i], ".")
+		if _, ok := resources[id]; ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// dataSourceProvisionerDiagnostic returns the diagnostic for the
+// provisioner blocks, given as their raw value, declared by the data source
+// r, whose name is n.
+func dataSourceProvisionerDiagnostic(n string, r *Resource, raw interface{}) *hcl2.Diagnostic {
+	var types []string
+	switch v := raw.(type) {
+	case []map[string]interface{}:
+		for _, m := range v {
+			for k := range m {
+				types = append(types, fmt.Sprintf("%q", k))
+			}
+		}
+	case map[string]interface{}:
+		for k := range v {
+			types = append(types, fmt.Sprintf("%q", k))
+		}
+	}
+	sort.Strings(types)
+
+	detail := fmt.Sprintf("%s: data sources cannot have provisioners", n)
+	if len(types) > 0 {
+		detail += fmt.Sprintf(", but this declares %s", strings.Join(types, ", "))
+	}
+	detail += ". Provisioners run only when managed resources are created or destroyed, and data sources are only read."
+
+	diag := &hcl2.Diagnostic{
+		Severity: hcl2.DiagError,
+		Summary:  "Provisioner on a data source",
+		Detail:   detail,
+	}
+	if rng, ok := r.AttributeRanges["provisioner"]; ok && rng.Filename != "" {
+		diag.Subject = rng.ToHCL().Ptr()
+	}
+	return diag
+}
+
+// duplicateProviderDiagnostic returns the diagnostic for the provider
+// configuration dup, whose name and alias are the same as those of the
+// earlier configuration existing, naming the positions of both.
+func duplicateProviderDiagnostic(existing, dup *ProviderConfig) *hcl2.Diagnostic {
+	where := ""
+	if existing.DeclRange.Filename != "" {
+		where = " at " + existing.DeclRange.StartString()
+	}
+
+	var detail string
+	if dup.Alias == "" {
+		detail = fmt.Sprintf(
+			"provider.%s: a default (non-aliased) configuration for %q was already given%s. If multiple configurations are required, set the \"alias\" argument for alternative configurations.",
+			dup.FullName(), dup.Name, where,
+		)
+	} else {
+		detail = fmt.Sprintf(
+			"provider.%s: a configuration for %q with alias %q was already given%s. Each configuration for the same provider must have a distinct alias.",
+			dup.FullName(), dup.Name, dup.Alias, where,
+		)
+	}
+
+	diag := &hcl2.Diagnostic{
+		Severity: hcl2.DiagError,
+		Summary:  "Duplicate provider configuration",
+		Detail:   detail,
+	}
+	if dup.DeclRange.Filename != "" {
+		diag.Subject = dup.DeclRange.ToHCL().Ptr()
+	}
+	return diag
+}
+
 func (m *Module) mergerName() string {
 	return m.Id()
 }
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	hcl2 "github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/helper/didyoumean"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateFunctionCalls returns an error for each function called in the
+// configuration that isn't among the built-in functions that this version
+// of Terraform provides, as langEvalConfig makes them available to
+// interpolations, suggesting the closest function that is.
+//
+// Such calls would otherwise fail during the validate walk, once for each
+// instance and without the position of the call, which is most often the
+// result of configuration written for a newer version of Terraform. The
+// subject of each error is the attribute that makes the call, where that is
+// known, or else the enclosing block.
+func (c *Config) validateFunctionCalls() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	funcs := make(map[string]bool)
+	for name := range langEvalConfig(nil).GlobalScope.FuncMap {
+		funcs[name] = true
+	}
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	check := func(source string, raw *RawConfig, subject tfdiags.SourceRange) {
+		if raw == nil {
+			return
+		}
+		for _, name := range unknownFunctionCalls(raw, funcs) {
+			detail := fmt.Sprintf("%s: there is no function named %q in this version of Terraform.", source, name)
+			if suggestion := didyoumean.NameSuggestion(name, names); suggestion != "" {
+				detail += fmt.Sprintf(" Did you mean %q?", suggestion)
+			}
+			diag := &hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Call to unknown function",
+				Detail:   detail,
+			}
+			if subject.Filename != "" {
+				diag.Subject = subject.ToHCL().Ptr()
+			}
+			diags = diags.Append(diag)
+		}
+	}
+
+	for _, pc := range c.ProviderConfigs {
+		check("provider."+pc.FullName(), pc.RawConfig, pc.DeclRange)
+	}
+
+	for _, m := range c.Modules {
+		check("module."+m.Name, m.RawConfig, m.DeclRange)
+	}
+
+	for _, r := range c.Resources {
+		source := r.Id()
+		subject := r.DeclRange
+		if r.CountRange.Filename != "" {
+			subject = r.CountRange
+		}
+		check(source, r.RawCount, subject)
+
+		// Each attribute is parsed on its own so that the errors can name
+		// the attributes that make the calls.
+		if r.RawConfig != nil {
+			keys := make([]string, 0, len(r.RawConfig.Raw))
+			for k := range r.RawConfig.Raw {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				raw, err := NewRawConfig(map[string]interface{}{k: r.RawConfig.Raw[k]})
+				if err != nil {
+					continue
+				}
+				subject := r.DeclRange
+				if rng, ok := r.AttributeRanges[k]; ok {
+					subject = rng
+				}
+				check(source, raw, subject)
+			}
+		}
+
+		for _, p := range r.Provisioners {
+			subsource := fmt.Sprintf("%s: provisioner %s", source, p.Type)
+			check(subsource, p.RawConfig, p.DeclRange)
+			check(subsource+" connection", p.ConnInfo, p.DeclRange)
+		}
+	}
+
+	for _, l := range c.Locals {
+		check("local."+l.Name, l.RawConfig, l.DeclRange)
+	}
+
+	for _, o := range c.Outputs {
+		check("output."+o.Name, o.RawConfig, o.DeclRange)
+	}
+
+	return diags
+}
+
+// unknownFunctionCalls returns the sorted names of the functions called by
+// the interpolations in the given raw configuration that aren't in funcs.
+func unknownFunctionCalls(raw *RawConfig, funcs map[string]bool) []string {
+	found := make(map[string]bool)
+	visit := func(n ast.Node) ast.Node {
+		if call, ok := n.(*ast.Call); ok && !funcs[call.Func] {
+			found[call.Func] = true
+		}
+		return n
+	}
+	for _, n := range raw.Interpolations {
+		n.Accept(visit)
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/hashstructure"
 )
 
@@ -13,6 +14,19 @@ import (
 type Terraform struct {
 	RequiredVersion string   `hcl:"required_version"` // Required Terraform version (constraint)
 	Backend         *Backend // See Backend struct docs
+
+	// KeyRanges is the position of each argument and nested block in the
+	// block, by name, if the configuration was loaded from an HCL file.
+	// This includes any names that aren't recognized, which are not
+	// rejected when loading so that configuration written for future
+	// versions of Terraform can still be read.
+	KeyRanges map[string]tfdiags.SourceRange
+
+	// RequiredProviders is the position of each entry in the
+	// required_providers block, by provider name, if there is one. Only the
+	// names are recorded, since the requirements themselves are not used by
+	// this version of Terraform.
+	RequiredProviders map[string]tfdiags.SourceRange
 }
 
 // Validate performs the validation for just the Terraform configuration.
@@ -57,6 +71,20 @@ func (t *Terraform) Merge(t2 *Terraform) {
 	if t2.Backend != nil {
 		t.Backend = t2.Backend
 	}
+
+	for k, r := range t2.KeyRanges {
+		if t.KeyRanges == nil {
+			t.KeyRanges = make(map[string]tfdiags.SourceRange)
+		}
+		t.KeyRanges[k] = r
+	}
+
+	for k, r := range t2.RequiredProviders {
+		if t.RequiredProviders == nil {
+			t.RequiredProviders = make(map[string]tfdiags.SourceRange)
+		}
+		t.RequiredProviders[k] = r
+	}
 }
 
 // Backend is the configuration for the "backend" to use with Terraform.
@@ -181,6 +181,13 @@ func TestConfigValidate_table(t *testing.T) {
 			"non-existent module 'foo'",
 		},
 
+		{
+			"depends on resource attribute",
+			"validate-depends-on-attribute",
+			true,
+			"depends_on takes resource addresses, not attributes; use 'aws_instance.db' instead of 'aws_instance.db.id'",
+		},
+
 		{
 			"data source with provisioners",
 			"validate-data-provisioner",
@@ -416,6 +423,13 @@ func TestConfigValidate_ignoreChangesBad(t *testing.T) {
 	}
 }
 
+func TestConfigValidate_ignoreChangesWildcard(t *testing.T) {
+	c := testConfig(t, "validate-ignore-changes-wildcard")
+	if err := c.Validate(); err == nil {
+		t.Fatal("should not be valid")
+	}
+}
+
 func TestConfigValidate_ignoreChangesInterpolate(t *testing.T) {
 	c := testConfig(t, "validate-ignore-changes-interpolate")
 	if err := c.Validate(); err == nil {
@@ -465,6 +479,20 @@ func TestConfigValidate_moduleVarSelf(t *testing.T) {
 	}
 }
 
+func TestConfigValidate_moduleVersionGit(t *testing.T) {
+	c := testConfig(t, "validate-module-version-git")
+	if err := c.Validate(); err == nil {
+		t.Fatal("should not be valid")
+	}
+}
+
+func TestConfigValidate_moduleVersionRegistry(t *testing.T) {
+	c := testConfig(t, "validate-module-version-registry")
+	if err := c.Validate(); err != nil {
+		t.Fatalf("should be valid: %s", err)
+	}
+}
+
 func TestConfigValidate_nil(t *testing.T) {
 	var c Config
 	if err := c.Validate(); err != nil {
@@ -515,8 +543,90 @@ func TestConfigValidate_pathVarInvalid(t *testing.T) {
 
 func TestConfigValidate_providerMulti(t *testing.T) {
 	c := testConfig(t, "validate-provider-multi")
-	if err := c.Validate(); err == nil {
-		t.Fatal("should not be valid")
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(diags), diags.Err())
+	}
+
+	desc := diags[0].Description()
+	if got, want := desc.Summary, "Duplicate provider configuration"; got != want {
+		t.Errorf("wrong summary %q; want %q", got, want)
+	}
+	wantDetail := `a configuration for "aws" with alias "foo" was already given at ` + filepath.Join(fixtureDir, "validate-provider-multi", "main.tf") + ":1,10."
+	if !strings.Contains(desc.Detail, wantDetail) {
+		t.Errorf("wrong detail %q; want it to contain %q", desc.Detail, wantDetail)
+	}
+	subject := diags[0].Source().Subject
+	if subject == nil {
+		t.Fatal("diagnostic has no subject")
+	}
+	if got, want := subject.Start.Line, 5; got != want {
+		t.Errorf("wrong subject line %d; want %d", got, want)
+	}
+}
+
+func TestConfigValidate_dataSourceProvisioner(t *testing.T) {
+	c := testConfig(t, "validate-data-provisioner")
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(diags), diags.Err())
+	}
+
+	desc := diags[0].Description()
+	if got, want := desc.Summary, "Provisioner on a data source"; got != want {
+		t.Errorf("wrong summary %q; want %q", got, want)
+	}
+	if want := `this declares "local-exec"`; !strings.Contains(desc.Detail, want) {
+		t.Errorf("wrong detail %q; want it to contain %q", desc.Detail, want)
+	}
+	subject := diags[0].Source().Subject
+	if subject == nil {
+		t.Fatal("diagnostic has no subject")
+	}
+	if got, want := subject.Start.Line, 2; got != want {
+		t.Errorf("wrong subject line %d; want %d", got, want)
+	}
+}
+
+func TestConfigValidate_unknownFunction(t *testing.T) {
+	c := testConfig(t, "validate-unknown-function")
+	diags := c.Validate()
+	if len(diags) != 4 {
+		t.Fatalf("wrong number of diagnostics %d; want 4\n%s", len(diags), diags.Err())
+	}
+
+	tests := []struct {
+		Detail string
+		Line   int
+	}{
+		{`module.child: there is no function named "uppper" in this version of Terraform. Did you mean "upper"?`, 10},
+		{`aws_instance.foo: there is no function named "uppper" in this version of Terraform. Did you mean "upper"?`, 2},
+		{`local.name: there is no function named "titel" in this version of Terraform. Did you mean "title"?`, 16},
+		{`output.addr: there is no function named "nosuchfunction" in this version of Terraform.`, 6},
+	}
+	for i, test := range tests {
+		desc := diags[i].Description()
+		if got, want := desc.Summary, "Call to unknown function"; got != want {
+			t.Errorf("%d: wrong summary %q; want %q", i, got, want)
+		}
+		if desc.Detail != test.Detail {
+			t.Errorf("%d: wrong detail\ngot:  %s\nwant: %s", i, desc.Detail, test.Detail)
+		}
+		subject := diags[i].Source().Subject
+		if subject == nil {
+			t.Errorf("%d: diagnostic has no subject", i)
+			continue
+		}
+		if subject.Start.Line != test.Line {
+			t.Errorf("%d: wrong subject line %d; want %d", i, subject.Start.Line, test.Line)
+		}
+	}
+}
+
+func TestConfigValidate_knownFunction(t *testing.T) {
+	c := testConfig(t, "validate-known-function")
+	if diags := c.Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
 	}
 }
 
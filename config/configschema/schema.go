@@ -49,6 +49,21 @@ type Attribute struct {
 	// future to help Terraform mask sensitive information. (Terraform
 	// currently achieves this in a limited sense via other mechanisms.)
 	Sensitive bool
+
+	// ReplacedBy, if set, indicates that this attribute is deprecated in
+	// favor of the attribute of the given name in the same block. Setting
+	// both in configuration is an error.
+	ReplacedBy string
+
+	// ForceNew, if set to true, indicates that a change to the value of this
+	// attribute can't be applied in-place, and so causes the object to be
+	// replaced.
+	ForceNew bool
+
+	// Internal, if set to true, indicates that this attribute is for the
+	// provider's own use and can't be referred to from configuration. It
+	// should be set only along with Computed.
+	Internal bool
 }
 
 // NestedBlock represents the embedding of one block within another.
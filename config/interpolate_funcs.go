@@ -115,7 +115,7 @@ func Funcs() map[string]ast.Function {
 		"sort":         interpolationFuncSort(),
 		"split":        interpolationFuncSplit(),
 		"substr":       interpolationFuncSubstr(),
-		"timestamp":    interpolationFuncTimestamp(),
+		"timestamp":    interpolationFuncTimestamp(time.Now),
 		"timeadd":      interpolationFuncTimeAdd(),
 		"title":        interpolationFuncTitle(),
 		"transpose":    interpolationFuncTranspose(),
@@ -1498,13 +1498,14 @@ func interpolationFuncUUID() ast.Function {
 	}
 }
 
-// interpolationFuncTimestamp
-func interpolationFuncTimestamp() ast.Function {
+// interpolationFuncTimestamp returns the current time as reported by the
+// given clock.
+func interpolationFuncTimestamp(now func() time.Time) ast.Function {
 	return ast.Function{
 		ArgTypes:   []ast.Type{},
 		ReturnType: ast.TypeString,
 		Callback: func(args []interface{}) (interface{}, error) {
-			return time.Now().UTC().Format(time.RFC3339), nil
+			return now().UTC().Format(time.RFC3339), nil
 		},
 	}
 }
@@ -2426,6 +2426,26 @@ func TestInterpolateFuncTimestamp(t *testing.T) {
 	}
 }
 
+func TestInterpolateFuncTimestamp_clock(t *testing.T) {
+	ast, err := hil.Parse("${timestamp()} ${timeadd(timestamp(), \"1h\")}")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	now := func() time.Time {
+		return time.Date(2017, 11, 22, 0, 0, 0, 0, time.UTC)
+	}
+	result, err := hil.Eval(ast, langEvalConfigWithClock(nil, now))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := "2017-11-22T00:00:00Z 2017-11-22T01:00:00Z"
+	if result.Value != expected {
+		t.Fatalf("bad: %#v\nexpected: %#v", result.Value, expected)
+	}
+}
+
 func TestInterpolateFuncTimeAdd(t *testing.T) {
 	testFunction(t, testFunctionConfig{
 		Cases: []testFunctionCase{
@@ -7,6 +7,8 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -150,6 +152,57 @@ func (t *hclConfigurable) Config() (*Config, error) {
 		}
 	}
 
+	// The HCL parser doesn't know which file it was reading, so we fill
+	// in the filename of the declaration ranges here.
+	for _, pc := range config.ProviderConfigs {
+		pc.DeclRange.Filename = t.File
+	}
+	for _, r := range config.Resources {
+		r.DeclRange.Filename = t.File
+		if r.CountRange.Start.Line > 0 {
+			r.CountRange.Filename = t.File
+		}
+		if r.IgnoreChangesRange.Start.Line > 0 {
+			r.IgnoreChangesRange.Filename = t.File
+		}
+		for k, rng := range r.AttributeRanges {
+			rng.Filename = t.File
+			r.AttributeRanges[k] = rng
+		}
+		for i := range r.DependsOnRanges {
+			r.DependsOnRanges[i].Filename = t.File
+		}
+		for _, p := range r.Provisioners {
+			p.DeclRange.Filename = t.File
+			for k, rng := range p.ValueRanges {
+				rng.Filename = t.File
+				p.ValueRanges[k] = rng
+			}
+		}
+	}
+	for _, m := range config.Modules {
+		m.DeclRange.Filename = t.File
+	}
+	for _, l := range config.Locals {
+		l.DeclRange.Filename = t.File
+	}
+	for _, o := range config.Outputs {
+		o.DeclRange.Filename = t.File
+	}
+	for _, v := range config.Variables {
+		v.DeclRange.Filename = t.File
+	}
+	if config.Terraform != nil {
+		for k, r := range config.Terraform.KeyRanges {
+			r.Filename = t.File
+			config.Terraform.KeyRanges[k] = r
+		}
+		for k, r := range config.Terraform.RequiredProviders {
+			r.Filename = t.File
+			config.Terraform.RequiredProviders[k] = r
+		}
+	}
+
 	// Check for invalid keys
 	for _, item := range list.Items {
 		if len(item.Keys) == 0 {
@@ -168,6 +221,89 @@ func (t *hclConfigurable) Config() (*Config, error) {
 	return config, nil
 }
 
+// hclDeclRange returns a zero-length source range at the start of the given
+// item. The filename is left empty, since the parser doesn't record it.
+func hclDeclRange(item *ast.ObjectItem) tfdiags.SourceRange {
+	return hclPosRange(item.Pos())
+}
+
+// hclAttributeRanges returns the position of the first item in the given
+// list for each argument or block set in the given raw configuration, by
+// name.
+func hclAttributeRanges(list *ast.ObjectList, rawConfig *RawConfig) map[string]tfdiags.SourceRange {
+	ranges := make(map[string]tfdiags.SourceRange)
+	for _, attr := range list.Items {
+		if len(attr.Keys) == 0 {
+			continue
+		}
+		name := attr.Keys[0].Token.Value().(string)
+		if _, ok := rawConfig.Raw[name]; !ok {
+			continue
+		}
+		if _, exists := ranges[name]; !exists {
+			ranges[name] = hclDeclRange(attr)
+		}
+	}
+	return ranges
+}
+
+// hclPosRange returns a zero-length source range at the given position.
+func hclPosRange(pos token.Pos) tfdiags.SourceRange {
+	start := tfdiags.SourcePos{
+		Line:   pos.Line,
+		Column: pos.Column,
+		Byte:   pos.Offset,
+	}
+	return tfdiags.SourceRange{
+		Start: start,
+		End:   start,
+	}
+}
+
+// hclStringRanges records in ranges the position of each single-line quoted
+// string value in the given list, recursing into nested objects, keyed by
+// the keys leading to the value joined with dots and appended to prefix.
+// Values in lists are not recorded.
+func hclStringRanges(list *ast.ObjectList, prefix string, ranges map[string]tfdiags.SourceRange) {
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+		key := prefix + item.Keys[0].Token.Value().(string)
+		if _, exists := ranges[key]; exists {
+			continue
+		}
+
+		switch v := item.Val.(type) {
+		case *ast.LiteralType:
+			if v.Token.Type == token.STRING {
+				ranges[key] = hclPosRange(v.Pos())
+			}
+		case *ast.ObjectType:
+			hclStringRanges(v.List, key+".", ranges)
+		}
+	}
+}
+
+// hclListRanges returns the position of each element of the list given for
+// the named argument in the given list, or nil if the argument isn't set to
+// a list.
+func hclListRanges(list *ast.ObjectList, name string) []tfdiags.SourceRange {
+	o := list.Filter(name)
+	if len(o.Items) == 0 {
+		return nil
+	}
+	lt, ok := o.Items[0].Val.(*ast.ListType)
+	if !ok {
+		return nil
+	}
+	ranges := make([]tfdiags.SourceRange, 0, len(lt.List))
+	for _, elem := range lt.List {
+		ranges = append(ranges, hclPosRange(elem.Pos()))
+	}
+	return ranges
+}
+
 // loadFileHcl is a fileLoaderFunc that knows how to read HCL
 // files and turn them into hclConfigurables.
 func loadFileHcl(root string) (configurable, []string, error) {
@@ -268,8 +404,8 @@ func loadTerraformHcl(list *ast.ObjectList) (*Terraform, error) {
 	// we can potentially read _future_ Terraform version config (to
 	// still be able to validate the required version).
 	//
-	// We should still keep track of unknown keys to validate later, but
-	// HCL doesn't currently support that.
+	// We still keep track of all of the keys and their positions so that
+	// unknown keys can be reported when validating.
 
 	var config Terraform
 	if err := hcl.DecodeObject(&config, item.Val); err != nil {
@@ -278,6 +414,38 @@ func loadTerraformHcl(list *ast.ObjectList) (*Terraform, error) {
 			err)
 	}
 
+	config.KeyRanges = make(map[string]tfdiags.SourceRange)
+	for _, item := range listVal.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+		k := item.Keys[0].Token.Value().(string)
+		if _, exists := config.KeyRanges[k]; !exists {
+			config.KeyRanges[k] = hclDeclRange(item)
+		}
+	}
+
+	// Record the names of the required providers, whatever form their
+	// requirements take.
+	if os := listVal.Filter("required_providers"); len(os.Items) > 0 {
+		config.RequiredProviders = make(map[string]tfdiags.SourceRange)
+		for _, item := range os.Items {
+			ot, ok := item.Val.(*ast.ObjectType)
+			if !ok {
+				return nil, fmt.Errorf("terraform block: required_providers should be an object")
+			}
+			for _, item := range ot.List.Items {
+				if len(item.Keys) == 0 {
+					continue
+				}
+				k := item.Keys[0].Token.Value().(string)
+				if _, exists := config.RequiredProviders[k]; !exists {
+					config.RequiredProviders[k] = hclDeclRange(item)
+				}
+			}
+		}
+	}
+
 	// If we have provisioners, then parse those out
 	if os := listVal.Filter("backend"); len(os.Items) > 0 {
 		var err error
@@ -445,6 +613,7 @@ func loadModulesHcl(list *ast.ObjectList) ([]*Module, error) {
 			Version:   version,
 			Providers: providers,
 			RawConfig: rawConfig,
+			DeclRange: hclDeclRange(item),
 		})
 	}
 
@@ -496,6 +665,7 @@ func loadLocalsHcl(list *ast.ObjectList) ([]*Local, error) {
 				result = append(result, &Local{
 					Name:      k,
 					RawConfig: rawConfig,
+					DeclRange: hclDeclRange(item),
 				})
 			}
 		}
@@ -571,6 +741,7 @@ func loadOutputsHcl(list *ast.ObjectList) ([]*Output, error) {
 			RawConfig:   rawConfig,
 			DependsOn:   dependsOn,
 			Description: description,
+			DeclRange:   hclDeclRange(item),
 		})
 	}
 
@@ -647,6 +818,7 @@ func loadVariablesHcl(list *ast.ObjectList) ([]*Variable, error) {
 			DeclaredType: hclVar.DeclaredType,
 			Default:      hclVar.Default,
 			Description:  hclVar.Description,
+			DeclRange:    hclDeclRange(item),
 		}
 		if err := newVar.ValidateTypeAndDefault(); err != nil {
 			return nil, err
@@ -727,6 +899,7 @@ func loadProvidersHcl(list *ast.ObjectList) ([]*ProviderConfig, error) {
 			Alias:     alias,
 			Version:   version,
 			RawConfig: rawConfig,
+			DeclRange: hclDeclRange(item),
 		})
 	}
 
@@ -796,7 +969,9 @@ func loadDataResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 
 		// If we have a count, then figure it out
 		var count string = "1"
+		var countRange tfdiags.SourceRange
 		if o := listVal.Filter("count"); len(o.Items) > 0 {
+			countRange = hclPosRange(o.Items[0].Val.Pos())
 			err = hcl.DecodeObject(&count, o.Items[0].Val)
 			if err != nil {
 				return nil, fmt.Errorf(
@@ -850,6 +1025,11 @@ func loadDataResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 			Provisioners: []*Provisioner{},
 			DependsOn:    dependsOn,
 			Lifecycle:    ResourceLifecycle{},
+			DeclRange:    hclDeclRange(item),
+			CountRange:   countRange,
+
+			AttributeRanges: hclAttributeRanges(listVal, rawConfig),
+			DependsOnRanges: hclListRanges(listVal, "depends_on"),
 		})
 	}
 
@@ -930,7 +1110,9 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 
 		// If we have a count, then figure it out
 		var count string = "1"
+		var countRange tfdiags.SourceRange
 		if o := listVal.Filter("count"); len(o.Items) > 0 {
+			countRange = hclPosRange(o.Items[0].Val.Pos())
 			err = hcl.DecodeObject(&count, o.Items[0].Val)
 			if err != nil {
 				return nil, fmt.Errorf(
@@ -1004,6 +1186,7 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 		// Check if the resource should be re-created before
 		// destroying the existing instance
 		var lifecycle ResourceLifecycle
+		var ignoreChangesRange tfdiags.SourceRange
 		if o := listVal.Filter("lifecycle"); len(o.Items) > 0 {
 			if len(o.Items) > 1 {
 				return nil, fmt.Errorf(
@@ -1034,6 +1217,12 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 					k,
 					err)
 			}
+
+			if ot, ok := o.Items[0].Val.(*ast.ObjectType); ok {
+				if ic := ot.List.Filter("ignore_changes"); len(ic.Items) > 0 {
+					ignoreChangesRange = hclPosRange(ic.Items[0].Val.Pos())
+				}
+			}
 		}
 
 		result = append(result, &Resource{
@@ -1046,6 +1235,12 @@ func loadManagedResourcesHcl(list *ast.ObjectList) ([]*Resource, error) {
 			Provider:     provider,
 			DependsOn:    dependsOn,
 			Lifecycle:    lifecycle,
+			DeclRange:    hclDeclRange(item),
+			CountRange:   countRange,
+
+			AttributeRanges:    hclAttributeRanges(listVal, rawConfig),
+			DependsOnRanges:    hclListRanges(listVal, "depends_on"),
+			IgnoreChangesRange: ignoreChangesRange,
 		})
 	}
 
@@ -1147,12 +1342,18 @@ func loadProvisionersHcl(list *ast.ObjectList, connInfo map[string]interface{})
 			return nil, err
 		}
 
+		valueRanges := make(map[string]tfdiags.SourceRange)
+		hclStringRanges(listVal, "", valueRanges)
+
 		result = append(result, &Provisioner{
 			Type:      n,
 			RawConfig: rawConfig,
 			ConnInfo:  connRaw,
 			When:      when,
 			OnFailure: onFailure,
+
+			DeclRange:   hclDeclRange(item),
+			ValueRanges: valueRanges,
 		})
 	}
 
@@ -6,6 +6,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 func TestErrNoConfigsFound_impl(t *testing.T) {
@@ -183,7 +185,16 @@ func TestLoadFileBasic(t *testing.T) {
 		t.Fatalf("wrong dir %#v; want %#v", c.Dir, "")
 	}
 
-	expectedTF := &Terraform{RequiredVersion: "foo"}
+	expectedTF := &Terraform{
+		RequiredVersion: "foo",
+		KeyRanges: map[string]tfdiags.SourceRange{
+			"required_version": {
+				Filename: filepath.Join(fixtureDir, "basic.tf"),
+				Start:    tfdiags.SourcePos{Line: 2, Column: 5, Byte: 16},
+				End:      tfdiags.SourcePos{Line: 2, Column: 5, Byte: 16},
+			},
+		},
+	}
 	if !reflect.DeepEqual(c.Terraform, expectedTF) {
 		t.Fatalf("wrong terraform block %#v; want %#v", c.Terraform, expectedTF)
 	}
@@ -379,6 +390,30 @@ backend (s3)
 	}
 }
 
+func TestLoadFile_terraformRequiredProviders(t *testing.T) {
+	c, err := LoadFile(filepath.Join(fixtureDir, "terraform-required-providers.tf"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	filename := filepath.Join(fixtureDir, "terraform-required-providers.tf")
+	expected := map[string]tfdiags.SourceRange{
+		"aws": {
+			Filename: filename,
+			Start:    tfdiags.SourcePos{Line: 3, Column: 5, Byte: 39},
+			End:      tfdiags.SourcePos{Line: 3, Column: 5, Byte: 39},
+		},
+		"google": {
+			Filename: filename,
+			Start:    tfdiags.SourcePos{Line: 5, Column: 5, Byte: 59},
+			End:      tfdiags.SourcePos{Line: 5, Column: 5, Byte: 59},
+		},
+	}
+	if !reflect.DeepEqual(c.Terraform.RequiredProviders, expected) {
+		t.Fatalf("wrong required providers %#v; want %#v", c.Terraform.RequiredProviders, expected)
+	}
+}
+
 func TestLoadFile_terraformBackendJSON(t *testing.T) {
 	c, err := LoadFile(filepath.Join(fixtureDir, "terraform-backend.tf.json"))
 	if err != nil {
@@ -0,0 +1,9 @@
+provider "aws" { alias = "east" }
+
+module "child" {
+    source = "./child"
+
+    providers = {
+        "aws.north" = "aws.east"
+    }
+}
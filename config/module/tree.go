@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
@@ -13,6 +14,7 @@ import (
 
 	getter "github.com/hashicorp/go-getter"
 	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/didyoumean"
 )
 
 // RootName is the name of the root tree.
@@ -182,6 +184,9 @@ func (t *Tree) Load(s *Storage) error {
 
 	// Go through all the children and load them.
 	for _, c := range children {
+		if err := c.checkRecursion(); err != nil {
+			return err
+		}
 		if err := c.Load(s); err != nil {
 			return err
 		}
@@ -345,11 +350,90 @@ func (t *Tree) getChildren(s *Storage) (map[string]*Tree, error) {
 	return children, nil
 }
 
+// checkRecursion returns an error if the module of the tree is in the same
+// directory as the module of one of its ancestors, which it would then call
+// recursively, so that loading it would never finish. Directories are
+// compared after resolving symlinks, since the modules with local sources
+// are linked into the module storage.
+func (t *Tree) checkRecursion() error {
+	if t.config == nil || t.config.Dir == "" {
+		return nil
+	}
+	dir, err := filepath.EvalSymlinks(t.config.Dir)
+	if err != nil {
+		return nil
+	}
+
+	chain := []string{"module." + strings.Join(t.path, ".module.")}
+	for p := t.parent; p != nil; p = p.parent {
+		name := "root"
+		if len(p.path) > 0 {
+			name = "module." + strings.Join(p.path, ".module.")
+		}
+		chain = append([]string{name}, chain...)
+
+		if p.config == nil || p.config.Dir == "" {
+			continue
+		}
+		if pDir, err := filepath.EvalSymlinks(p.config.Dir); err == nil && pDir == dir {
+			return fmt.Errorf(
+				"module %s: calls itself recursively, through %s",
+				strings.Join(t.path, "."), strings.Join(chain, " -> "))
+		}
+	}
+
+	return nil
+}
+
 // Path is the full path to this tree.
 func (t *Tree) Path() []string {
 	return t.path
 }
 
+// AddrPrefix returns the prefix of the addresses of the objects declared by
+// the tree's module, such as "module.child." for the resource address
+// "module.child.aws_instance.web", or the empty string for the root module.
+func (t *Tree) AddrPrefix() string {
+	if len(t.path) == 0 {
+		return ""
+	}
+	return "module." + strings.Join(t.path, ".module.") + "."
+}
+
+// AsRoot returns a copy of the receiver that is the root of its own tree,
+// so that a child module can be used as if it were the root module. The
+// configurations are shared with the receiver but the paths of the copy
+// and its descendents are relative to the copy.
+func (t *Tree) AsRoot() *Tree {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.copyAt("", nil, nil)
+}
+
+func (t *Tree) copyAt(name string, path []string, parent *Tree) *Tree {
+	ret := &Tree{
+		name:    name,
+		config:  t.config,
+		path:    path,
+		version: t.version,
+		source:  t.source,
+		parent:  parent,
+	}
+	if t.children != nil {
+		ret.children = make(map[string]*Tree, len(t.children))
+		for k, c := range t.children {
+			childPath := make([]string, len(path), len(path)+1)
+			copy(childPath, path)
+			childPath = append(childPath, k)
+
+			c.lock.RLock()
+			ret.children[k] = c.copyAt(c.name, childPath, ret)
+			c.lock.RUnlock()
+		}
+	}
+	return ret
+}
+
 // String gives a nice output to describe the tree.
 func (t *Tree) String() string {
 	var result bytes.Buffer
@@ -412,9 +496,7 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 	// If we're the root, we do extra validation. This validation usually
 	// requires the entire tree (since children don't have parent pointers).
 	if len(t.path) == 0 {
-		if err := t.validateProviderAlias(); err != nil {
-			diags = diags.Append(err)
-		}
+		diags = diags.Append(t.validateProviderAlias())
 	}
 
 	// Get the child trees
@@ -441,8 +523,10 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 		// Build the variables that the module defines
 		requiredMap := make(map[string]struct{})
 		varMap := make(map[string]struct{})
+		varNames := make([]string, 0, len(tree.config.Variables))
 		for _, v := range tree.config.Variables {
 			varMap[v.Name] = struct{}{}
+			varNames = append(varNames, v.Name)
 
 			if v.Required() {
 				requiredMap[v.Name] = struct{}{}
@@ -452,9 +536,13 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 		// Compare to the keys in our raw config for the module
 		for k, _ := range m.RawConfig.Raw {
 			if _, ok := varMap[k]; !ok {
+				var suggestion string
+				if name := didyoumean.NameSuggestion(k, varNames); name != "" {
+					suggestion = fmt.Sprintf("; did you mean %q?", name)
+				}
 				diags = diags.Append(fmt.Errorf(
-					"module %q: %q is not a valid argument",
-					m.Name, k,
+					"module %q: %q is not a valid argument%s",
+					m.Name, k, suggestion,
 				))
 			}
 
@@ -497,6 +585,10 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 				}
 			}
 			if !found {
+				if strings.HasPrefix(mv.Field, "module.") {
+					diags = diags.Append(nestedModuleReferenceError(source, mv, tree))
+					continue
+				}
 				diags = diags.Append(fmt.Errorf(
 					"%s: %q is not a valid output for module %q",
 					source, mv.Field, mv.Name,
@@ -505,9 +597,69 @@ func (t *Tree) Validate() tfdiags.Diagnostics {
 		}
 	}
 
+	// The number of instances that a count produces is shown in plan output
+	// and recorded in state as part of the instance addresses, so a count
+	// derived from a sensitive output discloses its value.
+	for _, rc := range t.config.Resources {
+		if rc.RawCount == nil {
+			continue
+		}
+		source := fmt.Sprintf("resource '%s' count", rc.Id())
+
+		keys := make([]string, 0, len(rc.RawCount.Variables))
+		for k := range rc.RawCount.Variables {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			mv, ok := rc.RawCount.Variables[k].(*config.ModuleVariable)
+			if !ok {
+				continue
+			}
+			tree, ok := children[mv.Name]
+			if !ok {
+				continue
+			}
+			for _, o := range tree.config.Outputs {
+				if o.Name == mv.Field && o.Sensitive {
+					diags = diags.Append(tfdiags.SimpleWarning(fmt.Sprintf(
+						"%s: refers to the sensitive output %q of module %q; the value will be disclosed by the number of instances, which is shown in plan output",
+						source, mv.Field, mv.Name,
+					)))
+				}
+			}
+		}
+	}
+
 	return diags
 }
 
+// nestedModuleReferenceError returns the error for a reference such as
+// module.a.module.b.id, which reaches through the module called as a into
+// the module that it calls in turn. Only the outputs of a module can be
+// referred to by its caller, so the value must be exported by an output of
+// the child module, which is suggested if the child already has one.
+func nestedModuleReferenceError(source string, mv *config.ModuleVariable, child *Tree) error {
+	grandchild := strings.SplitN(strings.TrimPrefix(mv.Field, "module."), ".", 2)[0]
+	msg := fmt.Sprintf(
+		"%s: %s reaches through module %q into the module that it calls as %q, but only the outputs of module %q can be referred to",
+		source, mv.FullKey(), mv.Name, grandchild, mv.Name,
+	)
+
+	for _, o := range child.config.Outputs {
+		for _, v := range o.RawConfig.Variables {
+			if gv, ok := v.(*config.ModuleVariable); ok && gv.Name == grandchild {
+				return fmt.Errorf(
+					"%s; its output %q exports a value from module %q, and can be referred to as module.%s.%s",
+					msg, o.Name, grandchild, mv.Name, o.Name,
+				)
+			}
+		}
+	}
+	return fmt.Errorf("%s; add an output to module %q that exports the value, and refer to that instead", msg, mv.Name)
+}
+
 // versionedPathKey returns a path string with every levels full name, version
 // and source encoded. This is to provide a unique key for our module storage,
 // since submodules need to know which versions of their ancestor modules they
@@ -0,0 +1,83 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+)
+
+// ConfigFunc returns the configuration of the module called by the given
+// module call, whose path in the tree is given.
+type ConfigFunc func(path []string, m *Module) (*config.Config, error)
+
+// NewTreeFromConfig returns a loaded tree whose root module has the given
+// configuration, with the configuration of each module call found by calling
+// the given function, which may be nil if no module is called. The result
+// can be validated and used in the same way as a tree loaded from files,
+// but nothing is read from the filesystem or fetched, so it suits callers
+// that build their configurations in memory.
+//
+// The source ranges recorded in the configurations are kept as they are, so
+// the diagnostics that refer to them have whatever ranges were given.
+func NewTreeFromConfig(c *config.Config, fn ConfigFunc) (*Tree, error) {
+	t := NewTree("", c)
+	if err := t.loadConfigs(fn); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// loadConfigs sets the children of the tree, and of its descendents, to the
+// configurations returned by fn for its module calls.
+func (t *Tree) loadConfigs(fn ConfigFunc) error {
+	children := make(map[string]*Tree)
+	for _, m := range t.Modules() {
+		if _, ok := children[m.Name]; ok {
+			return fmt.Errorf(
+				"module %s: duplicated. module names must be unique", m.Name)
+		}
+
+		modPath := make([]string, len(t.path), len(t.path)+1)
+		copy(modPath, t.path)
+		modPath = append(modPath, m.Name)
+
+		var c *config.Config
+		if fn != nil {
+			var err error
+			c, err = fn(modPath, m)
+			if err != nil {
+				return fmt.Errorf("module %s: %s", strings.Join(modPath, "."), err)
+			}
+		}
+		if c == nil {
+			return fmt.Errorf("module %s: no configuration was given", strings.Join(modPath, "."))
+		}
+
+		child := NewTree(m.Name, c)
+		child.path = modPath
+		child.parent = t
+		child.version = m.Version
+		child.source = m.Source
+
+		// A configuration built in memory may have no directory to compare,
+		// so calls are also recursive if they return an ancestor's config.
+		for p := t; p != nil; p = p.parent {
+			if p.config == c {
+				return fmt.Errorf(
+					"module %s: calls itself recursively",
+					strings.Join(modPath, "."))
+			}
+		}
+		if err := child.checkRecursion(); err != nil {
+			return err
+		}
+		if err := child.loadConfigs(fn); err != nil {
+			return err
+		}
+		children[m.Name] = child
+	}
+
+	t.children = children
+	return nil
+}
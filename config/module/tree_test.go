@@ -63,6 +63,40 @@ func TestTreeChild(t *testing.T) {
 	}
 }
 
+func TestTreeAsRoot(t *testing.T) {
+	storage := testStorage(t, nil)
+	storage.Mode = GetModeGet
+	tree := NewTree("", testConfig(t, "child"))
+	if err := tree.Load(storage); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	foo := tree.Child([]string{"foo"})
+	root := foo.AsRoot()
+	if root.Name() != "root" {
+		t.Fatalf("bad: %#v", root.Name())
+	}
+	if len(root.Path()) != 0 {
+		t.Fatalf("bad: %#v", root.Path())
+	}
+	if root.Config() != foo.Config() {
+		t.Fatal("config should be shared")
+	}
+
+	if c := root.Child([]string{"bar"}); c == nil {
+		t.Fatal("should not be nil")
+	} else if c.Name() != "bar" {
+		t.Fatalf("bad: %#v", c.Name())
+	} else if !reflect.DeepEqual(c.Path(), []string{"bar"}) {
+		t.Fatalf("bad: %#v", c.Path())
+	}
+
+	// The original tree must be unchanged
+	if c := tree.Child([]string{"foo", "bar"}); !reflect.DeepEqual(c.Path(), []string{"foo", "bar"}) {
+		t.Fatalf("bad: %#v", c.Path())
+	}
+}
+
 func TestTreeLoad(t *testing.T) {
 	storage := testStorage(t, nil)
 	tree := NewTree("", testConfig(t, "basic"))
@@ -118,6 +152,86 @@ func TestTreeLoad_duplicate(t *testing.T) {
 	}
 }
 
+func TestTreeLoad_recursive(t *testing.T) {
+	storage := testStorage(t, nil)
+	tree := NewTree("", testConfig(t, "recursive"))
+
+	storage.Mode = GetModeGet
+	err := tree.Load(storage)
+	if err == nil {
+		t.Fatalf("should error")
+	}
+
+	expected := "module child.grandchild.again: calls itself recursively, through module.child -> module.child.module.grandchild -> module.child.module.grandchild.module.again"
+	if err.Error() != expected {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, expected)
+	}
+}
+
+func TestNewTreeFromConfig(t *testing.T) {
+	raw, err := config.NewRawConfig(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	root := &config.Config{
+		Modules: []*config.Module{
+			{Name: "network", Source: "./network", RawConfig: raw},
+		},
+	}
+	network := &config.Config{
+		Modules: []*config.Module{
+			{Name: "subnets", Source: "./subnets", RawConfig: raw},
+		},
+	}
+	subnets := &config.Config{}
+
+	configs := map[string]*config.Config{
+		"network":         network,
+		"network.subnets": subnets,
+	}
+	tree, err := NewTreeFromConfig(root, func(path []string, m *Module) (*config.Config, error) {
+		return configs[strings.Join(path, ".")], nil
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !tree.Loaded() {
+		t.Fatal("should be loaded")
+	}
+
+	child := tree.Child([]string{"network", "subnets"})
+	if child == nil {
+		t.Fatal("no child module at network.subnets")
+	}
+	if child.Config() != subnets {
+		t.Fatal("wrong config for network.subnets")
+	}
+	if got, want := child.Path(), []string{"network", "subnets"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong path %#v; want %#v", got, want)
+	}
+	if diags := tree.Validate(); diags.HasErrors() {
+		t.Fatalf("err: %s", diags.Err())
+	}
+
+	// A module call without a configuration is an error.
+	delete(configs, "network.subnets")
+	_, err = NewTreeFromConfig(root, func(path []string, m *Module) (*config.Config, error) {
+		return configs[strings.Join(path, ".")], nil
+	})
+	if got, want := fmt.Sprint(err), "module network.subnets: no configuration was given"; got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// So is one that returns the configuration of an ancestor.
+	configs["network.subnets"] = network
+	_, err = NewTreeFromConfig(root, func(path []string, m *Module) (*config.Config, error) {
+		return configs[strings.Join(path, ".")], nil
+	})
+	if got, want := fmt.Sprint(err), "module network.subnets: calls itself recursively"; got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
 func TestTreeLoad_copyable(t *testing.T) {
 	dir := tempDir(t)
 	storage := &Storage{
@@ -361,6 +475,26 @@ func TestTreeName(t *testing.T) {
 	}
 }
 
+func TestTreeAddrPrefix(t *testing.T) {
+	storage := testStorage(t, nil)
+	storage.Mode = GetModeGet
+	tree := NewTree("", testConfig(t, "child"))
+	if err := tree.Load(storage); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := map[string][]string{
+		"":                       nil,
+		"module.foo.":            {"foo"},
+		"module.foo.module.bar.": {"foo", "bar"},
+	}
+	for want, path := range cases {
+		if got := tree.Child(path).AddrPrefix(); got != want {
+			t.Errorf("wrong prefix for %#v\ngot:  %q\nwant: %q", path, got, want)
+		}
+	}
+}
+
 // This is a table-driven test for tree validation. This is the preferred
 // way to test Validate. Non table-driven tests exist historically but
 // that style shouldn't be done anymore.
@@ -382,6 +516,18 @@ func TestTreeValidate_table(t *testing.T) {
 			"alias must be defined",
 		},
 
+		{
+			"provider alias passed to child",
+			"validate-alias-providers",
+			"",
+		},
+
+		{
+			"explicit default provider without configuration",
+			"validate-alias-default",
+			"",
+		},
+
 		{
 			"root module named root",
 			"validate-module-root",
@@ -418,6 +564,38 @@ func TestTreeValidate_table(t *testing.T) {
 	}
 }
 
+func TestTreeValidate_providerAlias(t *testing.T) {
+	tree := NewTree("", testConfig(t, "validate-alias-bad"))
+	storage := testStorage(t, nil)
+	storage.Mode = GetModeGet
+	if err := tree.Load(storage); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	diags := tree.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics; want 1: %s", len(diags), diags.Err())
+	}
+	desc := diags[0].Description()
+	if got, want := desc.Summary, "Provider configuration not present"; got != want {
+		t.Errorf("wrong summary %q; want %q", got, want)
+	}
+	want := `module.child.aws_instance.foo uses the "aws" provider configuration aws.foo, which isn't defined by its module or by any module that calls it. Only default provider configurations are created implicitly, so the alias must be defined by a provider "aws" block with alias = "foo".`
+	if got := desc.Detail; got != want {
+		t.Errorf("wrong detail\ngot:  %s\nwant: %s", got, want)
+	}
+	subject := diags[0].Source().Subject
+	if subject == nil {
+		t.Fatal("diagnostic has no subject")
+	}
+	if got, want := filepath.Base(subject.Filename), "main.tf"; got != want {
+		t.Errorf("wrong subject file %q; want %q", got, want)
+	}
+	if got, want := subject.Start.Line, 1; got != want {
+		t.Errorf("wrong subject line %d; want %d", got, want)
+	}
+}
+
 func TestTreeValidate_badChild(t *testing.T) {
 	tree := NewTree("", testConfig(t, "validate-child-bad"))
 
@@ -4,115 +4,117 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/hashicorp/go-multierror"
-	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
-// validateProviderAlias validates that all provider alias references are
-// defined at some point in the parent tree. This improves UX by catching
-// alias typos at the slight cost of requiring a declaration of usage. This
-// is usually a good tradeoff since not many aliases are used.
-func (t *Tree) validateProviderAlias() error {
+// validateProviderAlias validates that each resource's provider
+// configuration can be resolved, in the same way as core resolves it when
+// building the graph: that any alias it refers to is defined by its own
+// module or by one of the modules that call it, or is passed in by the
+// providers argument of a module call on the way. This improves UX by
+// catching alias typos at the slight cost of requiring a declaration of
+// usage. This is usually a good tradeoff since not many aliases are used.
+//
+// Default (non-aliased) configurations can always be resolved, since core
+// creates an empty one where none is defined.
+func (t *Tree) validateProviderAlias() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
 	// If we're not the root, don't perform this validation. We must be the
 	// root since we require full tree visibilty.
 	if len(t.path) != 0 {
-		return nil
+		return diags
 	}
 
-	// We'll use a graph to keep track of defined aliases at each level.
-	// As long as a parent defines an alias, it is okay.
-	var g dag.AcyclicGraph
-	t.buildProviderAliasGraph(&g, nil)
-
-	// Go through the graph and check that the usage is all good.
-	var err error
-	for _, v := range g.Vertices() {
-		pv, ok := v.(*providerAliasVertex)
-		if !ok {
-			// This shouldn't happen, just ignore it.
-			continue
+	t.DeepEach(func(t *Tree) {
+		if t.config == nil {
+			return
 		}
 
-		// If we're not using any aliases, fast track and just continue
-		if len(pv.Used) == 0 {
-			continue
-		}
+		prefix := t.AddrPrefix()
 
-		// Grab the ancestors since we're going to have to check if our
-		// parents define any of our aliases.
-		var parents []*providerAliasVertex
-		ancestors, _ := g.Ancestors(v)
-		for _, raw := range ancestors.List() {
-			if pv, ok := raw.(*providerAliasVertex); ok {
-				parents = append(parents, pv)
-			}
-		}
-		for k, _ := range pv.Used {
-			// Check if we define this
-			if _, ok := pv.Defined[k]; ok {
+		for _, r := range t.config.Resources {
+			if !strings.Contains(r.Provider, ".") || t.providerAliasResolvable(r.Provider) {
 				continue
 			}
 
-			// Check for a parent
-			found := false
-			for _, parent := range parents {
-				_, found = parent.Defined[k]
-				if found {
-					break
-				}
+			typ := strings.SplitN(r.Provider, ".", 2)[0]
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Provider configuration not present",
+				Detail: fmt.Sprintf(
+					"%s%s uses the %q provider configuration %s, which isn't defined by its module or by any module that calls it. Only default provider configurations are created implicitly, so the alias must be defined by a provider %q block with alias = %q.",
+					prefix, r.Id(), typ, r.Provider, typ, strings.TrimPrefix(r.Provider, typ+"."),
+				),
 			}
-			if found {
-				continue
+			if def := t.defaultProviderConfig(typ); def != nil {
+				// The default block is most likely the alternative
+				// configuration with its alias missing.
+				diag.Detail += fmt.Sprintf(
+					" If the default %q provider block of this module is meant to be %s, set alias = %q in it.",
+					typ, r.Provider, strings.TrimPrefix(r.Provider, typ+"."),
+				)
 			}
-
-			// We didn't find the alias, error!
-			err = multierror.Append(err, fmt.Errorf(
-				"module %s: provider alias must be defined by the module: %s",
-				strings.Join(pv.Path, "."), k))
+			if r.DeclRange.Filename != "" {
+				diag.Subject = r.DeclRange.ToHCL().Ptr()
+			}
+			diags = diags.Append(diag)
 		}
-	}
+	})
 
-	return err
+	return diags
 }
 
-func (t *Tree) buildProviderAliasGraph(g *dag.AcyclicGraph, parent dag.Vertex) {
-	// Add all our defined aliases
-	defined := make(map[string]struct{})
+// defaultProviderConfig returns the default (non-aliased) configuration of
+// the given provider type that the receiver defines, or nil if it defines
+// none.
+func (t *Tree) defaultProviderConfig(typ string) *config.ProviderConfig {
 	for _, p := range t.config.ProviderConfigs {
-		defined[p.FullName()] = struct{}{}
+		if p.Name == typ && p.Alias == "" {
+			return p
+		}
 	}
+	return nil
+}
 
-	// Add all our used aliases
-	used := make(map[string]struct{})
-	for _, r := range t.config.Resources {
-		if r.Provider != "" {
-			used[r.Provider] = struct{}{}
+// providerAliasResolvable returns true if a resource in the receiver can
+// use the provider configuration with the given name, such as "aws.west",
+// because it is available in the receiver or inherited from one of the
+// modules that call it.
+func (t *Tree) providerAliasResolvable(name string) bool {
+	for p := t; p != nil; p = p.parent {
+		if p.providerAliasAvailable(name) {
+			return true
 		}
 	}
+	return false
+}
 
-	// Add it to the graph
-	vertex := &providerAliasVertex{
-		Path:    t.Path(),
-		Defined: defined,
-		Used:    used,
+// providerAliasAvailable returns true if the receiver defines the provider
+// configuration with the given name, or is passed one for it by the
+// providers argument of the module call that calls it.
+func (t *Tree) providerAliasAvailable(name string) bool {
+	if t.config == nil {
+		return false
 	}
-	g.Add(vertex)
-
-	// Connect to our parent if we have one
-	if parent != nil {
-		g.Connect(dag.BasicEdge(vertex, parent))
+	for _, p := range t.config.ProviderConfigs {
+		if p.FullName() == name {
+			return true
+		}
 	}
 
-	// Build all our children
-	for _, c := range t.Children() {
-		c.buildProviderAliasGraph(g, vertex)
+	if t.parent == nil || t.parent.config == nil {
+		return false
 	}
-}
-
-// providerAliasVertex is the vertex for the graph that keeps track of
-// defined provider aliases.
-type providerAliasVertex struct {
-	Path    []string
-	Defined map[string]struct{}
-	Used    map[string]struct{}
+	for _, m := range t.parent.config.Modules {
+		if m.Name != t.name {
+			continue
+		}
+		if parentName, ok := m.Providers[name]; ok {
+			return t.parent.providerAliasAvailable(parentName)
+		}
+	}
+	return false
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/convert"
@@ -168,10 +169,17 @@ func (r *RawConfig) Config() map[string]interface{} {
 //
 // If a variable key is missing, this will panic.
 func (r *RawConfig) Interpolate(vs map[string]ast.Variable) error {
+	return r.InterpolateWithClock(vs, nil)
+}
+
+// InterpolateWithClock is like Interpolate, but time-dependent functions
+// such as timestamp() use the given clock instead of the real one. If now
+// is nil then the real clock is used.
+func (r *RawConfig) InterpolateWithClock(vs map[string]ast.Variable, now func() time.Time) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	config := langEvalConfig(vs)
+	config := langEvalConfigWithClock(vs, now)
 	return r.interpolate(func(root ast.Node) (interface{}, error) {
 		// None of the variables we need are computed, meaning we should
 		// be able to properly evaluate.
@@ -439,6 +447,12 @@ type gobRawConfig struct {
 
 // langEvalConfig returns the evaluation configuration we use to execute.
 func langEvalConfig(vs map[string]ast.Variable) *hil.EvalConfig {
+	return langEvalConfigWithClock(vs, nil)
+}
+
+// langEvalConfigWithClock is like langEvalConfig, but with time-dependent
+// functions using the given clock if it is non-nil.
+func langEvalConfigWithClock(vs map[string]ast.Variable, now func() time.Time) *hil.EvalConfig {
 	funcMap := make(map[string]ast.Function)
 	for k, v := range Funcs() {
 		funcMap[k] = v
@@ -446,6 +460,9 @@ func langEvalConfig(vs map[string]ast.Variable) *hil.EvalConfig {
 	funcMap["lookup"] = interpolationFuncLookup(vs)
 	funcMap["keys"] = interpolationFuncKeys(vs)
 	funcMap["values"] = interpolationFuncValues(vs)
+	if now != nil {
+		funcMap["timestamp"] = interpolationFuncTimestamp(now)
+	}
 
 	return &hil.EvalConfig{
 		GlobalScope: &ast.BasicScope{
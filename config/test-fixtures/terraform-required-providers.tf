@@ -0,0 +1,9 @@
+terraform {
+  required_providers {
+    aws = "~> 1.0"
+
+    google = {
+      version = ">= 1.2"
+    }
+  }
+}
@@ -0,0 +1,7 @@
+resource aws_instance "web" {
+  ami = "ami-abcd1234"
+
+  lifecycle {
+    ignore_changes = ["*", "tags"]
+  }
+}
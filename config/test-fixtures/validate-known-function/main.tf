@@ -0,0 +1,8 @@
+resource "aws_instance" "foo" {
+  count = "${length(list("a", "b"))}"
+  ami   = "${upper("ami")}"
+}
+
+output "addr" {
+  value = "${join(",", aws_instance.foo.*.id)}"
+}
@@ -0,0 +1,8 @@
+module "consul" {
+  source  = "hashicorp/consul/aws"
+  version = "0.1.0"
+}
+
+module "local" {
+  source = "./child"
+}
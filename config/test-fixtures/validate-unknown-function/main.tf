@@ -0,0 +1,17 @@
+resource "aws_instance" "foo" {
+  ami  = "${uppper("ami")}"
+  tags = "${map("Name", lower("foo"))}"
+}
+
+output "addr" {
+  value = "${nosuchfunction(aws_instance.foo.id)}"
+}
+
+module "child" {
+  source = "./child"
+  name   = "${uppper("child")}"
+}
+
+locals {
+  name = "${titel("foo")}"
+}
@@ -0,0 +1,116 @@
+package configs
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// ExpressionScope describes the symbols that are available to an expression
+// checked with ValidateExpression. Only the types of the symbols are given,
+// since the expression is type-checked rather than evaluated.
+type ExpressionScope struct {
+	// Variables are the types of the input variables, by name, that can be
+	// referred to as var.NAME.
+	Variables map[string]cty.Type
+
+	// Locals are the types of the local values, by name, that can be
+	// referred to as local.NAME.
+	Locals map[string]cty.Type
+
+	// Resources are the schemas of the resources that can be referred to,
+	// by address, such as "aws_instance.foo" for a managed resource or
+	// "data.aws_ami.ubuntu" for a data resource. Entries whose keys are not
+	// of either form are ignored.
+	Resources map[string]*configschema.Block
+
+	// Functions are the functions that the expression can call, by name.
+	Functions map[string]function.Function
+}
+
+// ValidateExpression parses the given expression, in the native syntax,
+// and checks it against the given scope, returning the type of the value it
+// would produce along with any problems found, such as references to
+// symbols that are not in the scope, to attributes that resources do not
+// have, or operations on values of the wrong type.
+//
+// The values of the symbols in the scope are not known, and so the result
+// is cty.DynamicPseudoType where the type depends on them, and also where
+// there are errors. The given filename is used only in the source ranges of
+// the diagnostics.
+func ValidateExpression(src, filename string, scope *ExpressionScope) (cty.Type, hcl.Diagnostics) {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), filename, hcl.Pos{Line: 1, Column: 1, Byte: 0})
+	if diags.HasErrors() {
+		return cty.DynamicPseudoType, diags
+	}
+	if scope == nil {
+		scope = &ExpressionScope{}
+	}
+
+	val, valDiags := expr.Value(scope.evalContext())
+	diags = append(diags, valDiags...)
+	if diags.HasErrors() {
+		return cty.DynamicPseudoType, diags
+	}
+	return val.Type(), diags
+}
+
+// evalContext returns an evaluation context in which each symbol of the
+// scope has an unknown value of its type.
+func (s *ExpressionScope) evalContext() *hcl.EvalContext {
+	vars := map[string]cty.Value{
+		"var":   unknownObjectVal(s.Variables),
+		"local": unknownObjectVal(s.Locals),
+	}
+
+	// Resources are nested by type and then by name, with data resources
+	// nested again under "data".
+	managed := make(map[string]map[string]cty.Type)
+	data := make(map[string]map[string]cty.Type)
+	for addr, schema := range s.Resources {
+		parts := strings.Split(addr, ".")
+		byType := managed
+		if parts[0] == "data" {
+			byType, parts = data, parts[1:]
+		}
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" || schema == nil {
+			continue
+		}
+		if byType[parts[0]] == nil {
+			byType[parts[0]] = make(map[string]cty.Type)
+		}
+		byType[parts[0]][parts[1]] = schema.ImpliedType()
+	}
+	for typeName, names := range managed {
+		vars[typeName] = unknownObjectVal(names)
+	}
+	if len(data) > 0 {
+		dataVals := make(map[string]cty.Value, len(data))
+		for typeName, names := range data {
+			dataVals[typeName] = unknownObjectVal(names)
+		}
+		vars["data"] = cty.ObjectVal(dataVals)
+	}
+
+	return &hcl.EvalContext{
+		Variables: vars,
+		Functions: s.Functions,
+	}
+}
+
+// unknownObjectVal returns an object value whose attributes are unknown
+// values of the given types.
+func unknownObjectVal(types map[string]cty.Type) cty.Value {
+	if len(types) == 0 {
+		return cty.EmptyObjectVal
+	}
+	vals := make(map[string]cty.Value, len(types))
+	for name, ty := range types {
+		vals[name] = cty.UnknownVal(ty)
+	}
+	return cty.ObjectVal(vals)
+}
@@ -0,0 +1,110 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+func TestValidateExpression(t *testing.T) {
+	scope := &ExpressionScope{
+		Variables: map[string]cty.Type{
+			"name":  cty.String,
+			"count": cty.Number,
+		},
+		Locals: map[string]cty.Type{
+			"tags": cty.Map(cty.String),
+		},
+		Resources: map[string]*configschema.Block{
+			"aws_instance.web": {
+				Attributes: map[string]*configschema.Attribute{
+					"id":    {Type: cty.String, Computed: true},
+					"ports": {Type: cty.List(cty.Number), Optional: true},
+				},
+			},
+			"data.aws_ami.ubuntu": {
+				Attributes: map[string]*configschema.Attribute{
+					"image_id": {Type: cty.String, Computed: true},
+				},
+			},
+		},
+		Functions: map[string]function.Function{
+			"upper": stdlib.UpperFunc,
+		},
+	}
+
+	tests := map[string]struct {
+		src      string
+		wantType cty.Type
+		wantErr  string
+	}{
+		"variable": {
+			`var.name`,
+			cty.String,
+			``,
+		},
+		"template": {
+			`"${var.name}-${local.tags["env"]}"`,
+			cty.String,
+			``,
+		},
+		"arithmetic": {
+			`var.count + 1`,
+			cty.Number,
+			``,
+		},
+		"managed resource": {
+			`aws_instance.web.ports`,
+			cty.List(cty.Number),
+			``,
+		},
+		"data resource": {
+			`upper(data.aws_ami.ubuntu.image_id)`,
+			cty.String,
+			``,
+		},
+		"undeclared variable": {
+			`var.nope`,
+			cty.DynamicPseudoType,
+			`test.tf:1,4-9: Unsupported attribute; This object does not have an attribute named "nope".`,
+		},
+		"unknown resource attribute": {
+			`aws_instance.web.ami`,
+			cty.DynamicPseudoType,
+			`test.tf:1,17-21: Unsupported attribute; This object does not have an attribute named "ami".`,
+		},
+		"wrong operand type": {
+			`var.count + aws_instance.web.ports`,
+			cty.DynamicPseudoType,
+			`test.tf:1,13-35: Invalid operand; Unsuitable value for right operand: incorrect type; number required.`,
+		},
+		"syntax error": {
+			`var.name +`,
+			cty.DynamicPseudoType,
+			`test.tf:1,11-11: Invalid expression; Expected the start of an expression, but found an invalid expression token.`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotType, diags := ValidateExpression(test.src, "test.tf", scope)
+
+			if test.wantErr == "" {
+				assertNoDiagnostics(t, diags)
+			} else {
+				if len(diags) != 1 {
+					t.Fatalf("got %d diagnostics; want 1\n%s", len(diags), diags.Error())
+				}
+				if got := diags[0].Error(); got != test.wantErr {
+					t.Errorf("wrong diagnostic\ngot:  %s\nwant: %s", got, test.wantErr)
+				}
+			}
+			if !gotType.Equals(test.wantType) {
+				t.Errorf("wrong type\ngot:  %#v\nwant: %#v", gotType, test.wantType)
+			}
+		})
+	}
+}
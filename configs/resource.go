@@ -72,7 +72,7 @@ func decodeResourceBlock(block *hcl.Block) (*ManagedResource, hcl.Diagnostics) {
 	}
 
 	if attr, exists := content.Attributes["for_each"]; exists {
-		r.Count = attr.Expr
+		r.ForEach = attr.Expr
 	}
 
 	if attr, exists := content.Attributes["provider"]; exists {
@@ -267,7 +267,7 @@ func decodeDataBlock(block *hcl.Block) (*DataResource, hcl.Diagnostics) {
 	}
 
 	if attr, exists := content.Attributes["for_each"]; exists {
-		r.Count = attr.Expr
+		r.ForEach = attr.Expr
 	}
 
 	if attr, exists := content.Attributes["provider"]; exists {
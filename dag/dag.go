@@ -171,6 +171,58 @@ func (g *AcyclicGraph) Walk(cb WalkFunc) error {
 	return w.Wait()
 }
 
+// Layers returns the vertices of the graph grouped into topological layers,
+// using the same edge direction as Walk: the source of an edge depends on
+// its target.
+//
+// The first layer contains all of the vertices that have no dependencies,
+// and each subsequent layer contains the vertices whose dependencies all
+// appear in earlier layers. The vertices within each layer are sorted by
+// VertexName so that the result is deterministic.
+//
+// The graph must not contain cycles. If it does, an error is returned along
+// with the layers that could be computed before the cycle was encountered.
+//
+// Complexity: O(V+E), plus the cost of sorting each layer
+func (g *AcyclicGraph) Layers() ([][]Vertex, error) {
+	remaining := make(map[interface{}]int)
+	var current []Vertex
+	for _, v := range g.Vertices() {
+		deps := g.DownEdges(v).Len()
+		if deps == 0 {
+			current = append(current, v)
+			continue
+		}
+		remaining[hashcode(v)] = deps
+	}
+
+	var layers [][]Vertex
+	for len(current) > 0 {
+		sort.Sort(byVertexName(current))
+		layers = append(layers, current)
+
+		var next []Vertex
+		for _, v := range current {
+			for _, raw := range g.UpEdges(v).List() {
+				dependent := raw.(Vertex)
+				code := hashcode(dependent)
+				remaining[code]--
+				if remaining[code] == 0 {
+					delete(remaining, code)
+					next = append(next, dependent)
+				}
+			}
+		}
+		current = next
+	}
+
+	if len(remaining) > 0 {
+		return layers, fmt.Errorf("cannot compute layers: %d vertices are part of or depend on a cycle", len(remaining))
+	}
+
+	return layers, nil
+}
+
 // simple convenience helper for converting a dag.Set to a []Vertex
 func AsVertexList(s *Set) []Vertex {
 	rawList := s.List()
@@ -263,6 +263,55 @@ func TestAcyclicGraphDescendents(t *testing.T) {
 	}
 }
 
+func TestAcyclicGraphLayers(t *testing.T) {
+	var g AcyclicGraph
+	g.Add(1)
+	g.Add(2)
+	g.Add(3)
+	g.Add(4)
+	g.Add(5)
+	g.Connect(BasicEdge(3, 1))
+	g.Connect(BasicEdge(3, 2))
+	g.Connect(BasicEdge(4, 3))
+	g.Connect(BasicEdge(5, 1))
+
+	actual, err := g.Layers()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := [][]Vertex{
+		{1, 2},
+		{3, 5},
+		{4},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
+func TestAcyclicGraphLayers_cycle(t *testing.T) {
+	var g AcyclicGraph
+	g.Add(1)
+	g.Add(2)
+	g.Add(3)
+	g.Connect(BasicEdge(2, 1))
+	g.Connect(BasicEdge(2, 3))
+	g.Connect(BasicEdge(3, 2))
+
+	actual, err := g.Layers()
+	if err == nil {
+		t.Fatal("should error")
+	}
+
+	expected := [][]Vertex{
+		{1},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
 func TestAcyclicGraphWalk(t *testing.T) {
 	var g AcyclicGraph
 	g.Add(1)
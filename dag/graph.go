@@ -93,6 +93,20 @@ func (g *Graph) EdgesTo(v Vertex) []Edge {
 	return result
 }
 
+// Sources returns the vertices that have no inward edges, sorted by
+// VertexName.
+func (g *Graph) Sources() []Vertex {
+	var result []Vertex
+	for _, v := range g.Vertices() {
+		if g.UpEdges(v).Len() == 0 {
+			result = append(result, v)
+		}
+	}
+	sort.Sort(byVertexName(result))
+
+	return result
+}
+
 // HasVertex checks if the given Vertex is present in the graph.
 func (g *Graph) HasVertex(v Vertex) bool {
 	return g.vertices.Include(v)
@@ -161,6 +175,45 @@ func (g *Graph) Replace(original, replacement Vertex) bool {
 	return true
 }
 
+// Merge adds the vertices and edges of other to the graph. A vertex of
+// other that has the same name, as returned by VertexName, as a vertex
+// already in the graph is not added; its edges are connected to the
+// existing vertex instead. Edges that are in both graphs are added once.
+func (g *Graph) Merge(other *Graph) {
+	g.init()
+
+	defer g.debug.BeginOperation("Merge", "").End("")
+
+	byName := make(map[string]Vertex)
+	for _, v := range g.Vertices() {
+		byName[VertexName(v)] = v
+	}
+
+	// Map each vertex of other to the vertex that it becomes in the graph.
+	mapped := make(map[interface{}]Vertex)
+	for _, v := range other.Vertices() {
+		name := VertexName(v)
+		existing, ok := byName[name]
+		if !ok {
+			existing = g.Add(v)
+			byName[name] = existing
+		}
+		mapped[hashcode(v)] = existing
+	}
+
+	for _, e := range other.Edges() {
+		source := mapped[hashcode(e.Source())]
+		target := mapped[hashcode(e.Target())]
+
+		// Keep the original edge where possible, so that its type is kept.
+		if hashcode(source) == hashcode(e.Source()) && hashcode(target) == hashcode(e.Target()) {
+			g.Connect(e)
+		} else {
+			g.Connect(BasicEdge(source, target))
+		}
+	}
+}
+
 // RemoveEdge removes an edge from the graph.
 func (g *Graph) RemoveEdge(edge Edge) {
 	g.init()
@@ -2,6 +2,7 @@ package dag
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -80,6 +81,41 @@ func TestGraph_replaceSelf(t *testing.T) {
 	}
 }
 
+func TestGraph_merge(t *testing.T) {
+	a, b := &namedVertex{"a"}, &namedVertex{"b"}
+	var g Graph
+	g.Add(a)
+	g.Add(b)
+	g.Connect(BasicEdge(a, b))
+
+	// Distinct vertices with the same names as those in g, so that they
+	// are merged by name rather than by identity.
+	a2, b2, c := &namedVertex{"a"}, &namedVertex{"b"}, &namedVertex{"c"}
+	var other Graph
+	other.Add(a2)
+	other.Add(b2)
+	other.Add(c)
+	other.Connect(BasicEdge(a2, b2))
+	other.Connect(BasicEdge(b2, c))
+
+	g.Merge(&other)
+
+	actual := strings.TrimSpace(g.String())
+	expected := strings.TrimSpace(testGraphMergeStr)
+	if actual != expected {
+		t.Fatalf("bad: %s", actual)
+	}
+	if n := len(g.Vertices()); n != 3 {
+		t.Fatalf("wrong number of vertices %d; want 3", n)
+	}
+	if n := len(g.Edges()); n != 2 {
+		t.Fatalf("wrong number of edges %d; want 2", n)
+	}
+	if !g.HasVertex(a) || g.HasVertex(a2) {
+		t.Fatal("merged vertex should be the one already in the graph")
+	}
+}
+
 // This tests that connecting edges works based on custom Hashcode
 // implementations for uniqueness.
 func TestGraph_hashcode(t *testing.T) {
@@ -170,6 +206,25 @@ func TestGraphEdgesTo(t *testing.T) {
 	}
 }
 
+func TestGraphSources(t *testing.T) {
+	var g Graph
+	g.Add(1)
+	g.Add(2)
+	g.Add(3)
+	g.Add(4)
+	g.Add(5)
+	g.Connect(BasicEdge(4, 2))
+	g.Connect(BasicEdge(4, 3))
+	g.Connect(BasicEdge(2, 1))
+	g.Connect(BasicEdge(3, 1))
+
+	actual := g.Sources()
+	expected := []Vertex{4, 5}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
 type hashVertex struct {
 	code interface{}
 }
@@ -182,6 +237,14 @@ func (v *hashVertex) Name() string {
 	return fmt.Sprintf("%#v", v.code)
 }
 
+type namedVertex struct {
+	name string
+}
+
+func (v *namedVertex) Name() string {
+	return v.name
+}
+
 const testGraphBasicStr = `
 1
   3
@@ -208,6 +271,14 @@ const testGraphReplaceStr = `
   3
 `
 
+const testGraphMergeStr = `
+a
+  b
+b
+  c
+c
+`
+
 const testGraphReplaceSelfStr = `
 1
   2
@@ -234,6 +234,72 @@ func TestSchemaMapCoreConfigSchema(t *testing.T) {
 				BlockTypes: map[string]*configschema.NestedBlock{},
 			},
 		},
+		"force new": {
+			map[string]*Schema{
+				"ami": {
+					Type:     TypeString,
+					Required: true,
+					ForceNew: true,
+				},
+			},
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {
+						Type:     cty.String,
+						Required: true,
+						ForceNew: true,
+					},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{},
+			},
+		},
+		"internal": {
+			map[string]*Schema{
+				"state": {
+					Type:     TypeString,
+					Computed: true,
+					Internal: true,
+				},
+			},
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"state": {
+						Type:     cty.String,
+						Computed: true,
+						Internal: true,
+					},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{},
+			},
+		},
+		"replaced by": {
+			map[string]*Schema{
+				"old": {
+					Type:       TypeString,
+					Optional:   true,
+					Deprecated: "use new instead",
+					ReplacedBy: "new",
+				},
+				"new": {
+					Type:     TypeString,
+					Optional: true,
+				},
+			},
+			&configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"old": {
+						Type:       cty.String,
+						Optional:   true,
+						ReplacedBy: "new",
+					},
+					"new": {
+						Type:     cty.String,
+						Optional: true,
+					},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -176,6 +176,19 @@ type Schema struct {
 	// how to address the deprecation.
 	Deprecated string
 
+	// ReplacedBy is the key of the attribute in the same schema map that
+	// replaces this one, if any. It should be set only along with
+	// Deprecated, and allows Terraform to report an error when both the
+	// deprecated attribute and its replacement are set in configuration.
+	ReplacedBy string
+
+	// Internal marks a computed attribute as being for the provider's own
+	// use, such as for bookkeeping between operations, so that Terraform
+	// reports an error when configuration refers to it rather than using a
+	// value whose meaning isn't part of the resource's interface. It can be
+	// set only along with Computed.
+	Internal bool
+
 	// When Removed is set, this attribute has been removed from the schema
 	//
 	// Removed attributes can be left in the Schema to generate informative error
@@ -643,6 +656,10 @@ func (m schemaMap) InternalValidate(topSchemaMap schemaMap) error {
 			return fmt.Errorf("%s: ComputedWhen can only be set with Computed", k)
 		}
 
+		if v.Internal && (!v.Computed || v.Optional) {
+			return fmt.Errorf("%s: Internal can only be set with Computed, and not with Optional", k)
+		}
+
 		if len(v.ConflictsWith) > 0 && v.Required {
 			return fmt.Errorf("%s: ConflictsWith cannot be set with Required", k)
 		}
@@ -3554,6 +3554,29 @@ func TestSchemaMap_InternalValidate(t *testing.T) {
 			false,
 		},
 
+		"Internal and computed": {
+			map[string]*Schema{
+				"foo": &Schema{
+					Type:     TypeString,
+					Computed: true,
+					Internal: true,
+				},
+			},
+			false,
+		},
+
+		"Internal but optional": {
+			map[string]*Schema{
+				"foo": &Schema{
+					Type:     TypeString,
+					Optional: true,
+					Computed: true,
+					Internal: true,
+				},
+			},
+			true,
+		},
+
 		"Computed but has default": {
 			map[string]*Schema{
 				"foo": &Schema{
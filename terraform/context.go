@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform/tfdiags"
 
@@ -65,11 +66,151 @@ type ContextOpts struct {
 	Targets            []string
 	Variables          map[string]interface{}
 
+	// ValidateFailFast, if true, causes Validate to visit the graph one
+	// dependency layer at a time and to stop after the first layer that
+	// produces errors, rather than collecting errors from the whole graph.
+	ValidateFailFast bool
+
+	// ValidateSensitiveInterpolation, if true, causes Validate to warn when
+	// a sensitive module output is interpolated into a resource attribute
+	// that is likely to be displayed, such as a name or tags. This is a
+	// heuristic and so it is off by default.
+	ValidateSensitiveInterpolation bool
+
+	// ValidateReplacementChurn, if true, causes Validate to warn when a
+	// required attribute that forces replacement of its resource is set
+	// using a function that returns a different value on every run, such
+	// as timestamp(), so that the resource would be replaced on every
+	// apply. This is a heuristic and so it is off by default.
+	ValidateReplacementChurn bool
+
+	// ValidateIgnoredAttributes, if true, causes Validate to warn when a
+	// resource sets an attribute that is also listed in the ignore_changes
+	// of its lifecycle block, since the configured value then has no effect
+	// once the resource has been created. This is sometimes intended and so
+	// it is off by default.
+	ValidateIgnoredAttributes bool
+
+	// ValidateImpliedProviders, if true, causes Validate to warn about each
+	// resource whose provider isn't declared in the required_providers
+	// block of its module, and so is implied by the resource type. This is
+	// noisy for small configurations and so it is off by default.
+	ValidateImpliedProviders bool
+
+	// ValidateRedundantDependsOn, if true, causes Validate to warn about
+	// each depends_on entry of a resource that names a resource its
+	// configuration already refers to, since the reference implies the
+	// dependency anyway. Such entries are harmless and so it is off by
+	// default.
+	ValidateRedundantDependsOn bool
+
+	// ValidateMaxModuleDepth, if positive, is the number of levels that
+	// module calls can be nested before Validate warns about the modules
+	// nested more deeply. Modules that call themselves recursively are
+	// always an error, reported when the module tree is loaded.
+	ValidateMaxModuleDepth int
+
+	// ValidateMaxNestingDepth, if positive, is the number of levels that
+	// the blocks and the map values in the configuration of a resource can
+	// be nested before Validate reports them as too deeply nested, in place
+	// of the default of DefaultValidateMaxNestingDepth.
+	ValidateMaxNestingDepth int
+
+	// ValidateComputedPlaceholders, if true, causes Validate to check the
+	// arguments of resources that are set to a computed attribute of
+	// another resource as if the attribute had a placeholder value of the
+	// type in that resource's schema, rather than an unknown value, so
+	// that type mismatches between them are reported. This gives false
+	// positives for attributes whose values have shapes that are decided
+	// only at apply time, and so it is off by default.
+	ValidateComputedPlaceholders bool
+
+	// ValidateHiddenDependencies, if true, causes Validate to warn about
+	// pairs of resources that set identifier arguments to the same literal
+	// value without either referring to the other, since they may depend on
+	// each other through the identified object without an explicit
+	// depends_on. This is a heuristic with false positives, and so it is
+	// off by default.
+	ValidateHiddenDependencies bool
+
+	// ValidateSeverities, if non-empty, overrides the severities of the
+	// diagnostics returned by Validate that have the given rule identifiers,
+	// as returned by tfdiags.Rule, so that, for example, the warnings of a
+	// rule can be treated as errors. Diagnostics without a rule identifier
+	// always keep their severities.
+	ValidateSeverities map[string]tfdiags.Severity
+
+	// ValidateZeroCount, if true, causes Validate to warn about resources
+	// whose count is the literal 0, since they can never have instances.
+	// Setting count to 0 is sometimes used to disable a resource for the
+	// time being, and so it is off by default.
+	ValidateZeroCount bool
+
+	// ValidateTargetVersion, if set, is a version of Terraform, such as
+	// "0.9.11", for which Validate reports the language features used by
+	// the configuration that were introduced after that version, for
+	// configuration that must still be usable with it.
+	ValidateTargetVersion string
+
+	// ValidateClock, if non-nil, is used instead of the real clock by
+	// time-dependent interpolation functions such as timestamp() during
+	// Validate, so that their results are reproducible.
+	ValidateClock func() time.Time
+
+	// ValidateSchemasPath, if set, is the path of a file of provider schemas
+	// as written by WriteProviderSchemas, such as a snapshot generated with
+	// ExportProviderSchemas and committed alongside the configuration.
+	// Providers are then not started:
+	// configuration is validated against these schemas only, skipping the
+	// providers' own validation. A context created this way can be used
+	// only for Validate, and ProviderResolver is ignored.
+	ValidateSchemasPath string
+
+	// ValidateNonInteractive, if true, indicates that Validate is being run
+	// where there is no way to prompt for input, such as in automation, so
+	// that the errors for required variables without values say so.
+	ValidateNonInteractive bool
+
+	// ValidateProviders, if non-empty, limits Validate to the resources and
+	// data sources that belong to providers of the given types, such as
+	// "aws", so that the resources of a single provider can be checked
+	// quickly. Any targets are applied as well.
+	ValidateProviders []string
+
+	// ValidateChangedFiles, if non-empty, are the paths of files that have
+	// changed, such as those listed by "git diff", so that Validate checks
+	// only the resources declared in them and the resources that depend on
+	// those, along with any targets. The whole configuration is validated if
+	// the changes can't be attributed to resources, such as when variables
+	// or module calls have changed or a file other than a configuration file
+	// has changed within a module's directory.
+	ValidateChangedFiles []string
+
+	// ValidateTagPolicy, if non-nil, causes Validate to check the tags of
+	// each managed resource against the given policy.
+	ValidateTagPolicy *TagPolicy
+
+	// ValidateProviderOverrides, if non-empty, causes Validate to validate
+	// the resources at the given addresses, such as "aws_instance.foo" or
+	// "module.child.aws_instance.foo[0]", with the given provider
+	// configurations, such as "aws.west", in place of their own. This is
+	// useful for testing a migration between providers. It is an error for
+	// a resource to be overridden with a provider configuration that isn't
+	// in the module that contains it.
+	ValidateProviderOverrides map[string]string
+
 	// If non-nil, will apply as additional constraints on the provider
 	// plugins that will be requested from the provider resolver.
 	ProviderSHA256s    map[string][]byte
 	SkipProviderVerify bool
 
+	// ProviderVersions, if non-nil, are the versions of the provider
+	// plugins that ProviderResolver resolves, by provider type such as
+	// "aws". The resolver doesn't report the versions it chooses, so they
+	// must be given here to be recorded with the schemas returned by
+	// ResourceSchemas.
+	ProviderVersions map[string]string
+
 	UIInput UIInput
 }
 
@@ -106,10 +247,49 @@ type Context struct {
 	uiInput    UIInput
 	variables  map[string]interface{}
 
+	validateFailFast               bool
+	validateSensitiveInterpolation bool
+	validateReplacementChurn       bool
+	validateIgnoredAttributes      bool
+	validateClock                  func() time.Time
+	validateSchemaOnly             bool
+	validateNonInteractive         bool
+	validateProviders              []string
+	validateChangedFiles           []string
+	validateTagPolicy              *TagPolicy
+	validateImpliedProviders       bool
+	validateRedundantDependsOn     bool
+	validateMaxModuleDepth         int
+	validateMaxNestingDepth        int
+	validateComputedPlaceholders   bool
+	validateHiddenDependencies     bool
+	validateSeverities             map[string]tfdiags.Severity
+	validateZeroCount              bool
+	validateTargetVersion          string
+	validateProviderOverrides      map[string]string
+
+	// sharedProviders records the providers of the most recently built
+	// validate graph whose instances are shared with other providers.
+	sharedProviders map[string]string
+
+	resourceSchemas     map[string]*ResourceSchema
+	resourceSchemasLock sync.Mutex
+	vertexDiagnostics   map[string]tfdiags.Diagnostics
+	validateSummary     *ValidateSummary
+
+	// validateTimings, if non-nil, records the durations of the phases of
+	// Validate. It is set only by ValidateBenchmark.
+	validateTimings *ValidateTimings
+
+	// opts are the options that the context was created with, from which
+	// ValidateRecursive creates the contexts for the modules it calls.
+	opts *ContextOpts
+
 	l                   sync.Mutex // Lock acquired during any task
 	parallelSem         Semaphore
 	providerInputConfig map[string]map[string]interface{}
 	providerSHA256s     map[string][]byte
+	providerVersions    map[string]string
 	runLock             sync.Mutex
 	runCond             *sync.Cond
 	runContext          context.Context
@@ -179,7 +359,13 @@ func NewContext(opts *ContextOpts) (*Context, error) {
 
 	// Bind available provider plugins to the constraints in config
 	var providers map[string]ResourceProviderFactory
-	if opts.ProviderResolver != nil {
+	if opts.ValidateSchemasPath != "" {
+		schemas, err := ReadProviderSchemasFile(opts.ValidateSchemasPath)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading provider schemas: %s", err)
+		}
+		providers = schemaOnlyResourceProviderFactories(schemas)
+	} else if opts.ProviderResolver != nil {
 		var err error
 		deps := ModuleTreeDependencies(opts.Module, state)
 		reqd := deps.AllPluginRequirements()
@@ -199,6 +385,8 @@ func NewContext(opts *ContextOpts) (*Context, error) {
 		diff = &Diff{}
 	}
 
+	optsCopy := *opts
+
 	return &Context{
 		components: &basicComponentFactory{
 			providers:    providers,
@@ -215,9 +403,32 @@ func NewContext(opts *ContextOpts) (*Context, error) {
 		uiInput:   opts.UIInput,
 		variables: variables,
 
+		validateFailFast:               opts.ValidateFailFast,
+		validateSensitiveInterpolation: opts.ValidateSensitiveInterpolation,
+		validateReplacementChurn:       opts.ValidateReplacementChurn,
+		validateIgnoredAttributes:      opts.ValidateIgnoredAttributes,
+		validateTagPolicy:              opts.ValidateTagPolicy,
+		validateImpliedProviders:       opts.ValidateImpliedProviders,
+		validateRedundantDependsOn:     opts.ValidateRedundantDependsOn,
+		validateMaxModuleDepth:         opts.ValidateMaxModuleDepth,
+		validateMaxNestingDepth:        opts.ValidateMaxNestingDepth,
+		validateComputedPlaceholders:   opts.ValidateComputedPlaceholders,
+		validateHiddenDependencies:     opts.ValidateHiddenDependencies,
+		validateSeverities:             opts.ValidateSeverities,
+		validateZeroCount:              opts.ValidateZeroCount,
+		validateTargetVersion:          opts.ValidateTargetVersion,
+		validateProviderOverrides:      opts.ValidateProviderOverrides,
+		validateClock:                  opts.ValidateClock,
+		validateSchemaOnly:             opts.ValidateSchemasPath != "",
+		validateNonInteractive:         opts.ValidateNonInteractive,
+		validateProviders:              opts.ValidateProviders,
+		validateChangedFiles:           opts.ValidateChangedFiles,
+
 		parallelSem:         NewSemaphore(par),
 		providerInputConfig: make(map[string]map[string]interface{}),
 		providerSHA256s:     opts.ProviderSHA256s,
+		providerVersions:    opts.ProviderVersions,
+		opts:                &optsCopy,
 		sh:                  sh,
 	}, nil
 }
@@ -276,6 +487,14 @@ func (c *Context) Graph(typ GraphType, opts *ContextGraphOpts) (*Graph, error) {
 		case GraphTypeValidate:
 			// We need to set the provisioners so those can be validated
 			p.Provisioners = c.components.ResourceProvisioners()
+			p.ProviderInput = c.providerInputConfig
+			p.ValidateDestroy = c.destroy
+			p.ValidateProviders = c.validateProviders
+			p.ValidateProviderOverrides = c.validateProviderOverrides
+			p.ValidateMaxNestingDepth = c.validateMaxNestingDepth
+			p.ValidateComputedPlaceholders = c.validateComputedPlaceholders
+			c.sharedProviders = make(map[string]string)
+			p.SharedProviders = c.sharedProviders
 
 			b = ValidateGraphBuilder(p)
 		}
@@ -683,9 +902,25 @@ func (c *Context) Stop() {
 
 // Validate validates the configuration and returns any warnings or errors.
 func (c *Context) Validate() tfdiags.Diagnostics {
+	diags, _ := c.validate()
+	return diags
+}
+
+// validate implements Validate and ValidateByModule, returning the
+// diagnostics both as a flat list and grouped by module.
+func (c *Context) validate() (result tfdiags.Diagnostics, tree *ModuleDiagnosticsTree) {
 	defer c.acquireRun("validate")()
+	if len(c.validateSeverities) > 0 {
+		defer func() {
+			result = remapSeverities(result, c.validateSeverities)
+			tree.remapSeverities(c.validateSeverities)
+			c.validateSummary.count(result)
+		}()
+	}
 
 	var diags tfdiags.Diagnostics
+	start := time.Now()
+	began := start
 
 	// Validate the configuration itself
 	diags = diags.Append(c.module.Validate())
@@ -694,43 +929,175 @@ func (c *Context) Validate() tfdiags.Diagnostics {
 	// variables are validated in the module tree.
 	if config := c.module.Config(); config != nil {
 		// Validate the user variables
-		for _, err := range smcUserVariables(config, c.variables) {
+		diags = diags.Append(smcMissingVariableDiags(config, c.variables, c.validateNonInteractive))
+		for _, err := range smcUserVariableTypes(config, c.variables) {
 			diags = diags.Append(err)
 		}
 	}
 
+	// A resource that refers to itself through local values would produce
+	// a cycle when building the graph, so we check for that first in order
+	// to report the whole chain of references.
+	for _, err := range c.validateSelfReferencesThroughLocals() {
+		diags = diags.Append(err)
+	}
+	diags = diags.Append(c.validateTriggerSelfReferences())
+
+	// So would a provider configuration that refers to one of its own
+	// resources, which deserves an explanation of its own.
+	diags = diags.Append(c.validateProviderResourceCycles())
+
+	// Targets that match nothing in the configuration are most likely
+	// typos, which would otherwise silently produce an empty graph.
+	for _, err := range c.validateTargets() {
+		diags = diags.Append(err)
+	}
+
 	// If we have errors at this point, the graphing has no chance,
 	// so just bail early.
 	if diags.HasErrors() {
-		return diags
+		c.validateSummary = newValidateSummary(diags, nil, time.Since(began))
+		return diags, newModuleDiagnosticsTree(diags, nil, nil)
+	}
+
+	// When only some files have changed, only the resources they declare
+	// and the resources that depend on them need to be validated.
+	if len(c.validateChangedFiles) > 0 {
+		changed, complete, err := c.changedFileTargets(c.validateChangedFiles)
+		if err != nil {
+			diags = diags.Append(err)
+			c.validateSummary = newValidateSummary(diags, nil, time.Since(began))
+			return diags, newModuleDiagnosticsTree(diags, nil, nil)
+		}
+		if complete && len(changed) == 0 && len(c.targets) == 0 {
+			c.validateSummary = newValidateSummary(diags, nil, time.Since(began))
+			return diags, newModuleDiagnosticsTree(diags, nil, nil)
+		}
+		if complete {
+			targets := c.targets
+			c.targets = append(append([]string(nil), targets...), changed...)
+			defer func() { c.targets = targets }()
+		}
 	}
 
 	// Build the graph so we can walk it and run Validate on nodes.
 	// We also validate the graph generated here, but this graph doesn't
 	// necessarily match the graph that Plan will generate, so we'll validate the
 	// graph again later after Planning.
+	c.validateTimings.add(validatePhaseStatic, time.Since(start))
+	start = time.Now()
 	graph, err := c.Graph(GraphTypeValidate, nil)
+	c.validateTimings.add(validatePhaseGraph, time.Since(start))
 	if err != nil {
 		diags = diags.Append(err)
-		return diags
+		diags = diags.Append(c.validateModuleCallCycles())
+		c.validateSummary = newValidateSummary(diags, nil, time.Since(began))
+		return diags, newModuleDiagnosticsTree(diags, nil, nil)
 	}
 
+	// Without provider plugins, the walk can't get far for a provider that
+	// has no schema, so we check that they all have them first.
+	if c.validateSchemaOnly {
+		diags = diags.Append(c.validateSchemaProviders(graph))
+		if diags.HasErrors() {
+			c.validateSummary = newValidateSummary(diags, nil, time.Since(began))
+			return diags, newModuleDiagnosticsTree(diags, nil, nil)
+		}
+	}
+
+	// Record the schemas used to validate resources afresh.
+	c.resourceSchemasLock.Lock()
+	c.resourceSchemas = make(map[string]*ResourceSchema)
+	c.resourceSchemasLock.Unlock()
+
 	// Walk
-	walker, err := c.walk(graph, walkValidate)
+	var walker *ContextGraphWalker
+	stoppedLayer := -1
+	start = time.Now()
+	if c.validateFailFast {
+		walker, stoppedLayer, err = c.walkLayers(graph, walkValidate)
+	} else {
+		walker, err = c.walk(graph, walkValidate)
+	}
+	c.validateTimings.add(validatePhaseWalk, time.Since(start))
 	if err != nil {
 		diags = diags.Append(err)
 	}
 
-	sort.Strings(walker.ValidationWarnings)
-	sort.Slice(walker.ValidationErrors, func(i, j int) bool {
-		return walker.ValidationErrors[i].Error() < walker.ValidationErrors[j].Error()
+	// Diagnostics that don't come from the vertices of the graph belong to
+	// the root module when grouped.
+	preDiags := append(tfdiags.Diagnostics(nil), diags...)
+	diags = diags.Append(validationDiagnostics(walker.ValidationWarnings, walker.ValidationErrors))
+	c.vertexDiagnostics = walker.vertexDiagnostics
+
+	var moreDiags tfdiags.Diagnostics
+	moreDiags = moreDiags.Append(c.validateTerraformBlocks())
+	moreDiags = moreDiags.Append(c.validateCountUnknowns())
+	moreDiags = moreDiags.Append(c.validateInternalReferences())
+	if c.validateSensitiveInterpolation {
+		moreDiags = moreDiags.Append(c.validateSensitiveInterpolations())
+	}
+	if c.validateReplacementChurn {
+		moreDiags = moreDiags.Append(c.validateReplacementChurns())
+	}
+	if c.validateIgnoredAttributes {
+		moreDiags = moreDiags.Append(c.validateIgnoredAttributeAssignments())
+	}
+	if c.validateTagPolicy != nil {
+		moreDiags = moreDiags.Append(c.validateTagPolicies())
+	}
+	if c.validateImpliedProviders {
+		moreDiags = moreDiags.Append(c.validateImpliedProviderResources())
+	}
+	if c.validateRedundantDependsOn {
+		moreDiags = moreDiags.Append(c.validateRedundantDependencies())
+	}
+	if c.validateMaxModuleDepth > 0 {
+		moreDiags = moreDiags.Append(c.validateModuleDepths())
+	}
+	if c.validateHiddenDependencies {
+		moreDiags = moreDiags.Append(c.validateHiddenDependencyPairs())
+	}
+	if c.validateZeroCount {
+		moreDiags = moreDiags.Append(c.validateZeroCounts())
+	}
+	if c.validateTargetVersion != "" {
+		moreDiags = moreDiags.Append(c.validateTargetVersionFeatures())
+	}
+
+	if c.validateSchemaOnly {
+		moreDiags = moreDiags.Append(tfdiags.SimpleWarning(
+			"Provider plugins were not started, so the configuration was validated " +
+				"against the provider schemas only; provider-side validation was skipped."))
+	}
+
+	if stoppedLayer >= 0 {
+		moreDiags = moreDiags.Append(tfdiags.SimpleWarning(fmt.Sprintf(
+			"Validation stopped after dependency layer %d because it produced errors; "+
+				"objects that depend on it were not validated.", stoppedLayer+1)))
+	}
+	diags = diags.Append(moreDiags)
+
+	c.validateSummary = newValidateSummary(diags, walker, time.Since(began))
+	return diags, newModuleDiagnosticsTree(preDiags, moreDiags, walker.moduleValidations)
+}
+
+// validationDiagnostics converts the given validation warnings and errors
+// from a graph walk to diagnostics, with the warnings first and each sorted
+// by message so that the result is deterministic.
+func validationDiagnostics(warnings []string, errs []error) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	sort.Strings(warnings)
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
 	})
 
-	for _, warn := range walker.ValidationWarnings {
+	for _, warn := range warnings {
 		diags = diags.Append(tfdiags.SimpleWarning(warn))
 	}
-	for _, err := range walker.ValidationErrors {
-		diags = diags.Append(err)
+	for _, err := range errs {
+		diags = diags.Append(validateErrorDiagnostics(err))
 	}
 
 	return diags
@@ -833,6 +1200,43 @@ func (c *Context) walk(graph *Graph, operation walkOperation) (*ContextGraphWalk
 	return walker, realErr
 }
 
+// walkLayers is like walk except that the graph is walked one dependency
+// layer at a time and the walk stops after the first layer that records
+// any validation errors, after applying ContextOpts.ValidateSeverities.
+//
+// The returned layer is the zero-based index of the layer that caused the
+// walk to stop, or -1 if all layers were walked.
+func (c *Context) walkLayers(graph *Graph, operation walkOperation) (*ContextGraphWalker, int, error) {
+	log.Printf("[DEBUG] Starting layered graph walk: %s", operation.String())
+
+	walker := &ContextGraphWalker{
+		Context:     c,
+		Operation:   operation,
+		StopContext: c.runContext,
+	}
+
+	watchStop, watchWait := c.watchStop(walker)
+
+	stoppedLayer := -1
+	err := graph.WalkLayers(walker, func(layer int) bool {
+		walker.errorLock.Lock()
+		defer walker.errorLock.Unlock()
+
+		for _, err := range walker.ValidationErrors {
+			if remapSeverities(validateErrorDiagnostics(err), c.validateSeverities).HasErrors() {
+				stoppedLayer = layer
+				return true
+			}
+		}
+		return false
+	})
+
+	close(watchStop)
+	<-watchWait
+
+	return walker, stoppedLayer, err
+}
+
 // watchStop immediately returns a `stop` and a `wait` chan after dispatching
 // the watchStop goroutine. This will watch the runContext for cancellation and
 // stop the providers accordingly.  When the watch is no longer needed, the
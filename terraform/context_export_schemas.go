@@ -0,0 +1,63 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ExportProviderSchemas returns the complete schemas of each of the
+// providers required by the context's configuration and state, including
+// all of their resource types and data sources, so that they can be written
+// with WriteProviderSchemas.
+//
+// This is how a snapshot of the schemas for use with
+// ContextOpts.ValidateSchemasPath is generated and regenerated. The context
+// must have been created with the provider plugins, rather than with a
+// snapshot.
+func (c *Context) ExportProviderSchemas() (ProviderSchemas, error) {
+	reqd := ModuleTreeDependencies(c.module, c.state).AllPluginRequirements()
+	names := make([]string, 0, len(reqd))
+	for name := range reqd {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	schemas := make(ProviderSchemas, len(names))
+	for _, name := range names {
+		schema, err := c.exportProviderSchema(name)
+		if err != nil {
+			return nil, err
+		}
+		schemas[name] = schema
+	}
+	return schemas, nil
+}
+
+// exportProviderSchema returns the complete schema of the provider with the
+// given type name.
+func (c *Context) exportProviderSchema(name string) (*ProviderSchema, error) {
+	p, err := c.components.ResourceProvider(name, "export-schemas."+name)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start provider %q: %s", name, err)
+	}
+	if closer, ok := p.(ResourceProviderCloser); ok {
+		defer closer.Close()
+	}
+
+	req := &ProviderSchemaRequest{}
+	for _, rt := range p.Resources() {
+		req.ResourceTypes = append(req.ResourceTypes, rt.Name)
+	}
+	for _, ds := range p.DataSources() {
+		req.DataSources = append(req.DataSources, ds.Name)
+	}
+
+	schema, err := p.GetSchema(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the schema of provider %q: %s", name, err)
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("Provider %q does not support schemas", name)
+	}
+	return schema, nil
+}
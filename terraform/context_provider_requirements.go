@@ -0,0 +1,88 @@
+package terraform
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/moduledeps"
+	"github.com/hashicorp/terraform/plugin/discovery"
+)
+
+// ProviderRequirement describes everything that the configuration and
+// state associated with a context require of a single provider type.
+type ProviderRequirement struct {
+	// Versions is the combination of all of the version constraints in
+	// Sources, which a plugin must satisfy to be used for this provider.
+	Versions discovery.Constraints
+
+	// Sources records each module that contributed to the requirement,
+	// sorted by module path and then by provider name.
+	Sources []ProviderRequirementSource
+}
+
+// ProviderRequirementSource describes the dependency of a single module on
+// a single provider configuration.
+type ProviderRequirementSource struct {
+	// Path is the path of the module that has the dependency, starting with
+	// "root".
+	Path []string
+
+	// Provider is the provider name, including the alias if any, such
+	// as "aws.west".
+	Provider moduledeps.ProviderInstance
+
+	// Constraints is the version constraint given in the module, which is
+	// unconstrained for any dependency other than an explicit one.
+	Constraints discovery.Constraints
+
+	// Reason describes how the dependency was detected: from a provider
+	// block, from the type of a resource, or from the state.
+	Reason moduledeps.ProviderDependencyReason
+}
+
+// ProviderRequirements returns the provider requirements of the
+// configuration and state associated with the context, keyed by provider
+// type.
+//
+// Both explicit requirements from provider blocks and requirements implied
+// by resource types are included, as returned by ModuleTreeDependencies. The
+// result is the same whether or not Validate has been called, but it is
+// only meaningful for a configuration that is valid.
+func (c *Context) ProviderRequirements() map[string]*ProviderRequirement {
+	deps := ModuleTreeDependencies(c.module, c.state)
+
+	ret := make(map[string]*ProviderRequirement)
+	deps.WalkTree(func(path []string, parent *moduledeps.Module, current *moduledeps.Module) error {
+		for inst, dep := range current.Providers {
+			req, exists := ret[inst.Type()]
+			if !exists {
+				req = &ProviderRequirement{
+					Versions: discovery.Constraints{},
+				}
+				ret[inst.Type()] = req
+			}
+
+			req.Versions = req.Versions.Append(dep.Constraints)
+			req.Sources = append(req.Sources, ProviderRequirementSource{
+				Path:        append([]string(nil), path...),
+				Provider:    inst,
+				Constraints: dep.Constraints,
+				Reason:      dep.Reason,
+			})
+		}
+		return nil
+	})
+
+	for _, req := range ret {
+		sources := req.Sources
+		sort.Slice(sources, func(i, j int) bool {
+			pi, pj := strings.Join(sources[i].Path, "."), strings.Join(sources[j].Path, ".")
+			if pi != pj {
+				return pi < pj
+			}
+			return sources[i].Provider < sources[j].Provider
+		})
+	}
+
+	return ret
+}
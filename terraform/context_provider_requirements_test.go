@@ -0,0 +1,50 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/terraform/moduledeps"
+)
+
+func TestContextProviderRequirements(t *testing.T) {
+	p := testProvider("foo")
+	m := testModule(t, "context-provider-requirements")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"foo": testProviderFuncFixed(p),
+				"baz": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	reqs := c.ProviderRequirements()
+
+	got := make(map[string][]string)
+	for name, req := range reqs {
+		got[name] = append(got[name], req.Versions.String())
+		for _, src := range req.Sources {
+			got[name] = append(got[name], fmt.Sprintf("%v %s %q %d", src.Path, src.Provider, src.Constraints.String(), src.Reason))
+		}
+	}
+
+	want := map[string][]string{
+		"baz": {
+			"",
+			fmt.Sprintf("[root] baz %q %d", "", moduledeps.ProviderDependencyImplicit),
+		},
+		"foo": {
+			"<2.0.0,>=1.0.0",
+			fmt.Sprintf("[root] foo %q %d", ">=1.0.0", moduledeps.ProviderDependencyExplicit),
+			fmt.Sprintf("[root child] foo %q %d", "", moduledeps.ProviderDependencyInherited),
+			fmt.Sprintf("[root child] foo.bar %q %d", "<2.0.0", moduledeps.ProviderDependencyExplicit),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\ngot: %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
@@ -0,0 +1,76 @@
+package terraform
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform/config/module"
+)
+
+// AttributeCoverage is the number of the optional attributes of a
+// resource's type that its configuration sets, as returned by
+// Context.ResourceAttributeCoverage.
+type AttributeCoverage struct {
+	// Available is the number of top-level attributes in the schema of the
+	// resource type that may be set in configuration but aren't required,
+	// including those that the provider computes if they aren't set.
+	// Required and computed-only attributes aren't counted.
+	Available int
+
+	// Set is the number of the available attributes that the resource's
+	// configuration sets, and Unset are the sorted names of the others.
+	Set   int
+	Unset []string
+}
+
+// Ratio returns the fraction of the available attributes that are set, or
+// 1 if the resource type has no optional attributes.
+func (c *AttributeCoverage) Ratio() float64 {
+	if c.Available == 0 {
+		return 1
+	}
+	return float64(c.Set) / float64(c.Available)
+}
+
+// ResourceAttributeCoverage returns how many of the optional attributes of
+// each resource's type are set in its configuration, keyed by resource
+// address as for ResourceSchemas, using the schemas recorded during the
+// most recent call to Validate. Resources without a recorded schema are not
+// included. Attributes are counted as set whether their values are literal
+// or interpolated.
+func (c *Context) ResourceAttributeCoverage() map[string]*AttributeCoverage {
+	schemas := c.ResourceSchemas()
+	ret := make(map[string]*AttributeCoverage)
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		for _, rc := range cfg.Resources {
+			schema, ok := schemas[prefix+rc.Id()]
+			if !ok || schema.Block == nil {
+				continue
+			}
+
+			coverage := &AttributeCoverage{}
+			for name, attr := range schema.Block.Attributes {
+				if !attr.Optional {
+					continue
+				}
+				coverage.Available++
+				if _, set := rc.RawConfig.Raw[name]; set {
+					coverage.Set++
+				} else {
+					coverage.Unset = append(coverage.Unset, name)
+				}
+			}
+			sort.Strings(coverage.Unset)
+			ret[prefix+rc.Id()] = coverage
+		}
+	})
+
+	return ret
+}
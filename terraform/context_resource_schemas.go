@@ -0,0 +1,79 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform/config/configschema"
+)
+
+// ResourceSchema is the schema that was used to validate a resource.
+type ResourceSchema struct {
+	// Provider is the resolved name of the provider configuration that
+	// validated the resource, such as "provider.aws.west", or
+	// "module.child.provider.aws" for a configuration in a child module.
+	Provider string
+
+	// ProviderVersion is the version of the provider plugin that validated
+	// the resource, as given for its provider type by
+	// ContextOpts.ProviderVersions, or the empty string if it isn't known.
+	ProviderVersion string
+
+	// Block is the provider's schema for the resource type.
+	Block *configschema.Block
+}
+
+// ResourceSchemas returns the schema that was used to validate each resource
+// during the most recent call to Validate, keyed by resource address, such
+// as "module.child.aws_instance.foo". Resources whose providers don't
+// support schemas are not included.
+//
+// The result is a copy, and so it may be modified by the caller.
+func (c *Context) ResourceSchemas() map[string]*ResourceSchema {
+	c.resourceSchemasLock.Lock()
+	defer c.resourceSchemasLock.Unlock()
+
+	ret := make(map[string]*ResourceSchema, len(c.resourceSchemas))
+	for k, v := range c.resourceSchemas {
+		ret[k] = &ResourceSchema{
+			Provider:        v.Provider,
+			ProviderVersion: c.providerVersions[providerTypeName(v.Provider)],
+			Block:           copySchemaBlock(v.Block),
+		}
+	}
+	return ret
+}
+
+// providerTypeName returns the provider type of the provider configuration
+// with the given resolved name, such as "aws" for "provider.aws.west" or
+// "module.child.provider.aws".
+func providerTypeName(name string) string {
+	if i := strings.LastIndex(name, "provider."); i >= 0 {
+		name = name[i+len("provider."):]
+	}
+	return strings.SplitN(name, ".", 2)[0]
+}
+
+// copySchemaBlock returns a deep copy of the given schema block.
+func copySchemaBlock(b *configschema.Block) *configschema.Block {
+	if b == nil {
+		return nil
+	}
+
+	ret := &configschema.Block{}
+	if b.Attributes != nil {
+		ret.Attributes = make(map[string]*configschema.Attribute, len(b.Attributes))
+		for k, v := range b.Attributes {
+			attr := *v
+			ret.Attributes[k] = &attr
+		}
+	}
+	if b.BlockTypes != nil {
+		ret.BlockTypes = make(map[string]*configschema.NestedBlock, len(b.BlockTypes))
+		for k, v := range b.BlockTypes {
+			nested := *v
+			nested.Block = *copySchemaBlock(&v.Block)
+			ret.BlockTypes[k] = &nested
+		}
+	}
+	return ret
+}
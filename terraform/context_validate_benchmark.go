@@ -0,0 +1,165 @@
+package terraform
+
+import (
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ValidateTimings are the durations of the phases of validating a
+// configuration, as measured by ValidateBenchmark.
+type ValidateTimings struct {
+	// Load is the time taken to load the configuration and its modules.
+	Load time.Duration
+
+	// Context is the time taken by NewContext, which includes resolving
+	// the provider plugins or reading the provider schemas.
+	Context time.Duration
+
+	// Static is the time taken by the checks that are made before the
+	// graph is built.
+	Static time.Duration
+
+	// Graph is the time taken to build the validate graph, and Walk is the
+	// time taken to walk it.
+	Graph time.Duration
+	Walk  time.Duration
+
+	// Expand is the time spent expanding resources into their instances
+	// during the walk, and ResourceValidation is the time spent validating
+	// resource instances, including against their providers' schemas.
+	// Since vertices are walked concurrently these are totals across all
+	// vertices, and so they may be greater than Walk.
+	Expand             time.Duration
+	ResourceValidation time.Duration
+
+	// Allocs and Bytes are the number of heap objects and bytes allocated
+	// during the whole run.
+	Allocs uint64
+	Bytes  uint64
+
+	lock sync.Mutex
+}
+
+// validatePhase identifies one of the durations of ValidateTimings.
+type validatePhase int
+
+const (
+	validatePhaseStatic validatePhase = iota
+	validatePhaseGraph
+	validatePhaseWalk
+	validatePhaseExpand
+	validatePhaseResourceValidation
+)
+
+// add adds the given duration to the given phase. It does nothing if the
+// receiver is nil, so that callers needn't check whether timings are being
+// recorded.
+func (t *ValidateTimings) add(phase validatePhase, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch phase {
+	case validatePhaseStatic:
+		t.Static += d
+	case validatePhaseGraph:
+		t.Graph += d
+	case validatePhaseWalk:
+		t.Walk += d
+	case validatePhaseExpand:
+		t.Expand += d
+	case validatePhaseResourceValidation:
+		t.ResourceValidation += d
+	}
+}
+
+// evalTimed is an EvalNode that adds the time taken to evaluate the wrapped
+// node to the given phase of the given timings.
+type evalTimed struct {
+	Node    EvalNode
+	Timings *ValidateTimings
+	Phase   validatePhase
+}
+
+func (n *evalTimed) Eval(ctx EvalContext) (interface{}, error) {
+	start := time.Now()
+	defer func() { n.Timings.add(n.Phase, time.Since(start)) }()
+
+	return EvalRaw(n.Node, ctx)
+}
+
+// ValidateBenchmark loads the configuration in the given directory and
+// validates it as for Context.Validate, returning the diagnostics along with
+// the time taken by each phase. It is intended for use in benchmarks.
+//
+// Modules are loaded using the given storage. If it is nil, the modules
+// must already be installed in the ".terraform/modules" directory within dir,
+// as for "terraform init", and none are downloaded. The module tree in opts
+// is ignored, and the other options are used as for NewContext: with
+// ValidateSchemasPath set, no provider plugins are started either, and so
+// the whole run needs no network access.
+func ValidateBenchmark(dir string, s *module.Storage, opts *ContextOpts) (*ValidateTimings, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	timings := &ValidateTimings{}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	mod, err := loadModuleDir(dir, s)
+	if err != nil {
+		diags = diags.Append(err)
+		return timings, diags
+	}
+	timings.Load = time.Since(start)
+
+	ctxOpts := *opts
+	ctxOpts.Module = mod
+
+	start = time.Now()
+	ctx, err := NewContext(&ctxOpts)
+	if err != nil {
+		diags = diags.Append(err)
+		return timings, diags
+	}
+	timings.Context = time.Since(start)
+
+	ctx.validateTimings = timings
+	diags = diags.Append(ctx.Validate())
+
+	runtime.ReadMemStats(&after)
+	timings.Allocs = after.Mallocs - before.Mallocs
+	timings.Bytes = after.TotalAlloc - before.TotalAlloc
+
+	return timings, diags
+}
+
+// loadModuleDir loads the module tree rooted at the given directory, using
+// the given storage to find the modules it calls. If s is nil, the modules
+// already installed under the directory's .terraform/modules are used and
+// no modules are fetched.
+func loadModuleDir(dir string, s *module.Storage) (*module.Tree, error) {
+	if s == nil {
+		s = &module.Storage{
+			StorageDir: filepath.Join(dir, ".terraform", "modules"),
+			Mode:       module.GetModeNone,
+		}
+	}
+
+	mod, err := module.NewTreeModule("", dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := mod.Load(s); err != nil {
+		return nil, err
+	}
+	return mod, nil
+}
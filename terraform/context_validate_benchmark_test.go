@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform/config/module"
+)
+
+func TestValidateBenchmark(t *testing.T) {
+	p := testProvider("aws")
+	s := &module.Storage{
+		StorageDir: tempDir(t),
+		Mode:       module.GetModeGet,
+	}
+	opts := &ContextOpts{
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	}
+
+	timings, diags := ValidateBenchmark(filepath.Join(fixtureDir, "validate-good-module"), s, opts)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if !p.ValidateResourceCalled {
+		t.Fatal("ValidateResource should be called")
+	}
+
+	for name, d := range map[string]int64{
+		"Load":               int64(timings.Load),
+		"Context":            int64(timings.Context),
+		"Static":             int64(timings.Static),
+		"Graph":              int64(timings.Graph),
+		"Walk":               int64(timings.Walk),
+		"Expand":             int64(timings.Expand),
+		"ResourceValidation": int64(timings.ResourceValidation),
+	} {
+		if d <= 0 {
+			t.Errorf("%s was not recorded", name)
+		}
+	}
+	if timings.Allocs == 0 || timings.Bytes == 0 {
+		t.Errorf("allocations were not recorded: %d objects, %d bytes", timings.Allocs, timings.Bytes)
+	}
+}
+
+func BenchmarkContext2Validate(b *testing.B) {
+	p := testProvider("aws")
+	opts := &ContextOpts{
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	}
+
+	for i := 0; i < b.N; i++ {
+		_, diags := ValidateBenchmark(filepath.Join(fixtureDir, "validate-good"), nil, opts)
+		if diags.HasErrors() {
+			b.Fatalf("unexpected errors: %s", diags.Err())
+		}
+	}
+}
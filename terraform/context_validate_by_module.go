@@ -0,0 +1,85 @@
+package terraform
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ModuleDiagnosticsTree is a tree of diagnostics grouped by the module that
+// produced them, as returned by ValidateByModule.
+type ModuleDiagnosticsTree struct {
+	ModuleDiagnostics
+
+	// Children are the trees for the child modules that produced any
+	// diagnostics, or that have descendents that did, sorted by name.
+	Children []*ModuleDiagnosticsTree
+}
+
+// ValidateByModule validates the configuration as for Validate, returning
+// the same flat list of diagnostics along with the diagnostics grouped by
+// the module that produced them.
+//
+// Diagnostics that are not produced by a particular resource, provider or
+// other object in the configuration, such as errors in the structure of the
+// module tree, are grouped with the root module. Within each module the
+// diagnostics are in the same order as in the flat list.
+func (c *Context) ValidateByModule() (tfdiags.Diagnostics, *ModuleDiagnosticsTree) {
+	return c.validate()
+}
+
+// newModuleDiagnosticsTree builds a tree from the given warnings and errors
+// from the validate walk, which are keyed by PathCacheKey of their module
+// path. The given diagnostics from before and after the walk are added to
+// the root module before and after its own.
+func newModuleDiagnosticsTree(pre, post tfdiags.Diagnostics, modules map[string]*moduleValidation) *ModuleDiagnosticsTree {
+	root := &ModuleDiagnosticsTree{}
+	root.Diagnostics = root.Diagnostics.Append(pre)
+
+	keys := make([]string, 0, len(modules))
+	for k := range modules {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		mv := modules[k]
+		node := root
+		path := normalizeModulePath(mv.Path)[1:]
+		for i, name := range path {
+			node = node.child(name, append([]string(nil), path[:i+1]...))
+		}
+		node.Diagnostics = node.Diagnostics.Append(validationDiagnostics(mv.Warnings, mv.Errors))
+	}
+	root.Diagnostics = root.Diagnostics.Append(post)
+
+	return root
+}
+
+// remapSeverities replaces the severities of the diagnostics in the
+// receiver and its descendents as for the function of the same name.
+func (t *ModuleDiagnosticsTree) remapSeverities(severities map[string]tfdiags.Severity) {
+	t.Diagnostics = remapSeverities(t.Diagnostics, severities)
+	for _, child := range t.Children {
+		child.remapSeverities(severities)
+	}
+}
+
+// child returns the child of the receiver with the given name, adding one
+// with the given path if there isn't one already.
+func (t *ModuleDiagnosticsTree) child(name string, path []string) *ModuleDiagnosticsTree {
+	i := sort.Search(len(t.Children), func(i int) bool {
+		return t.Children[i].Path[len(t.Children[i].Path)-1] >= name
+	})
+	if i < len(t.Children) && t.Children[i].Path[len(t.Children[i].Path)-1] == name {
+		return t.Children[i]
+	}
+
+	child := &ModuleDiagnosticsTree{
+		ModuleDiagnostics: ModuleDiagnostics{Path: path},
+	}
+	t.Children = append(t.Children, nil)
+	copy(t.Children[i+1:], t.Children[i:])
+	t.Children[i] = child
+	return child
+}
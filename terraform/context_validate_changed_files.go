@@ -0,0 +1,158 @@
+package terraform
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+)
+
+// changedFileTargets returns the addresses of the resources declared in the
+// given changed configuration files, along with the addresses of all of the
+// resources that depend on them, so that targeting them validates
+// everything that the changes could break. Files that aren't within the
+// directory of any module in the tree are skipped, since they can't affect
+// it.
+//
+// If a change can't be attributed to particular resources, such as when a
+// changed file declares variables, outputs, module calls or providers, or
+// has been deleted, the returned bool is false and the whole configuration
+// must be validated instead. So it is for any other file within a module's
+// directory, such as a template read with the file function, since there's
+// no telling which resources read it.
+func (c *Context) changedFileTargets(files []string) ([]string, bool, error) {
+	// Directories are compared after resolving symlinks, since the modules
+	// with local sources are linked into the module storage.
+	realDir := func(dir string) string {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = real
+		}
+		return dir
+	}
+
+	changed := make(map[string]string, len(files))
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return nil, false, err
+		}
+		changed[abs] = realDir(filepath.Dir(abs))
+	}
+
+	var addrs []*ResourceAddress
+	complete := true
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if !complete || cfg == nil || cfg.Dir == "" {
+			return
+		}
+		dir := realDir(cfg.Dir)
+
+		for f, fileDir := range changed {
+			name := filepath.Base(f)
+			if config.IsIgnoredFile(name) {
+				continue
+			}
+			if !(strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json")) {
+				if fileDir == dir || strings.HasPrefix(fileDir, dir+string(filepath.Separator)) {
+					log.Printf("[INFO] validate: changed file %s isn't a configuration file, so validating everything", f)
+					complete = false
+					return
+				}
+				continue
+			}
+			if fileDir != dir {
+				continue
+			}
+
+			fc, err := config.LoadFile(f)
+			if err != nil {
+				log.Printf("[INFO] validate: can't load changed file %s, so validating everything: %s", f, err)
+				complete = false
+				return
+			}
+			if len(fc.Modules) > 0 || len(fc.Variables) > 0 || len(fc.Outputs) > 0 ||
+				len(fc.ProviderConfigs) > 0 || len(fc.Locals) > 0 ||
+				fc.Terraform != nil || fc.Atlas != nil {
+				log.Printf("[INFO] validate: changed file %s declares more than resources, so validating everything", f)
+				complete = false
+				return
+			}
+			for _, rc := range fc.Resources {
+				addrs = append(addrs, &ResourceAddress{
+					Path:         t.Path(),
+					Mode:         rc.Mode,
+					Type:         rc.Type,
+					Name:         rc.Name,
+					Index:        -1,
+					InstanceType: TypePrimary,
+				})
+			}
+		}
+	})
+	if !complete {
+		return nil, false, nil
+	}
+	if len(addrs) == 0 {
+		return nil, true, nil
+	}
+
+	// The dependents are found in the whole graph, before any targeting.
+	targets := c.targets
+	c.targets = nil
+	graph, err := c.Graph(GraphTypeValidate, nil)
+	c.targets = targets
+	if err != nil {
+		return nil, false, err
+	}
+
+	seen := make(map[string]bool)
+	var ret []string
+	add := func(addr *ResourceAddress) {
+		given := *addr
+		given.Index = -1
+		given.InstanceTypeSet = false
+		if s := given.String(); !seen[s] {
+			seen[s] = true
+			ret = append(ret, s)
+		}
+	}
+	for _, addr := range addrs {
+		add(addr)
+	}
+	for _, v := range graph.Vertices() {
+		rn, ok := v.(GraphNodeResource)
+		if !ok {
+			continue
+		}
+		matched := false
+		for _, addr := range addrs {
+			if addr.Equals(rn.ResourceAddr()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		deps, err := graph.Descendents(v)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, d := range deps.List() {
+			if dn, ok := d.(GraphNodeResource); ok {
+				add(dn.ResourceAddr())
+			}
+		}
+	}
+
+	sort.Strings(ret)
+	return ret, true, nil
+}
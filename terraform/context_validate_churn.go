@@ -0,0 +1,96 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// volatileFunctionNames are the names of the interpolation functions that
+// return a different value each time they are called.
+var volatileFunctionNames = map[string]bool{
+	"timestamp": true,
+	"uuid":      true,
+}
+
+// validateReplacementChurns returns a warning for each required attribute of
+// a managed resource that forces replacement of the resource when it
+// changes and whose value is produced using one of volatileFunctionNames,
+// since such a resource would be replaced on every apply.
+//
+// Only top-level attributes are checked, using the schemas recorded by the
+// most recent validate walk. Resources whose providers don't support
+// schemas, and attributes whose values don't call any volatile function,
+// are not reported.
+func (c *Context) validateReplacementChurns() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	schemas := c.ResourceSchemas()
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		for _, rc := range cfg.Resources {
+			if rc.Mode != config.ManagedResourceMode {
+				continue
+			}
+			schema, ok := schemas[prefix+rc.Id()]
+			if !ok || schema.Block == nil {
+				continue
+			}
+
+			for _, k := range sortedRawKeys(rc.RawConfig.Raw) {
+				attr, ok := schema.Block.Attributes[k]
+				if !ok || !attr.Required || !attr.ForceNew {
+					continue
+				}
+
+				// Parse the attribute on its own to find just the functions
+				// that it calls.
+				raw, err := config.NewRawConfig(map[string]interface{}{k: rc.RawConfig.Raw[k]})
+				if err != nil {
+					continue
+				}
+				for _, name := range volatileFunctionCalls(raw) {
+					diags = diags.Append(tfdiags.SimpleWarning(fmt.Sprintf(
+						"%s%s: the attribute %q forces replacement when it changes, but it is set using %s(), which returns a different value on every run, so the resource will be replaced on every apply",
+						prefix, rc.Id(), k, name,
+					)))
+				}
+			}
+		}
+	})
+
+	return diags
+}
+
+// volatileFunctionCalls returns the sorted names of the functions in
+// volatileFunctionNames that are called by the interpolations in the given
+// raw configuration.
+func volatileFunctionCalls(raw *config.RawConfig) []string {
+	found := make(map[string]bool)
+	visit := func(n ast.Node) ast.Node {
+		if call, ok := n.(*ast.Call); ok && volatileFunctionNames[call.Func] {
+			found[call.Func] = true
+		}
+		return n
+	}
+	for _, n := range raw.Interpolations {
+		n.Accept(visit)
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
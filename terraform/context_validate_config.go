@@ -0,0 +1,37 @@
+package terraform
+
+import (
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ValidateConfig validates a configuration that is built in memory rather
+// than loaded from files: the root module has the configuration root, and
+// the configurations of the modules it calls are found by fn, as for
+// module.NewTreeFromConfig. The remaining options are taken from opts, whose
+// Module is ignored, and the diagnostics are the same as those returned by
+// Context.Validate for a context created with them.
+//
+// The diagnostics refer to the source ranges recorded in the given
+// configurations, so they have no ranges where none were given.
+func ValidateConfig(root *config.Config, fn module.ConfigFunc, opts *ContextOpts) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	tree, err := module.NewTreeFromConfig(root, fn)
+	if err != nil {
+		return diags.Append(err)
+	}
+
+	var ctxOpts ContextOpts
+	if opts != nil {
+		ctxOpts = *opts
+	}
+	ctxOpts.Module = tree
+
+	ctx, err := NewContext(&ctxOpts)
+	if err != nil {
+		return diags.Append(err)
+	}
+	return diags.Append(ctx.Validate())
+}
@@ -0,0 +1,181 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateCountUnknowns returns a warning for each resource whose count
+// refers, directly or through local values, to an attribute of a managed
+// resource that won't be known until that resource has been created. The
+// count of such a resource can't be determined while planning, and so the
+// resource can't be expanded into its instances until apply.
+//
+// An attribute is considered unknown if the referenced resource isn't yet in
+// the state and the attribute is "id" or is computed according to the
+// schema recorded by the most recent validate walk, without being set in
+// the referenced resource's configuration. Other references, such as to
+// module outputs, can't be determined statically and are not reported.
+func (c *Context) validateCountUnknowns() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	schemas := c.ResourceSchemas()
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		var mod *ModuleState
+		if c.state != nil {
+			mod = c.state.ModuleByPath(normalizeModulePath(t.Path()))
+		}
+
+		resources := make(map[string]*config.Resource, len(cfg.Resources))
+		for _, rc := range cfg.Resources {
+			resources[rc.Id()] = rc
+		}
+		locals := make(map[string]*config.Local, len(cfg.Locals))
+		for _, l := range cfg.Locals {
+			locals[l.Name] = l
+		}
+
+		// unknownRef returns whether the given resource attribute reference
+		// is to a value that won't be known until apply.
+		unknownRef := func(rv *config.ResourceVariable) bool {
+			if rv.Mode != config.ManagedResourceMode {
+				return false
+			}
+			target, ok := resources[rv.ResourceId()]
+			if !ok || stateHasResource(mod, rv.ResourceId()) {
+				return false
+			}
+
+			field := strings.SplitN(rv.Field, ".", 2)[0]
+			if field == "id" {
+				return true
+			}
+			schema, ok := schemas[prefix+rv.ResourceId()]
+			if !ok || schema.Block == nil {
+				return false
+			}
+			attr, ok := schema.Block.Attributes[field]
+			if !ok || !attr.Computed {
+				return false
+			}
+			_, set := target.RawConfig.Raw[field]
+			return !attr.Optional || !set
+		}
+
+		// unknownRefs returns the references to unknown values among the
+		// given variables, following local values.
+		visited := make(map[string]bool)
+		var unknownRefs func(vars map[string]config.InterpolatedVariable) []string
+		unknownRefs = func(vars map[string]config.InterpolatedVariable) []string {
+			var refs []string
+			for _, k := range sortedInterpolatedVariableKeys(vars) {
+				switch v := vars[k].(type) {
+				case *config.ResourceVariable:
+					if unknownRef(v) {
+						refs = append(refs, v.FullKey())
+					}
+				case *config.LocalVariable:
+					l, ok := locals[v.Name]
+					if !ok || visited[v.Name] {
+						continue
+					}
+					visited[v.Name] = true
+					refs = append(refs, unknownRefs(l.RawConfig.Variables)...)
+				}
+			}
+			return refs
+		}
+
+		for _, rc := range cfg.Resources {
+			if rc.RawCount == nil {
+				continue
+			}
+			for k := range visited {
+				delete(visited, k)
+			}
+
+			for _, ref := range unknownRefs(rc.RawCount.Variables) {
+				diag := &hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  "Resource count is not known until apply",
+					Detail: fmt.Sprintf(
+						"The count of %s%s depends on %s%s, which is not known until that resource has been created, so it can't be expanded into instances until apply.",
+						prefix, rc.Id(), prefix, ref,
+					),
+				}
+				if rc.CountRange.Filename != "" {
+					diag.Subject = rc.CountRange.ToHCL().Ptr()
+				}
+				diags = diags.Append(tfdiags.WithRule(RuleUnknownCount, diag))
+			}
+		}
+	})
+
+	return diags
+}
+
+// validateZeroCounts returns a warning for each resource whose count is the
+// literal 0, rather than an interpolation that happens to produce 0, since
+// such a resource can never have any instances.
+func (c *Context) validateZeroCounts() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		for _, rc := range cfg.Resources {
+			if rc.RawCount == nil || len(rc.RawCount.Interpolations) > 0 {
+				continue
+			}
+			if raw, ok := rc.RawCount.Raw[rc.RawCount.Key]; !ok || fmt.Sprint(raw) != "0" {
+				continue
+			}
+
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Resource has no instances",
+				Detail: fmt.Sprintf(
+					"The count of %s%s is set to 0, so it never has any instances and its configuration has no effect. Remove the resource if it's no longer needed, or set count from a variable if it's meant to be switched on and off.",
+					prefix, rc.Id(),
+				),
+			}
+			if rc.CountRange.Filename != "" {
+				diag.Subject = rc.CountRange.ToHCL().Ptr()
+			}
+			diags = diags.Append(tfdiags.WithRule(RuleZeroCount, diag))
+		}
+	})
+
+	return diags
+}
+
+// stateHasResource returns whether the given module state has any instances
+// of the resource with the given id, such as "aws_instance.foo".
+func stateHasResource(mod *ModuleState, id string) bool {
+	if mod == nil {
+		return false
+	}
+	for k := range mod.Resources {
+		if k == id || strings.HasPrefix(k, id+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateRedundantDependencies returns a warning for each depends_on entry
+// of a resource that names a resource referred to by the resource's count,
+// its configuration or its creation-time provisioners. ReferenceTransformer
+// connects the resource to the referenced resource for those references
+// alone, so the entry adds no edge to the graph.
+//
+// Entries naming module calls are never redundant, since depending on a
+// module waits for everything in it rather than just for the outputs that
+// are referred to.
+func (c *Context) validateRedundantDependencies() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		for _, rc := range cfg.Resources {
+			if len(rc.DependsOn) == 0 {
+				continue
+			}
+
+			raws := []*config.RawConfig{rc.RawCount, rc.RawConfig}
+			for _, p := range rc.Provisioners {
+				if p.When == config.ProvisionerWhenCreate {
+					raws = append(raws, p.ConnInfo, p.RawConfig)
+				}
+			}
+			referenced := make(map[string]bool)
+			for _, raw := range raws {
+				if raw == nil {
+					continue
+				}
+				for _, v := range raw.Variables {
+					if rv, ok := v.(*config.ResourceVariable); ok {
+						referenced[rv.ResourceId()] = true
+					}
+				}
+			}
+
+			for i, dep := range rc.DependsOn {
+				if !referenced[dep] {
+					continue
+				}
+
+				diag := &hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  "Redundant depends_on entry",
+					Detail: fmt.Sprintf(
+						"%s%s depends on %s%s explicitly, but its configuration already refers to that resource, which implies the dependency. The entry can be removed from depends_on.",
+						prefix, rc.Id(), prefix, dep,
+					),
+				}
+				rng := rc.DeclRange
+				if i < len(rc.DependsOnRanges) {
+					rng = rc.DependsOnRanges[i]
+				}
+				if rng.Filename != "" {
+					diag.Subject = rng.ToHCL().Ptr()
+				}
+				diags = diags.Append(tfdiags.WithRule(RuleRedundantDependsOn, diag))
+			}
+		}
+	})
+
+	return diags
+}
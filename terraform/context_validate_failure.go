@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ValidateFailurePolicy decides which of the diagnostics returned by
+// Validate mean that validation failed, for use with ValidateWithPolicy.
+// Errors always fail validation; the policy decides whether warnings do.
+type ValidateFailurePolicy struct {
+	// Severity is the least severe severity of diagnostic that fails
+	// validation: tfdiags.Error, which is the default, or tfdiags.Warning.
+	Severity tfdiags.Severity
+
+	// Rules overrides Severity for the diagnostics with the given rule
+	// identifiers, as returned by tfdiags.Rule, so that the warnings of
+	// particular rules can fail validation, or not, whatever Severity is.
+	Rules map[string]tfdiags.Severity
+}
+
+// fails returns whether the given diagnostic fails validation under the
+// receiver, which may be nil for the default policy.
+func (p *ValidateFailurePolicy) fails(diag tfdiags.Diagnostic) bool {
+	if diag.Severity() == tfdiags.Error {
+		return true
+	}
+	if p == nil {
+		return false
+	}
+
+	threshold := p.Severity
+	if rule := tfdiags.Rule(diag); rule != "" {
+		if s, ok := p.Rules[rule]; ok {
+			threshold = s
+		}
+	}
+	return threshold == tfdiags.Warning
+}
+
+// ValidateResult is the result of ValidateWithPolicy.
+type ValidateResult struct {
+	// Diagnostics are the diagnostics returned by Validate.
+	Diagnostics tfdiags.Diagnostics
+
+	// Failed is whether any of the diagnostics fail validation under the
+	// policy given, and Failures are the diagnostics that do, in the same
+	// order as in Diagnostics.
+	Failed   bool
+	Failures tfdiags.Diagnostics
+}
+
+// ValidateWithPolicy validates the configuration as for Validate, and
+// decides whether validation failed using the given policy, which may be
+// nil for the default policy, under which only errors fail validation.
+func (c *Context) ValidateWithPolicy(policy *ValidateFailurePolicy) *ValidateResult {
+	diags, _ := c.validate()
+
+	result := &ValidateResult{Diagnostics: diags}
+	for _, diag := range diags {
+		if policy.fails(diag) {
+			result.Failures = result.Failures.Append(diag)
+		}
+	}
+	result.Failed = len(result.Failures) > 0
+	return result
+}
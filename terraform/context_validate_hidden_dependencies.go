@@ -0,0 +1,142 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// hiddenDependencyMinLength is the length of the shortest value that is
+// treated as an identifier by validateHiddenDependencyPairs. Shorter values,
+// such as "default", are too likely to be shared by coincidence.
+const hiddenDependencyMinLength = 8
+
+// validateHiddenDependencyPairs returns a warning for each pair of managed
+// resources in the same module that set identifier arguments, those named
+// "id" or ending in "_id", to the same literal value while neither refers to
+// the other, directly or through other resources, and neither has the other
+// in its depends_on. Such resources are often related through the object
+// with that identifier, such as a subnet, even though there is no edge
+// between them in the graph.
+//
+// The heuristic is deliberately conservative: only values that are shared
+// by exactly two resources of different types are considered, since values
+// shared more widely tend to be identifiers of objects that aren't managed by
+// the configuration at all, and resources of the same type are usually
+// siblings rather than dependents. Each pair is reported once.
+func (c *Context) validateHiddenDependencyPairs() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		type use struct {
+			rc   *config.Resource
+			attr string
+		}
+		uses := make(map[string][]use)
+		deps := make(map[string][]string)
+		for _, rc := range cfg.Resources {
+			if rc.Mode != config.ManagedResourceMode {
+				continue
+			}
+
+			raws := []*config.RawConfig{rc.RawCount, rc.RawConfig}
+			for _, p := range rc.Provisioners {
+				raws = append(raws, p.ConnInfo, p.RawConfig)
+			}
+			for _, raw := range raws {
+				if raw == nil {
+					continue
+				}
+				for _, v := range raw.Variables {
+					if rv, ok := v.(*config.ResourceVariable); ok {
+						deps[rc.Id()] = append(deps[rc.Id()], rv.ResourceId())
+					}
+				}
+			}
+			deps[rc.Id()] = append(deps[rc.Id()], rc.DependsOn...)
+
+			for _, k := range sortedRawKeys(rc.RawConfig.Raw) {
+				if k != "id" && !strings.HasSuffix(k, "_id") {
+					continue
+				}
+				v, ok := rc.RawConfig.Raw[k].(string)
+				if !ok || len(v) < hiddenDependencyMinLength || strings.Contains(v, "${") {
+					continue
+				}
+				uses[v] = append(uses[v], use{rc: rc, attr: k})
+			}
+		}
+
+		// reaches returns whether from refers to to, directly or through
+		// other resources.
+		reaches := func(from, to string) bool {
+			visited := make(map[string]bool)
+			stack := []string{from}
+			for len(stack) > 0 {
+				id := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				for _, dep := range deps[id] {
+					if dep == to {
+						return true
+					}
+					if !visited[dep] {
+						visited[dep] = true
+						stack = append(stack, dep)
+					}
+				}
+			}
+			return false
+		}
+
+		values := make([]string, 0, len(uses))
+		for v := range uses {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+
+		reported := make(map[[2]string]bool)
+		for _, v := range values {
+			if len(uses[v]) != 2 {
+				continue
+			}
+			a, b := uses[v][0], uses[v][1]
+			if a.rc.Type == b.rc.Type {
+				continue
+			}
+			pair := [2]string{a.rc.Id(), b.rc.Id()}
+			if reported[pair] || reaches(pair[0], pair[1]) || reaches(pair[1], pair[0]) {
+				continue
+			}
+			reported[pair] = true
+
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Possible hidden dependency",
+				Detail: fmt.Sprintf(
+					"%s%s.%s and %s%s.%s are both set to %q, but neither resource refers to the other, so they may be created and destroyed in either order. If one of them must be created first, add it to the depends_on of the other.",
+					prefix, a.rc.Id(), a.attr, prefix, b.rc.Id(), b.attr, v,
+				),
+			}
+			if rng, ok := b.rc.AttributeRanges[b.attr]; ok && rng.Filename != "" {
+				diag.Subject = rng.ToHCL().Ptr()
+			} else if b.rc.DeclRange.Filename != "" {
+				diag.Subject = b.rc.DeclRange.ToHCL().Ptr()
+			}
+			diags = diags.Append(tfdiags.WithRule(RuleHiddenDependency, diag))
+		}
+	})
+
+	return diags
+}
@@ -0,0 +1,82 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateIgnoredAttributeAssignments returns a warning for each attribute
+// that is set in the configuration of a managed resource and is also listed,
+// by itself or by one of its elements such as "tags.Name", in the
+// ignore_changes of the resource's lifecycle block. The configured value is
+// used when the resource is created but has no effect after that, which is
+// often not what was intended.
+//
+// The "*" entry, which ignores changes to all attributes, is not reported,
+// since in that case the configuration is evidently only meant to be used
+// for creation.
+func (c *Context) validateIgnoredAttributeAssignments() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		for _, rc := range cfg.Resources {
+			if rc.Mode != config.ManagedResourceMode {
+				continue
+			}
+
+			reported := make(map[string]bool)
+			for _, entry := range rc.Lifecycle.IgnoreChanges {
+				name := strings.SplitN(entry, ".", 2)[0]
+				if entry == "*" || reported[name] {
+					continue
+				}
+				if _, set := rc.RawConfig.Raw[name]; !set {
+					continue
+				}
+				reported[name] = true
+
+				diag := &hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  "Attribute is set but its changes are ignored",
+					Detail: fmt.Sprintf(
+						"The attribute %q of %s%s is set in the configuration, but %q is also listed in lifecycle.ignore_changes, so the configured value has no effect once the resource has been created.",
+						name, prefix, rc.Id(), entry,
+					),
+				}
+
+				// The subject is the assignment, and the context spans it
+				// and the ignore_changes list so that both are shown.
+				ignoreRng := rc.IgnoreChangesRange
+				attrRng, ok := rc.AttributeRanges[name]
+				switch {
+				case ok && attrRng.Filename != "":
+					diag.Subject = attrRng.ToHCL().Ptr()
+					if ignoreRng.Filename == attrRng.Filename {
+						start, end := attrRng.ToHCL(), ignoreRng.ToHCL()
+						if end.Start.Line < start.Start.Line {
+							start, end = end, start
+						}
+						diag.Context = hcl.RangeBetween(start, end).Ptr()
+					}
+				case ignoreRng.Filename != "":
+					diag.Subject = ignoreRng.ToHCL().Ptr()
+				}
+				diags = diags.Append(tfdiags.WithRule(RuleIgnoredAttribute, diag))
+			}
+		}
+	})
+
+	return diags
+}
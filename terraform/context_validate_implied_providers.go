@@ -0,0 +1,56 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateImpliedProviderResources returns a warning for each resource whose
+// provider type, as implied by the prefix of its resource type or given by
+// its provider argument, isn't declared in the required_providers block of
+// the module containing it. The provider that such a resource belongs to is
+// decided only by the naming convention, which is ambiguous where several
+// providers use the same prefix.
+func (c *Context) validateImpliedProviderResources() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		var declared map[string]tfdiags.SourceRange
+		if cfg.Terraform != nil {
+			declared = cfg.Terraform.RequiredProviders
+		}
+
+		prefix := t.AddrPrefix()
+
+		for _, rc := range cfg.Resources {
+			typeName := strings.SplitN(rc.ProviderFullName(), ".", 2)[0]
+			if _, ok := declared[typeName]; ok {
+				continue
+			}
+
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Provider not declared in required_providers",
+				Detail: fmt.Sprintf(
+					"%s%s belongs to provider %q only by the naming convention for its type, since the required_providers block of its module doesn't declare that provider. Declare it there to make the provider explicit.",
+					prefix, rc.Id(), typeName,
+				),
+			}
+			if rc.DeclRange.Filename != "" {
+				diag.Subject = rc.DeclRange.ToHCL().Ptr()
+			}
+			diags = diags.Append(tfdiags.WithRule(RuleImpliedProvider, diag))
+		}
+	})
+
+	return diags
+}
@@ -0,0 +1,56 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateInternalReferences returns an error for each reference from an
+// output, a local value or a provisioner to an attribute of a resource in
+// the same module that the schema recorded by the validate walk marks as
+// internal, as EvalValidateResource reports for the arguments of resources.
+func (c *Context) validateInternalReferences() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	schemas := c.ResourceSchemas()
+	if len(schemas) == 0 {
+		return diags
+	}
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+		check := func(source string, raw *config.RawConfig) {
+			if raw == nil {
+				return
+			}
+			for _, err := range internalReferenceErrors(raw.Raw, t.Path(), schemas, nil) {
+				diags = diags.Append(tfdiags.WithRule(RuleInternalAttribute, fmt.Errorf(
+					"%s%s: %s", prefix, source, err,
+				)))
+			}
+		}
+
+		for _, o := range cfg.Outputs {
+			check("output."+o.Name, o.RawConfig)
+		}
+		for _, l := range cfg.Locals {
+			check("local."+l.Name, l.RawConfig)
+		}
+		for _, rc := range cfg.Resources {
+			for _, p := range rc.Provisioners {
+				source := fmt.Sprintf("%s: provisioner %s", rc.Id(), p.Type)
+				check(source, p.RawConfig)
+				check(source+" connection", p.ConnInfo)
+			}
+		}
+	})
+
+	return diags
+}
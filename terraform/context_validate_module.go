@@ -0,0 +1,74 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ValidateModule loads the module in the given directory and validates it
+// as a root module, as for Context.Validate, with its input variables set to
+// the given values. It is intended for testing a module on its own, for
+// values of its variables that the modules calling it might pass.
+//
+// The values are assigned to variables as for the -var option, combined
+// with the defaults declared in the module, and are then checked against the
+// declared types. Values are not read from TF_VAR_ environment variables,
+// and values for variables that the module doesn't declare are errors.
+//
+// Modules are loaded using the given storage, as for ValidateBenchmark. The
+// module tree and variables in opts are ignored, and the other options are
+// used as for NewContext.
+func ValidateModule(dir string, s *module.Storage, vars map[string]interface{}, opts *ContextOpts) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	mod, err := loadModuleDir(dir, s)
+	if err != nil {
+		return diags.Append(err)
+	}
+	if moreDiags := mod.Validate(); moreDiags.HasErrors() {
+		return diags.Append(moreDiags)
+	}
+
+	declared := make(map[string]bool)
+	for _, v := range mod.Config().Variables {
+		declared[v.Name] = true
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !declared[name] {
+			diags = diags.Append(fmt.Errorf("variable %q was given a value, but the module does not declare it", name))
+		}
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+
+	values, err := variables(mod, nil, vars)
+	if err != nil {
+		return diags.Append(err)
+	}
+
+	var ctxOpts ContextOpts
+	if opts != nil {
+		ctxOpts = *opts
+	}
+	ctxOpts.Module = mod
+	ctxOpts.Variables = nil
+
+	ctx, err := NewContext(&ctxOpts)
+	if err != nil {
+		return diags.Append(err)
+	}
+
+	// NewContext also takes values from the environment, which we replace
+	// with just the defaults and the given values.
+	ctx.variables = values
+	return diags.Append(ctx.Validate())
+}
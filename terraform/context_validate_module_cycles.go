@@ -0,0 +1,143 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateModuleCallCycles returns an error for each group of module calls
+// in the same module whose arguments depend on each other's outputs, either
+// directly or through resources and local values of the calling module.
+//
+// Module variables and outputs are separate vertices in the graph, so calls
+// that refer to each other's outputs are only a cycle if the outputs also
+// depend on the arguments. This is therefore called only once building the
+// graph has failed, to explain the resulting cycle in terms of the module
+// calls rather than the variables and outputs inside them.
+func (c *Context) validateModuleCallCycles() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil || len(cfg.Modules) == 0 {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		// The objects that each object in the module refers to directly,
+		// keyed as they are in interpolations.
+		refs := make(map[string][]string)
+		for _, m := range cfg.Modules {
+			refs["module."+m.Name] = moduleCallCycleRefs(m.RawConfig)
+		}
+		for _, l := range cfg.Locals {
+			refs["local."+l.Name] = moduleCallCycleRefs(l.RawConfig)
+		}
+		for _, rc := range cfg.Resources {
+			refs[rc.Id()] = append(moduleCallCycleRefs(rc.RawCount), moduleCallCycleRefs(rc.RawConfig)...)
+		}
+
+		// Connect each module call to the module calls whose outputs it
+		// depends on, following references through other kinds of object.
+		calls := make(map[string]*config.Module, len(cfg.Modules))
+		var g dag.Graph
+		for _, m := range cfg.Modules {
+			calls[m.Name] = m
+			g.Add(m.Name)
+		}
+		for _, m := range cfg.Modules {
+			visited := make(map[string]bool)
+			var visit func(key string)
+			visit = func(key string) {
+				for _, ref := range refs[key] {
+					if visited[ref] {
+						continue
+					}
+					visited[ref] = true
+					if name := strings.TrimPrefix(ref, "module."); name != ref {
+						if _, ok := calls[name]; ok {
+							g.Connect(dag.BasicEdge(m.Name, name))
+						}
+						continue
+					}
+					visit(ref)
+				}
+			}
+			visit("module." + m.Name)
+		}
+
+		var cycles [][]string
+		for _, scc := range dag.StronglyConnected(&g) {
+			if len(scc) == 1 && !g.HasEdge(dag.BasicEdge(scc[0], scc[0])) {
+				continue
+			}
+			names := make([]string, len(scc))
+			for i, v := range scc {
+				names[i] = v.(string)
+			}
+			sort.Strings(names)
+			cycles = append(cycles, names)
+		}
+		sort.Slice(cycles, func(i, j int) bool {
+			return cycles[i][0] < cycles[j][0]
+		})
+
+		for _, names := range cycles {
+			addrs := make([]string, len(names))
+			for i, name := range names {
+				addrs[i] = prefix + "module." + name
+			}
+
+			var detail string
+			if len(addrs) == 1 {
+				detail = fmt.Sprintf(
+					"The arguments of %s depend on the module's own outputs, so the module can't be evaluated.",
+					addrs[0],
+				)
+			} else {
+				detail = fmt.Sprintf(
+					"The arguments of %s depend on each other's outputs, so none of these modules can be evaluated before the others.",
+					strings.Join(addrs, ", "),
+				)
+			}
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Cycle between module calls",
+				Detail:   detail,
+			}
+			if m := calls[names[0]]; m.DeclRange.Filename != "" {
+				diag.Subject = m.DeclRange.ToHCL().Ptr()
+			}
+			diags = diags.Append(tfdiags.WithRule(RuleModuleCallCycle, diag))
+		}
+	})
+	return diags
+}
+
+// moduleCallCycleRefs returns the keys of the module calls, local values and
+// resources that the given configuration refers to, as used by
+// validateModuleCallCycles.
+func moduleCallCycleRefs(raw *config.RawConfig) []string {
+	if raw == nil {
+		return nil
+	}
+	var ret []string
+	for _, k := range sortedInterpolatedVariableKeys(raw.Variables) {
+		switch v := raw.Variables[k].(type) {
+		case *config.ModuleVariable:
+			ret = append(ret, "module."+v.Name)
+		case *config.LocalVariable:
+			ret = append(ret, "local."+v.Name)
+		case *config.ResourceVariable:
+			ret = append(ret, v.ResourceId())
+		}
+	}
+	return ret
+}
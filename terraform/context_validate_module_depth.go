@@ -0,0 +1,32 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateModuleDepths returns a warning for each module that is nested one
+// module call more deeply than ContextOpts.ValidateMaxModuleDepth allows.
+// The modules that it calls are nested too deeply as well, but aren't
+// reported separately.
+func (c *Context) validateModuleDepths() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	maxDepth := c.validateMaxModuleDepth
+
+	c.module.DeepEach(func(t *module.Tree) {
+		path := t.Path()
+		if len(path) != maxDepth+1 {
+			return
+		}
+
+		diags = diags.Append(tfdiags.WithRule(RuleModuleDepth, tfdiags.SimpleWarning(fmt.Sprintf(
+			"module.%s is nested %d module calls deep, which is more than the limit of %d.",
+			strings.Join(path, ".module."), len(path), maxDepth,
+		))))
+	})
+
+	return diags
+}
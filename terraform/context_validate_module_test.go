@@ -0,0 +1,97 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateModule(t *testing.T) {
+	// Values in the environment must not be used.
+	os.Setenv(VarEnvPrefix+"ami", "ami-env")
+	defer os.Unsetenv(VarEnvPrefix + "ami")
+
+	cases := map[string]struct {
+		Vars    map[string]interface{}
+		WantErr string
+	}{
+		"valid": {
+			Vars: map[string]interface{}{
+				"ami":   "ami-123",
+				"zones": []interface{}{"us-west-2a", "us-west-2b"},
+			},
+		},
+		"wrong type": {
+			Vars: map[string]interface{}{
+				"ami":   "ami-123",
+				"zones": "us-west-2a",
+			},
+			WantErr: "variable zones should be type list, got string",
+		},
+		"undeclared": {
+			Vars: map[string]interface{}{
+				"ami":  "ami-123",
+				"zone": "us-west-2a",
+			},
+			WantErr: `variable "zone" was given a value, but the module does not declare it`,
+		},
+		"missing": {
+			Vars:    map[string]interface{}{},
+			WantErr: "Required variable not set: ami",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got map[string]interface{}
+			p := testProvider("aws")
+			p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+				got = c.Config
+				return nil, nil
+			}
+			opts := &ContextOpts{
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+			}
+
+			diags := ValidateModule(filepath.Join(fixtureDir, "validate-module-inputs"), nil, tc.Vars, opts)
+			if tc.WantErr != "" {
+				if !diags.HasErrors() {
+					t.Fatal("succeeded; want error")
+				}
+				if err := diags.Err().Error(); !strings.Contains(err, tc.WantErr) {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, tc.WantErr)
+				}
+				return
+			}
+
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+			if got["ami"] != "ami-123" || got["availability_zone"] != "us-west-2a,us-west-2b" {
+				t.Fatalf("wrong config: %#v", got)
+			}
+		})
+	}
+}
+
+func TestValidateModule_nilOpts(t *testing.T) {
+	vars := map[string]interface{}{
+		"ami":   "ami-123",
+		"zones": []interface{}{"us-west-2a", "us-west-2b"},
+	}
+
+	// Without options there are no providers, but that is the only error.
+	diags := ValidateModule(filepath.Join(fixtureDir, "validate-module-inputs"), nil, vars, nil)
+	if !diags.HasErrors() {
+		t.Fatal("succeeded; want error")
+	}
+	want := `provider.aws: unknown provider "aws"`
+	if got := diags.Err().Error(); got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
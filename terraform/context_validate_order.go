@@ -0,0 +1,88 @@
+package terraform
+
+import (
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ValidateOrder is the dependency order of the resources in a configuration,
+// as returned by Context.ValidateOrder.
+type ValidateOrder struct {
+	// Diagnostics are the diagnostics returned by Validate.
+	Diagnostics tfdiags.Diagnostics
+
+	// Resources are the addresses of the resources, such as
+	// "aws_instance.web" or "module.child.data.aws_ami.ubuntu", sorted so
+	// that each resource comes after every resource that it depends on.
+	Resources []string
+
+	// Layers are the same addresses grouped so that the resources in each
+	// layer depend only on resources in earlier layers. The resources within
+	// a layer don't depend on each other, so they can be created in
+	// parallel once the earlier layers have been. Resources is the
+	// concatenation of the layers.
+	Layers [][]string
+}
+
+// ValidateOrder validates the configuration as for Validate and, if there
+// are no errors, returns the addresses of its resources in dependency order,
+// as found from the graph for the validate walk with the context's targets
+// applied. Where several orders are valid, resources are ordered by layer
+// and then by address, so the order is deterministic.
+//
+// A resource depends on another if the graph connects them, directly or
+// through other objects such as local values, module outputs or providers.
+// The instances of a resource with count can't all be known until plan, so
+// each resource is ordered as a whole; apply may also add dependencies that
+// validate can't see, such as those of resources being destroyed.
+func (c *Context) ValidateOrder() *ValidateOrder {
+	result := &ValidateOrder{Diagnostics: c.Validate()}
+	if result.Diagnostics.HasErrors() {
+		return result
+	}
+
+	graph, err := c.Graph(GraphTypeValidate, nil)
+	if err != nil {
+		result.Diagnostics = result.Diagnostics.Append(err)
+		return result
+	}
+
+	// Reduce the graph to just its resources, connecting each to every
+	// resource that it depends on, through whatever else is in between.
+	var order dag.AcyclicGraph
+	addrs := make(map[dag.Vertex]string)
+	for _, v := range graph.Vertices() {
+		if rn, ok := v.(GraphNodeResource); ok && rn.ResourceAddr() != nil {
+			addrs[v] = rn.ResourceAddr().String()
+			order.Add(addrs[v])
+		}
+	}
+	for v, addr := range addrs {
+		deps, err := graph.Ancestors(v)
+		if err != nil {
+			result.Diagnostics = result.Diagnostics.Append(err)
+			return result
+		}
+		for _, dep := range deps.List() {
+			if depAddr, ok := addrs[dep]; ok && depAddr != addr {
+				order.Connect(dag.BasicEdge(addr, depAddr))
+			}
+		}
+	}
+
+	layers, err := order.Layers()
+	if err != nil {
+		result.Diagnostics = result.Diagnostics.Append(err)
+		return result
+	}
+	for _, layer := range layers {
+		names := make([]string, len(layer))
+		for i, v := range layer {
+			names[i] = v.(string)
+		}
+		result.Layers = append(result.Layers, names)
+		result.Resources = append(result.Resources, names...)
+	}
+
+	return result
+}
@@ -0,0 +1,89 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ValidateProviderConfigs returns the resolved names of the provider
+// configurations, such as "provider.aws.west" or
+// "module.child.provider.aws", that Validate would start providers for,
+// sorted by name. Only the graph for the validate walk is built, with the
+// context's targets applied, so nothing is validated and no providers are
+// started. Where Validate would share one provider between identical
+// configurations, only the configuration that it keeps is included.
+//
+// This is useful for preparing, or mocking, exactly the provider plugins
+// that a targeted validation will use.
+func (c *Context) ValidateProviderConfigs() ([]string, error) {
+	graph, err := c.Graph(GraphTypeValidate, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, v := range graph.Vertices() {
+		if pv, ok := v.(GraphNodeProvider); ok {
+			// As for providerVertexMap, the name may have meta info.
+			names = append(names, strings.SplitN(pv.Name(), " ", 2)[0])
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// validateSchemaProviders returns an error if any of the providers in the
+// given validate graph has no schema among those that the context was
+// created with, when created with ContextOpts.ValidateSchemasPath. The walk
+// would otherwise fail at the first of them, partway through, so the error
+// names all of the provider configurations that need the missing schemas.
+// Only the providers left in the graph once the targets are applied, if
+// any, need schemas.
+func (c *Context) validateSchemaProviders(graph *Graph) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	available := make(map[string]bool)
+	for _, name := range c.components.ResourceProviders() {
+		available[name] = true
+	}
+
+	missing := make(map[string]bool)
+	var names []string
+	for _, v := range graph.Vertices() {
+		pv, ok := v.(GraphNodeProvider)
+		if !ok {
+			continue
+		}
+		// As for ProviderEvalTree, the provider name may have an alias.
+		typeName := strings.SplitN(pv.ProviderName(), ".", 2)[0]
+		if available[typeName] {
+			continue
+		}
+		missing[typeName] = true
+		// As for providerVertexMap, the name may have meta info.
+		names = append(names, strings.SplitN(pv.Name(), " ", 2)[0])
+	}
+	if len(names) == 0 {
+		return diags
+	}
+	sort.Strings(names)
+
+	types := make([]string, 0, len(missing))
+	for typ := range missing {
+		types = append(types, fmt.Sprintf("%q", typ))
+	}
+	sort.Strings(types)
+
+	return diags.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Missing provider schemas",
+		Detail: fmt.Sprintf(
+			"The provider schemas given for validation have no schemas for the providers %s, which are needed by %s. Export the schemas of these providers along with the others.",
+			strings.Join(types, ", "), strings.Join(names, ", "),
+		),
+	})
+}
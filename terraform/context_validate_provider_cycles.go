@@ -0,0 +1,65 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// validateProviderResourceCycles returns an error for each reference in a
+// provider configuration to a resource in the same module that belongs to
+// that provider configuration. The provider can't be configured until the
+// resource exists and the resource can't be created until the provider is
+// configured, and so the graph would have a cycle between the two.
+//
+// Only direct references are found. Cycles through local values or other
+// resources are still reported as cycles when the graph is built.
+func (c *Context) validateProviderResourceCycles() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		resources := make(map[string]*config.Resource, len(cfg.Resources))
+		for _, rc := range cfg.Resources {
+			resources[rc.Id()] = rc
+		}
+
+		for _, pc := range cfg.ProviderConfigs {
+			if pc.RawConfig == nil {
+				continue
+			}
+			for _, k := range sortedInterpolatedVariableKeys(pc.RawConfig.Variables) {
+				rv, ok := pc.RawConfig.Variables[k].(*config.ResourceVariable)
+				if !ok {
+					continue
+				}
+				rc := resources[rv.ResourceId()]
+				if rc == nil || rc.ProviderFullName() != pc.FullName() {
+					continue
+				}
+
+				diag := &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Provider configuration depends on its own resource",
+					Detail: fmt.Sprintf(
+						"The configuration of %sprovider.%s refers to %s, but %s%s belongs to that provider configuration. The provider can't be configured until the resource exists, and the resource can't be created until the provider is configured.",
+						prefix, pc.FullName(), k, prefix, rc.Id(),
+					),
+				}
+				if pc.DeclRange.Filename != "" {
+					diag.Subject = pc.DeclRange.ToHCL().Ptr()
+				}
+				diags = diags.Append(tfdiags.WithRule(RuleProviderResourceCycle, diag))
+			}
+		}
+	})
+	return diags
+}
@@ -0,0 +1,124 @@
+package terraform
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ModuleDiagnostics is the result of validating a single module as part of
+// ValidateRecursive.
+type ModuleDiagnostics struct {
+	// Path is the path of the module within the context's module tree,
+	// which is empty for the root module.
+	Path []string
+
+	Diagnostics tfdiags.Diagnostics
+}
+
+// ValidateRecursive validates the configuration as for Validate and then
+// also validates each of the modules it calls as if it were a root module,
+// returning the diagnostics for each module separately, sorted by module
+// path with the root module first.
+//
+// This catches problems in modules that only show up for values of their
+// input variables that the root module doesn't happen to pass. When a
+// module is validated on its own, any required variables are given
+// placeholder values: an unknown value for strings and an empty list or
+// map otherwise.
+//
+// Only modules that are present in the context's module tree are validated.
+// Modules from local sources are always present, but modules from remote
+// sources are present only once they have been installed.
+func (c *Context) ValidateRecursive() []ModuleDiagnostics {
+	ret := []ModuleDiagnostics{
+		{Path: nil, Diagnostics: c.Validate()},
+	}
+
+	// Errors in the module tree itself are already reported for the root.
+	if !c.module.Loaded() || c.module.Validate().HasErrors() {
+		return ret
+	}
+
+	var children []*module.Tree
+	c.module.DeepEach(func(t *module.Tree) {
+		if len(t.Path()) > 0 {
+			children = append(children, t)
+		}
+	})
+	sort.Slice(children, func(i, j int) bool {
+		return strings.Join(children[i].Path(), ".") < strings.Join(children[j].Path(), ".")
+	})
+
+	for _, t := range children {
+		ret = append(ret, ModuleDiagnostics{
+			Path:        t.Path(),
+			Diagnostics: c.validateAsRoot(t),
+		})
+	}
+
+	return ret
+}
+
+// validateAsRoot validates the given module from the context's module tree
+// as if it were the root module, with a fresh state, using a context created
+// with the same options as the receiver apart from those that are specific
+// to the root module.
+//
+// Targets and provider overrides are addresses in the root module, so the
+// overrides of resources in the given module are rebased onto it and the
+// rest are dropped, as are the targets. Changed files are attributed to
+// resources by the validation of the root module, which covers every module,
+// so each module is validated in full here.
+func (c *Context) validateAsRoot(t *module.Tree) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	root := t.AsRoot()
+	placeholders := make(map[string]interface{})
+	for _, v := range root.Config().Variables {
+		if !v.Required() {
+			continue
+		}
+		switch v.Type() {
+		case config.VariableTypeList:
+			placeholders[v.Name] = []interface{}{}
+		case config.VariableTypeMap:
+			placeholders[v.Name] = map[string]interface{}{}
+		default:
+			placeholders[v.Name] = config.UnknownVariableValue
+		}
+	}
+
+	opts := *c.opts
+	opts.Module = root
+	opts.State = nil
+	opts.Diff = nil
+	opts.Destroy = false
+	opts.Variables = placeholders
+	opts.Targets = nil
+	opts.ValidateChangedFiles = nil
+	opts.ValidateProviderOverrides = nil
+	prefix := t.AddrPrefix()
+	for addr, provider := range c.opts.ValidateProviderOverrides {
+		if !strings.HasPrefix(addr, prefix) {
+			continue
+		}
+		if opts.ValidateProviderOverrides == nil {
+			opts.ValidateProviderOverrides = make(map[string]string)
+		}
+		opts.ValidateProviderOverrides[strings.TrimPrefix(addr, prefix)] = provider
+	}
+
+	child, err := NewContext(&opts)
+	if err != nil {
+		return diags.Append(err)
+	}
+
+	// Stopping the context also stops the validation of its modules.
+	child.hooks = c.hooks
+	child.sh = c.sh
+	return child.Validate()
+}
@@ -0,0 +1,46 @@
+package terraform
+
+import (
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ValidateReferenceGraph validates the configuration as for Validate and, if
+// there are no errors, returns a copy of the graph for the validate walk,
+// with the context's targets applied, that has only the edges that
+// ReferenceTransformer connects: an edge from each object to each object
+// that it refers to, including by depends_on.
+//
+// The edges that other transformers add, such as those from resources to
+// their providers and provisioners, from the root vertex and from the
+// vertices that close providers, are left out, so the graph describes only
+// how data flows through the configuration. Every vertex of the validate
+// graph is kept, even if it has no edges left. Edges that TransitiveReduction
+// would remove from the validate graph are kept too, since each is a
+// reference in its own right.
+func (c *Context) ValidateReferenceGraph() (*dag.Graph, tfdiags.Diagnostics) {
+	diags := c.Validate()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	graph, err := c.Graph(GraphTypeValidate, nil)
+	if err != nil {
+		return nil, diags.Append(err)
+	}
+
+	var refs dag.Graph
+	vs := graph.Vertices()
+	for _, v := range vs {
+		refs.Add(v)
+	}
+	m := NewReferenceMap(vs)
+	for _, v := range vs {
+		parents, _ := m.References(v)
+		for _, parent := range parents {
+			refs.Connect(dag.BasicEdge(v, parent))
+		}
+	}
+
+	return &refs, diags
+}
@@ -0,0 +1,120 @@
+package terraform
+
+import (
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// These are the identifiers of the rules that Validate checks, which are
+// returned by tfdiags.Rule for the diagnostics that the rules produce. They
+// are stable, so that they can be used to suppress or filter diagnostics: a
+// rule's identifier never changes, even if its messages do, and the
+// identifiers of rules that are removed are never reused.
+//
+// Diagnostics produced by providers, by the loading of the configuration
+// and by checks without an identifier here have no rule identifier.
+const (
+	RuleArgumentType             = "argument_type"
+	RuleComputedPlaceholderType  = "computed_placeholder_type"
+	RuleDataSourceLifecycle      = "data_source_lifecycle"
+	RuleDeprecatedAttribute      = "deprecated_attribute"
+	RuleHiddenDependency         = "hidden_dependency"
+	RuleIgnoredAttribute         = "ignored_attribute"
+	RuleInternalAttribute        = "internal_attribute"
+	RuleImpliedProvider          = "implied_provider"
+	RuleInstanceLimit            = "instance_limit"
+	RuleInvalidResourceName      = "invalid_resource_name"
+	RuleMissingObjectField       = "missing_object_field"
+	RuleModuleCallCycle          = "module_call_cycle"
+	RuleModuleDepth              = "module_depth"
+	RuleNestingDepth             = "nesting_depth"
+	RuleProviderResourceCycle    = "provider_resource_cycle"
+	RuleReadOnlyAttribute        = "read_only_attribute"
+	RuleRedundantDependsOn       = "redundant_depends_on"
+	RuleSelfReferentialTrigger   = "self_referential_trigger"
+	RuleSingletonCount           = "singleton_count"
+	RuleTagPolicy                = "tag_policy"
+	RuleTargetVersion            = "target_version"
+	RuleTerraformBlockArgument   = "terraform_block_argument"
+	RuleUndeclaredReference      = "undeclared_reference"
+	RuleUnknownCount             = "unknown_count"
+	RuleUnsupportedArgument      = "unsupported_argument"
+	RuleUnsupportedObjectField   = "unsupported_object_field"
+	RuleUnsupportedSelfAttribute = "unsupported_self_attribute"
+	RuleZeroCount                = "zero_count"
+)
+
+// ruleError is an error returned by an EvalNode in an EvalValidateError,
+// annotated with the identifier of the rule that produced it. The walker
+// wraps such errors with the names of their vertices, so the annotation is
+// recovered by validateErrorDiagnostics.
+type ruleError struct {
+	err  error
+	rule string
+}
+
+// withRule annotates the given error with the given rule identifier.
+func withRule(rule string, err error) error {
+	return &ruleError{err: err, rule: rule}
+}
+
+func (e *ruleError) Error() string {
+	return e.err.Error()
+}
+
+// WrappedErrors implements errwrap.Wrapper.
+func (e *ruleError) WrappedErrors() []error {
+	return []error{e.err}
+}
+
+// validateErrorDiagnostics returns the diagnostics for the given error from
+// the validate walk, annotated with the identifier of the rule that produced
+// it if the error or any error it wraps was returned by withRule.
+func validateErrorDiagnostics(err error) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	re, ok := errwrap.GetType(err, &ruleError{}).(*ruleError)
+	if !ok {
+		return diags.Append(err)
+	}
+	if err == error(re) {
+		// Unwrap the error itself so that diagnostics keep their sources.
+		err = re.err
+	}
+	return diags.Append(tfdiags.WithRule(re.rule, err))
+}
+
+// remapSeverities returns the given diagnostics with the severities of those
+// whose rule identifiers are keys of the given map replaced by the
+// corresponding values, as for ContextOpts.ValidateSeverities.
+func remapSeverities(diags tfdiags.Diagnostics, severities map[string]tfdiags.Severity) tfdiags.Diagnostics {
+	if len(diags) == 0 {
+		return diags
+	}
+	ret := make(tfdiags.Diagnostics, len(diags))
+	for i, diag := range diags {
+		if rule := tfdiags.Rule(diag); rule != "" {
+			if s, ok := severities[rule]; ok {
+				diag = tfdiags.WithSeverity(diag, s)
+			}
+		}
+		ret[i] = diag
+	}
+	return ret
+}
+
+// ValidateByRule validates the configuration as for Validate, returning the
+// same flat list of diagnostics along with the diagnostics grouped by the
+// identifiers of the rules that produced them, as returned by tfdiags.Rule.
+// Diagnostics without a rule identifier are grouped under the empty string.
+// Within each group the diagnostics are in the same order as in the flat
+// list.
+func (c *Context) ValidateByRule() (tfdiags.Diagnostics, map[string]tfdiags.Diagnostics) {
+	diags, _ := c.validate()
+
+	byRule := make(map[string]tfdiags.Diagnostics)
+	for _, diag := range diags {
+		rule := tfdiags.Rule(diag)
+		byRule[rule] = byRule[rule].Append(diag)
+	}
+	return diags, byRule
+}
@@ -0,0 +1,116 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// triggerAttributes are the attributes, by resource type, whose values are
+// used only to decide when a resource must be replaced.
+var triggerAttributes = map[string]string{
+	"null_resource":  "triggers",
+	"terraform_data": "triggers_replace",
+}
+
+// triggerAttribute returns the name of the trigger attribute of the given
+// resource type, or an empty string if it has none. The resource types of
+// the random provider all use "keepers".
+func triggerAttribute(resourceType string) string {
+	if strings.HasPrefix(resourceType, "random_") {
+		return "keepers"
+	}
+	return triggerAttributes[resourceType]
+}
+
+// validateSelfReferencesThroughLocals returns an error for each resource in
+// the context's module tree that refers to itself through local values, as
+// found by selfReferencesThroughLocals.
+func (c *Context) validateSelfReferencesThroughLocals() []error {
+	var errs []error
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+		for _, rc := range cfg.Resources {
+			addr := &ResourceAddress{
+				Path:         t.Path(),
+				Mode:         rc.Mode,
+				Type:         rc.Type,
+				Name:         rc.Name,
+				Index:        -1,
+				InstanceType: TypePrimary,
+			}
+			errs = append(errs, selfReferencesThroughLocals(addr, rc.RawConfig, cfg.Locals)...)
+		}
+	})
+	return errs
+}
+
+// validateTriggerSelfReferences returns an error for each resource in the
+// context's module tree whose trigger attribute, as given by
+// triggerAttribute, refers directly to the resource itself.
+//
+// Such a reference is reported by EvalValidateResourceSelfRef too, but the
+// trigger is decided before the resource is replaced and so can never
+// depend on it, which deserves a more specific explanation. References
+// through local values are reported by validateSelfReferencesThroughLocals.
+func (c *Context) validateTriggerSelfReferences() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+		for _, rc := range cfg.Resources {
+			attr := triggerAttribute(rc.Type)
+			if rc.Mode != config.ManagedResourceMode || attr == "" {
+				continue
+			}
+			raw, ok := rc.RawConfig.Raw[attr]
+			if !ok {
+				continue
+			}
+
+			// Parse the attribute on its own to find just its references.
+			triggers, err := config.NewRawConfig(map[string]interface{}{attr: raw})
+			if err != nil {
+				continue
+			}
+
+			addr := &ResourceAddress{
+				Path:         t.Path(),
+				Mode:         rc.Mode,
+				Type:         rc.Type,
+				Name:         rc.Name,
+				Index:        -1,
+				InstanceType: TypePrimary,
+			}
+			for _, k := range sortedInterpolatedVariableKeys(triggers.Variables) {
+				rv, ok := triggers.Variables[k].(*config.ResourceVariable)
+				if !ok || !isSelfReference(addr, rv) {
+					continue
+				}
+
+				diag := &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Self-referential trigger",
+					Detail: fmt.Sprintf(
+						"The %s argument of %s refers to %s, which belongs to the resource itself. Its value decides when the resource must be replaced, so it can't depend on attributes that aren't known until the replacement has been made.",
+						attr, addr, k,
+					),
+				}
+				if rng, ok := rc.AttributeRanges[attr]; ok && rng.Filename != "" {
+					diag.Subject = rng.ToHCL().Ptr()
+				}
+				diags = diags.Append(tfdiags.WithRule(RuleSelfReferentialTrigger, diag))
+			}
+		}
+	})
+	return diags
+}
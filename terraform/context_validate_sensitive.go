@@ -0,0 +1,116 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// displayedAttributeNames are the names of resource attributes that
+// commonly become part of a human-visible identifier for an object, in the
+// UI of Terraform or of the remote system.
+var displayedAttributeNames = map[string]bool{
+	"description":  true,
+	"display_name": true,
+	"hostname":     true,
+	"identifier":   true,
+	"name":         true,
+	"name_prefix":  true,
+	"tags":         true,
+	"title":        true,
+}
+
+// validateSensitiveInterpolations returns a warning for each interpolation
+// of a sensitive module output into a displayed attribute of a resource.
+//
+// An attribute is considered to be displayed if its name is one of
+// displayedAttributeNames, unless the resource's schema, as recorded by the
+// most recent validate walk, marks it as sensitive. Resources without a
+// recorded schema are assumed to not have any sensitive attributes.
+func (c *Context) validateSensitiveInterpolations() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	schemas := c.ResourceSchemas()
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		// Collect the sensitive outputs of each of the module's children,
+		// which are the only sensitive values the module can refer to.
+		sensitive := make(map[string]bool)
+		for name, child := range t.Children() {
+			if child.Config() == nil {
+				continue
+			}
+			for _, o := range child.Config().Outputs {
+				if o.Sensitive {
+					sensitive[fmt.Sprintf("module.%s.%s", name, o.Name)] = true
+				}
+			}
+		}
+		if len(sensitive) == 0 {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		for _, rc := range cfg.Resources {
+			schema := schemas[prefix+rc.Id()]
+			for _, k := range sortedRawKeys(rc.RawConfig.Raw) {
+				if !displayedAttributeNames[k] {
+					continue
+				}
+
+				// Parse the attribute on its own to find just the variables
+				// that it refers to.
+				raw, err := config.NewRawConfig(map[string]interface{}{k: rc.RawConfig.Raw[k]})
+				if err != nil {
+					continue
+				}
+
+				var refs []string
+				for _, v := range raw.Variables {
+					mv, ok := v.(*config.ModuleVariable)
+					if ok && sensitive[mv.FullKey()] {
+						refs = append(refs, mv.FullKey())
+					}
+				}
+				if len(refs) == 0 {
+					continue
+				}
+
+				if schema != nil && schema.Block != nil {
+					if attr := schema.Block.Attributes[k]; attr != nil && attr.Sensitive {
+						continue
+					}
+				}
+
+				sort.Strings(refs)
+				for _, ref := range refs {
+					diags = diags.Append(tfdiags.SimpleWarning(fmt.Sprintf(
+						"%s%s: the sensitive output %s is used in the attribute %q, which is likely to be displayed in plan output and elsewhere",
+						prefix, rc.Id(), ref, k,
+					)))
+				}
+			}
+		}
+	})
+
+	return diags
+}
+
+// sortedRawKeys returns the keys of the given raw configuration map in
+// lexicographic order.
+func sortedRawKeys(raw map[string]interface{}) []string {
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
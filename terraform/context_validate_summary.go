@@ -0,0 +1,96 @@
+package terraform
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// ValidateSummary is a summary of a call to Context.Validate, for example
+// for reporting the health of a configuration over time. Its field names
+// and JSON encoding are stable.
+type ValidateSummary struct {
+	// Resources is the number of resources, and Instances the number of
+	// resource instances they were expanded into, that were validated.
+	// Resources that weren't expanded, such as those belonging to providers
+	// excluded by ContextOpts.ValidateProviders, count only as resources.
+	Resources int `json:"resources"`
+	Instances int `json:"instances"`
+
+	// Providers is the number of distinct provider configurations used.
+	Providers int `json:"providers"`
+
+	// Errors and Warnings are the numbers of diagnostics of each severity
+	// returned by Validate.
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+
+	// Duration is the time taken by Validate, encoded in JSON as a number
+	// of nanoseconds.
+	Duration time.Duration `json:"duration"`
+}
+
+// ValidateSummary returns the summary of the most recent call to Validate,
+// or nil if Validate hasn't been called.
+func (c *Context) ValidateSummary() *ValidateSummary {
+	return c.validateSummary
+}
+
+// newValidateSummary returns the summary of a call to Validate that
+// returned the given diagnostics after the given duration, with the counts
+// recorded by the given walker. The walker is nil if Validate returned
+// before walking the graph.
+func newValidateSummary(diags tfdiags.Diagnostics, walker *ContextGraphWalker, d time.Duration) *ValidateSummary {
+	s := &ValidateSummary{Duration: d}
+	if walker != nil {
+		s.Resources = walker.validateCounts.resources
+		s.Instances = walker.validateCounts.instances
+		s.Providers = len(walker.validateCounts.providers)
+	}
+	s.count(diags)
+	return s
+}
+
+// count sets the numbers of errors and warnings in the summary to those in
+// the given diagnostics.
+func (s *ValidateSummary) count(diags tfdiags.Diagnostics) {
+	s.Errors, s.Warnings = 0, 0
+	for _, diag := range diags {
+		switch diag.Severity() {
+		case tfdiags.Error:
+			s.Errors++
+		case tfdiags.Warning:
+			s.Warnings++
+		}
+	}
+}
+
+// validateCounts are the numbers of vertices of each kind walked while
+// validating, for ValidateSummary.
+type validateCounts struct {
+	resources int
+	instances int
+	providers map[string]bool
+
+	lock sync.Mutex
+}
+
+// add counts the given vertex.
+func (c *validateCounts) add(v dag.Vertex) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch v.(type) {
+	case *NodeValidatableResource:
+		c.resources++
+	case *NodeValidatableResourceInstance:
+		c.instances++
+	case *NodeApplyableProvider:
+		if c.providers == nil {
+			c.providers = make(map[string]bool)
+		}
+		c.providers[dag.VertexName(v)] = true
+	}
+}
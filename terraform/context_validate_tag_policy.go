@@ -0,0 +1,155 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// TagPolicy describes the tags that managed resources are required to have,
+// for use with ContextOpts.ValidateTagPolicy.
+type TagPolicy struct {
+	// Attribute is the name of the map attribute that holds the tags, such
+	// as "labels". If it is empty, "tags" is used.
+	Attribute string
+
+	// Required are the keys that each resource's tags must contain.
+	Required []string
+
+	// Patterns are regular expressions, by tag key, that the values of the
+	// tags with those keys must match, where they are set.
+	Patterns map[string]*regexp.Regexp
+}
+
+// attribute returns the name of the attribute that holds the tags.
+func (p *TagPolicy) attribute() string {
+	if p.Attribute == "" {
+		return "tags"
+	}
+	return p.Attribute
+}
+
+// validateTagPolicies returns an error for each managed resource whose tags
+// don't comply with the context's tag policy: for the required tags that it
+// doesn't set, and for each tag whose value doesn't match the pattern for
+// its key.
+//
+// The policy applies to the resources whose schemas, as recorded by the most
+// recent validate walk, have the tags attribute, and to resources without a
+// recorded schema that set it. Tags that are set from an interpolation can't
+// be checked until apply, and neither can all of the tags of a resource whose
+// whole tags attribute is interpolated, so these are skipped.
+func (c *Context) validateTagPolicies() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	policy := c.validateTagPolicy
+	attr := policy.attribute()
+	schemas := c.ResourceSchemas()
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		for _, rc := range cfg.Resources {
+			if rc.Mode != config.ManagedResourceMode {
+				continue
+			}
+
+			raw, set := rc.RawConfig.Raw[attr]
+			if schema, ok := schemas[prefix+rc.Id()]; ok && schema.Block != nil {
+				if _, ok := schema.Block.Attributes[attr]; !ok {
+					continue
+				}
+			} else if !set {
+				continue
+			}
+
+			tags := make(map[string]interface{})
+			if set {
+				var known bool
+				tags, known = rawMapValues(raw)
+				if !known {
+					continue
+				}
+			}
+
+			subject := rc.DeclRange
+			if rng, ok := rc.AttributeRanges[attr]; ok {
+				subject = rng
+			}
+			addDiag := func(detail string) {
+				diag := &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Resource does not comply with the tag policy",
+					Detail:   detail,
+				}
+				if subject.Filename != "" {
+					diag.Subject = subject.ToHCL().Ptr()
+				}
+				diags = diags.Append(tfdiags.WithRule(RuleTagPolicy, diag))
+			}
+
+			var missing []string
+			for _, k := range policy.Required {
+				if _, ok := tags[k]; !ok {
+					missing = append(missing, fmt.Sprintf("%q", k))
+				}
+			}
+			if len(missing) > 0 {
+				addDiag(fmt.Sprintf(
+					"The %s of %s%s are missing the required keys %s.",
+					attr, prefix, rc.Id(), strings.Join(missing, ", "),
+				))
+			}
+
+			keys := make([]string, 0, len(policy.Patterns))
+			for k := range policy.Patterns {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				v, ok := tags[k].(string)
+				if !ok || strings.Contains(v, "${") {
+					continue
+				}
+				if pattern := policy.Patterns[k]; !pattern.MatchString(v) {
+					addDiag(fmt.Sprintf(
+						"The value %q of the %s key %q of %s%s does not match the required pattern %s.",
+						v, attr, k, prefix, rc.Id(), pattern,
+					))
+				}
+			}
+		}
+	})
+
+	return diags
+}
+
+// rawMapValues returns the elements of the given raw value of a map or
+// object attribute, and whether they are known. A map written in HCL may be
+// decoded as a list of maps, which are merged.
+func rawMapValues(raw interface{}) (map[string]interface{}, bool) {
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		return v, true
+	case []map[string]interface{}:
+		tags := make(map[string]interface{})
+		for _, m := range v {
+			for k, e := range m {
+				tags[k] = e
+			}
+		}
+		return tags, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,160 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hil/ast"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// languageFeature is a feature of the configuration language that was
+// introduced after the first release of Terraform, as checked by
+// validateTargetVersionFeatures.
+type languageFeature struct {
+	// Name describes the feature, such as "local values", for use in
+	// diagnostic messages.
+	Name string
+
+	// Introduced is the first version of Terraform that supports the
+	// feature.
+	Introduced *version.Version
+}
+
+var (
+	featureDataSources         = &languageFeature{"data sources", version.Must(version.NewVersion("0.7.0"))}
+	featureConditionals        = &languageFeature{"conditional expressions", version.Must(version.NewVersion("0.8.0"))}
+	featureRequiredVersion     = &languageFeature{"the terraform.required_version argument", version.Must(version.NewVersion("0.8.0"))}
+	featureBackends            = &languageFeature{"backend configuration", version.Must(version.NewVersion("0.9.0"))}
+	featureDestroyProvisioners = &languageFeature{"destroy-time provisioners", version.Must(version.NewVersion("0.9.0"))}
+	featureProvisionerFailure  = &languageFeature{"the on_failure argument of provisioners", version.Must(version.NewVersion("0.9.0"))}
+	featureWorkspace           = &languageFeature{"terraform.workspace", version.Must(version.NewVersion("0.10.0"))}
+	featureProviderVersions    = &languageFeature{"provider version constraints", version.Must(version.NewVersion("0.10.0"))}
+	featureLocals              = &languageFeature{"local values", version.Must(version.NewVersion("0.10.3"))}
+	featureModuleProviders     = &languageFeature{"the providers argument of module calls", version.Must(version.NewVersion("0.11.0"))}
+)
+
+// validateTargetVersionFeatures returns an error for each object in the
+// configuration that uses a language feature that isn't available in the
+// context's target version of Terraform, naming the version that introduced
+// it, so that configuration that must still be used with an older version,
+// such as a shared module, can be validated by a newer one.
+//
+// Only the features declared above are checked, and only where they are
+// used explicitly: an on_failure argument that sets the default of "fail"
+// can't be told apart from no argument at all, for example. Each feature is
+// reported once for each object that uses it.
+func (c *Context) validateTargetVersionFeatures() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	target, err := version.NewVersion(c.validateTargetVersion)
+	if err != nil {
+		return diags.Append(fmt.Errorf("invalid target version %q for validation: %s", c.validateTargetVersion, err))
+	}
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+
+		prefix := t.AddrPrefix()
+
+		reported := make(map[string]bool)
+		use := func(f *languageFeature, source string, subject tfdiags.SourceRange) {
+			if !f.Introduced.GreaterThan(target) || reported[source+"\x00"+f.Name] {
+				return
+			}
+			reported[source+"\x00"+f.Name] = true
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Feature not available in target version",
+				Detail: fmt.Sprintf(
+					"%s%s uses %s, a feature introduced in Terraform %s, but the configuration is being validated for Terraform %s.",
+					prefix, source, f.Name, f.Introduced, target,
+				),
+			}
+			if subject.Filename != "" {
+				diag.Subject = subject.ToHCL().Ptr()
+			}
+			diags = diags.Append(tfdiags.WithRule(RuleTargetVersion, diag))
+		}
+		useInterpolations := func(source string, raw *config.RawConfig, subject tfdiags.SourceRange) {
+			if raw == nil {
+				return
+			}
+			for _, n := range raw.Interpolations {
+				n.Accept(func(n ast.Node) ast.Node {
+					if _, ok := n.(*ast.Conditional); ok {
+						use(featureConditionals, source, subject)
+					}
+					return n
+				})
+			}
+			for _, k := range sortedInterpolatedVariableKeys(raw.Variables) {
+				if v, ok := raw.Variables[k].(*config.TerraformVariable); ok && v.Field == "workspace" {
+					use(featureWorkspace, source, subject)
+				}
+			}
+		}
+
+		if tf := cfg.Terraform; tf != nil {
+			if tf.RequiredVersion != "" {
+				use(featureRequiredVersion, "terraform", tf.KeyRanges["required_version"])
+			}
+			if tf.Backend != nil {
+				use(featureBackends, "terraform", tf.KeyRanges["backend"])
+			}
+		}
+
+		for _, pc := range cfg.ProviderConfigs {
+			source := "provider." + pc.FullName()
+			if pc.Version != "" {
+				use(featureProviderVersions, source, pc.DeclRange)
+			}
+			useInterpolations(source, pc.RawConfig, pc.DeclRange)
+		}
+
+		for _, m := range cfg.Modules {
+			source := "module." + m.Name
+			if len(m.Providers) > 0 {
+				use(featureModuleProviders, source, tfdiags.SourceRange{})
+			}
+			useInterpolations(source, m.RawConfig, tfdiags.SourceRange{})
+		}
+
+		for _, rc := range cfg.Resources {
+			source := rc.Id()
+			if rc.Mode == config.DataResourceMode {
+				use(featureDataSources, source, rc.DeclRange)
+			}
+			useInterpolations(source, rc.RawCount, rc.DeclRange)
+			useInterpolations(source, rc.RawConfig, rc.DeclRange)
+			for _, p := range rc.Provisioners {
+				if p.When == config.ProvisionerWhenDestroy {
+					use(featureDestroyProvisioners, source, p.DeclRange)
+				}
+				if p.OnFailure == config.ProvisionerOnFailureContinue {
+					use(featureProvisionerFailure, source, p.DeclRange)
+				}
+				useInterpolations(source, p.RawConfig, p.DeclRange)
+				useInterpolations(source, p.ConnInfo, p.DeclRange)
+			}
+		}
+
+		for _, l := range cfg.Locals {
+			source := "local." + l.Name
+			use(featureLocals, source, tfdiags.SourceRange{})
+			useInterpolations(source, l.RawConfig, tfdiags.SourceRange{})
+		}
+
+		for _, o := range cfg.Outputs {
+			useInterpolations("output."+o.Name, o.RawConfig, o.DeclRange)
+		}
+	})
+
+	return diags
+}
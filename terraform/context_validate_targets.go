@@ -0,0 +1,108 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/helper/didyoumean"
+)
+
+// validateTargets returns an error for each of the context's targets that
+// doesn't match any resource or module declared in the configuration, such
+// as when the name of a resource is misspelled. Instance indices are not
+// checked, since the number of instances isn't known until the graph is
+// walked.
+//
+// Resources and modules that are present only in the state are also
+// accepted, so that orphans can still be targeted for destruction.
+func (c *Context) validateTargets() []error {
+	var addrs []*ResourceAddress
+	var modulePaths [][]string
+	c.module.DeepEach(func(t *module.Tree) {
+		modulePaths = append(modulePaths, t.Path())
+		cfg := t.Config()
+		if cfg == nil {
+			return
+		}
+		for _, rc := range cfg.Resources {
+			addrs = append(addrs, &ResourceAddress{
+				Path:  t.Path(),
+				Mode:  rc.Mode,
+				Type:  rc.Type,
+				Name:  rc.Name,
+				Index: -1,
+			})
+		}
+	})
+
+	c.stateLock.RLock()
+	for _, ms := range c.state.Modules {
+		path := normalizeModulePath(ms.Path)[1:]
+		modulePaths = append(modulePaths, path)
+		for k := range ms.Resources {
+			key, err := ParseResourceStateKey(k)
+			if err != nil {
+				continue
+			}
+			addrs = append(addrs, &ResourceAddress{
+				Path:  path,
+				Mode:  key.Mode,
+				Type:  key.Type,
+				Name:  key.Name,
+				Index: -1,
+			})
+		}
+	}
+	c.stateLock.RUnlock()
+
+	var errs []error
+	for _, target := range c.targets {
+		ta, err := ParseResourceAddress(target)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if targetDeclared(ta, addrs, modulePaths) {
+			continue
+		}
+
+		given := *ta
+		given.Index = -1
+		given.InstanceTypeSet = false
+		suggestions := make([]string, len(addrs))
+		for i, addr := range addrs {
+			suggestions[i] = addr.String()
+		}
+
+		msg := fmt.Sprintf("target %q does not match any resource in the configuration", target)
+		if suggestion := didyoumean.NameSuggestion(given.String(), suggestions); suggestion != "" {
+			msg += fmt.Sprintf(". Did you mean %q?", suggestion)
+		}
+		errs = append(errs, fmt.Errorf("%s", msg))
+	}
+	return errs
+}
+
+// targetDeclared returns true if the given target address refers to one of
+// the given resource addresses, or, when it has no resource type, to one of
+// the given module paths or their descendents.
+func targetDeclared(target *ResourceAddress, addrs []*ResourceAddress, modulePaths [][]string) bool {
+	targetPath := strings.Join(target.Path, ".")
+	if target.Type == "" {
+		for _, path := range modulePaths {
+			if len(path) >= len(target.Path) && strings.Join(path[:len(target.Path)], ".") == targetPath {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, addr := range addrs {
+		if addr.Mode == target.Mode && addr.Type == target.Type && addr.Name == target.Name &&
+			strings.Join(addr.Path, ".") == targetPath {
+			return true
+		}
+	}
+	return false
+}
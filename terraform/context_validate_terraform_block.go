@@ -0,0 +1,71 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/helper/didyoumean"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// terraformBlockKeys are the names of the arguments and nested blocks that
+// are recognized in a "terraform" block. Some of these are not used by
+// this version of Terraform, but are accepted so that configuration
+// written for other versions can be validated.
+var terraformBlockKeys = []string{
+	"backend",
+	"cloud",
+	"experiments",
+	"required_providers",
+	"required_version",
+}
+
+// validateTerraformBlocks returns an error for each argument or nested block
+// in the "terraform" block of each module in the context's module tree whose
+// name is not one of terraformBlockKeys, suggesting the closest recognized
+// name where there is one.
+func (c *Context) validateTerraformBlocks() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	known := make(map[string]bool, len(terraformBlockKeys))
+	for _, k := range terraformBlockKeys {
+		known[k] = true
+	}
+
+	c.module.DeepEach(func(t *module.Tree) {
+		cfg := t.Config()
+		if cfg == nil || cfg.Terraform == nil {
+			return
+		}
+
+		keys := make([]string, 0, len(cfg.Terraform.KeyRanges))
+		for k := range cfg.Terraform.KeyRanges {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if known[k] {
+				continue
+			}
+
+			detail := fmt.Sprintf("An argument or block named %q is not expected in the terraform block.", k)
+			if suggestion := didyoumean.NameSuggestion(k, terraformBlockKeys); suggestion != "" {
+				detail += fmt.Sprintf(" Did you mean %q?", suggestion)
+			}
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported terraform block argument",
+				Detail:   detail,
+			}
+			if rng := cfg.Terraform.KeyRanges[k]; rng.Filename != "" {
+				diag.Subject = rng.ToHCL().Ptr()
+			}
+			diags = diags.Append(tfdiags.WithRule(RuleTerraformBlockArgument, diag))
+		}
+	})
+
+	return diags
+}
@@ -1,9 +1,25 @@
 package terraform
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestContext2Validate_badCount(t *testing.T) {
@@ -127,6 +143,79 @@ func TestContext2Validate_countComputed(t *testing.T) {
 	}
 }
 
+func TestContext2Validate_countIndexBounds(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-count-index-bounds")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics; want 1\n%s", len(diags), diags.Err())
+	}
+	got := diags[0].Description().Summary
+	want := "output 'out_of_bounds': index 5 in variable aws_instance.web.5.id is out of range; aws_instance.web has count = 3, so the valid indices are 0 to 2"
+	if got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestContext2Validate_countSensitive(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-sensitive-count")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics; want 1", len(diags))
+	}
+	got := diags[0].Description().Summary
+	want := `resource 'aws_instance.web' count: refers to the sensitive output "secret_count" of module "child"; the value will be disclosed by the number of instances, which is shown in plan output`
+	if got != want {
+		t.Fatalf("wrong warning\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestContext2Validate_reservedResourceType(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-reserved-resource-type")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+				"var": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics; want 1\n%s", len(diags), diags.Err())
+	}
+	got := diags[0].Description().Summary
+	want := `var.web: resource type "var" is reserved, because interpolations that start with "var." refer to variables, so the resource could never be referred to`
+	if got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
 func TestContext2Validate_countNegative(t *testing.T) {
 	p := testProvider("aws")
 	m := testModule(t, "validate-count-negative")
@@ -220,6 +309,131 @@ func TestContext2Validate_moduleBadOutput(t *testing.T) {
 	}
 }
 
+func TestContext2Validate_moduleVarType(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-module-var-type")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("succeeded; want error")
+	}
+	want := "variable zones in module child should be type list, got string"
+	if err := diags.Err().Error(); !strings.Contains(err, want) {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, want)
+	}
+}
+
+func TestContext2Validate_moduleVersionLocal(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-module-version-local")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("succeeded; want error")
+	}
+	want := `module "child": version can only be set for modules from a module registry, but "./child" is not a registry address`
+	if err := diags.Err().Error(); !strings.Contains(err, want) {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, want)
+	}
+}
+
+func TestContext2Validate_moduleArgumentTypo(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-module-argument-typo")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics; want 1\n%s", len(diags), diags.Err())
+	}
+	got := diags[0].Description().Summary
+	want := `module "child": "instance_tpye" is not a valid argument; did you mean "instance_type"?`
+	if got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestContext2Validate_moduleCallCycle(t *testing.T) {
+	m := testModule(t, "validate-module-call-cycle")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+	})
+
+	diags := c.Validate()
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics; want 2\n%s", len(diags), diags.Err())
+	}
+	if got := diags[0].Description().Summary; !strings.HasPrefix(got, "Cycle: ") {
+		t.Fatalf("wrong graph error: %s", got)
+	}
+
+	diag := diags[1]
+	if got, want := tfdiags.Rule(diag), RuleModuleCallCycle; got != want {
+		t.Fatalf("wrong rule %q; want %q", got, want)
+	}
+	desc := diag.Description()
+	if got, want := desc.Summary, "Cycle between module calls"; got != want {
+		t.Fatalf("wrong summary %q; want %q", got, want)
+	}
+	if got, want := desc.Detail, "The arguments of module.a, module.b depend on each other's outputs, so none of these modules can be evaluated before the others."; got != want {
+		t.Fatalf("wrong detail\ngot:  %s\nwant: %s", got, want)
+	}
+	subject := diag.Source().Subject
+	if subject == nil || subject.Start.Line != 1 {
+		t.Fatalf("wrong subject %#v; want the declaration of module.a", subject)
+	}
+}
+
+func TestContext2Validate_moduleNestedReference(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-nested-module-reference")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.Description().Summary)
+	}
+	sort.Strings(got)
+	want := []string{
+		`output 'subnet_id': module.network.module.subnets.id reaches through module "network" into the module that it calls as "subnets", but only the outputs of module "network" can be referred to; add an output to module "network" that exports the value, and refer to that instead`,
+		`resource 'aws_instance.web' config: module.network.module.vpc.id reaches through module "network" into the module that it calls as "vpc", but only the outputs of module "network" can be referred to; its output "vpc_id" exports a value from module "vpc", and can be referred to as module.network.vpc_id`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
 func TestContext2Validate_moduleGood(t *testing.T) {
 	p := testProvider("aws")
 	m := testModule(t, "validate-good-module")
@@ -450,6 +664,38 @@ func TestContext2Validate_providerConfig_badEmpty(t *testing.T) {
 	}
 }
 
+func TestContext2Validate_providerAliasMisuse(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-provider-alias-misuse")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	var got []string
+	for _, diag := range diags {
+		desc := diag.Description()
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+		}
+		got = append(got, fmt.Sprintf("%s: %s: %s: %s", diag.Severity(), subject.StartString(), desc.Summary, desc.Detail))
+	}
+	path := filepath.Join(fixtureDir, "validate-provider-alias-misuse", "main.tf")
+	want := []string{
+		fmt.Sprintf(`%s: %s:1,10: Redundant provider configuration alias: The configuration for provider "aws" has the alias "aws", so it isn't the default configuration for the provider: only resources that set provider = "aws.aws" use it. If this is meant to be the default configuration, remove the alias argument.`, tfdiags.Warning, path),
+		fmt.Sprintf(`%s: %s:14,10: Provider configuration not present: aws_instance.web uses the "aws" provider configuration aws.west, which isn't defined by its module or by any module that calls it. Only default provider configurations are created implicitly, so the alias must be defined by a provider "aws" block with alias = "west". If the default "aws" provider block of this module is meant to be aws.west, set alias = "west" in it.`, tfdiags.Error, path),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
 func TestContext2Validate_providerConfig_good(t *testing.T) {
 	m := testModule(t, "validate-bad-pc")
 	p := testProvider("aws")
@@ -520,6 +766,48 @@ func TestContext2Validate_provisionerConfig_good(t *testing.T) {
 	}
 }
 
+func TestContext2Validate_provisionerReferences(t *testing.T) {
+	m := testModule(t, "validate-provisioner-references")
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"foo": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+	}
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Provisioners: map[string]ResourceProvisionerFactory{
+			"local-exec": testProvisionerFuncFixed(testProvisioner()),
+		},
+		Variables: map[string]interface{}{
+			"name": "web",
+		},
+	})
+
+	diags := c.Validate()
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.Description().Summary)
+	}
+	sort.Strings(got)
+	path := filepath.Join(fixtureDir, "validate-provisioner-references", "main.tf")
+	want := []string{
+		fmt.Sprintf(`aws_instance.web: %s:10,17-26: Unsupported attribute; The local-exec provisioner of aws_instance.web refers to self.nope in environment.ADDR. The resource aws_instance.web, which self refers to, has no attribute named "nope".`, path),
+		fmt.Sprintf(`aws_instance.web: %s:11,17-30: Reference to undeclared local value; The local-exec provisioner of aws_instance.web refers to local.missing in environment.NAME. A local value named "missing" has not been declared.`, path),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
 func TestContext2Validate_requiredVar(t *testing.T) {
 	m := testModule(t, "validate-required-var")
 	p := testProvider("aws")
@@ -538,6 +826,41 @@ func TestContext2Validate_requiredVar(t *testing.T) {
 	}
 }
 
+func TestContext2Validate_requiredVarNonInteractive(t *testing.T) {
+	m := testModule(t, "validate-required-var")
+	p := testProvider("aws")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		ValidateNonInteractive: true,
+	})
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(diags), diags.Err())
+	}
+
+	desc := diags[0].Description()
+	if got, want := desc.Summary, "Required variable not set: foo"; got != want {
+		t.Fatalf("wrong summary %q; want %q", got, want)
+	}
+	if !strings.Contains(desc.Detail, "running non-interactively") {
+		t.Fatalf("detail doesn't mention non-interactive mode: %s", desc.Detail)
+	}
+
+	subject := diags[0].Source().Subject
+	if subject == nil {
+		t.Fatal("diagnostic has no source range")
+	}
+	if got, want := subject.StartString(), "test-fixtures/validate-required-var/main.tf:1,10"; !strings.HasSuffix(got, want) {
+		t.Fatalf("wrong source range %q; want %q", got, want)
+	}
+}
+
 func TestContext2Validate_resourceConfig_bad(t *testing.T) {
 	m := testModule(t, "validate-bad-rc")
 	p := testProvider("aws")
@@ -648,6 +971,30 @@ func TestContext2Validate_selfRefMultiAll(t *testing.T) {
 	}
 }
 
+func TestContext2Validate_selfRefThroughLocals(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-self-ref-local")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if got, want := len(diags), 1; got != want {
+		t.Fatalf("wrong number of diagnostics %d; want %d: %s", got, want, diags.Err())
+	}
+
+	got := diags[0].Description().Summary
+	want := "aws_instance.foo: self reference not allowed through local values: aws_instance.foo -> local.name -> local.prefix -> aws_instance.foo"
+	if got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
 func TestContext2Validate_tainted(t *testing.T) {
 	p := testProvider("aws")
 	m := testModule(t, "validate-good")
@@ -725,6 +1072,77 @@ func TestContext2Validate_targetedDestroy(t *testing.T) {
 	}
 }
 
+func TestContext2Validate_targetsUndeclared(t *testing.T) {
+	tests := []struct {
+		Targets []string
+		WantErr string
+	}{
+		{
+			[]string{"aws_instance.foo"},
+			"",
+		},
+		{
+			[]string{"aws_instance.foo[1]", "aws_instance.bar"},
+			"",
+		},
+		{
+			[]string{"aws_instance.orphan"},
+			"",
+		},
+		{
+			[]string{"aws_instance.fooo"},
+			`target "aws_instance.fooo" does not match any resource in the configuration. Did you mean "aws_instance.foo"?`,
+		},
+		{
+			[]string{"module.child"},
+			`target "module.child" does not match any resource in the configuration`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.Targets, ","), func(t *testing.T) {
+			m := testModule(t, "validate-targeted")
+			p := testProvider("aws")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				Provisioners: map[string]ResourceProvisionerFactory{
+					"shell": testProvisionerFuncFixed(testProvisioner()),
+				},
+				State: &State{
+					Modules: []*ModuleState{
+						&ModuleState{
+							Path: rootModulePath,
+							Resources: map[string]*ResourceState{
+								"aws_instance.orphan": resourceState("aws_instance", "i-abc123"),
+							},
+						},
+					},
+				},
+				Targets: test.Targets,
+			})
+
+			diags := c.Validate()
+			if test.WantErr == "" {
+				if diags.HasErrors() {
+					t.Fatalf("unexpected errors: %s", diags.Err())
+				}
+				return
+			}
+			if !diags.HasErrors() {
+				t.Fatal("succeeded; want error")
+			}
+			if got := diags.Err().Error(); !strings.Contains(got, test.WantErr) {
+				t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, test.WantErr)
+			}
+		})
+	}
+}
+
 func TestContext2Validate_varRefFilled(t *testing.T) {
 	m := testModule(t, "validate-variable-ref")
 	p := testProvider("aws")
@@ -876,3 +1294,2667 @@ func TestContext2Validate_PlanGraphBuilder(t *testing.T) {
 		t.Fatal(walker.ValidationErrors)
 	}
 }
+
+func TestContext2Validate_failFast(t *testing.T) {
+	m := testModule(t, "validate-fail-fast")
+
+	for _, failFast := range []bool{false, true} {
+		t.Run(fmt.Sprintf("failFast=%t", failFast), func(t *testing.T) {
+			p := testProvider("aws")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				ValidateFailFast: failFast,
+			})
+
+			var lock sync.Mutex
+			var validated []string
+			p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+				lock.Lock()
+				defer lock.Unlock()
+
+				foo, _ := c.Get("foo")
+				validated = append(validated, fmt.Sprintf("%v", foo))
+				if foo == "bad" {
+					return nil, []error{fmt.Errorf("foo is bad")}
+				}
+				return nil, nil
+			}
+
+			diags := c.Validate()
+			if !diags.HasErrors() {
+				t.Fatal("succeeded; want errors")
+			}
+
+			want := 2
+			if failFast {
+				want = 1
+			}
+			if len(validated) != want {
+				t.Fatalf("validated %d resources; want %d\n%#v", len(validated), want, validated)
+			}
+
+			// The errors from the first layer must still be returned.
+			found := false
+			for _, diag := range diags {
+				if strings.Contains(diag.Description().Summary, "foo is bad") {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("missing error from first layer: %#v", diags)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_failFastSeverities(t *testing.T) {
+	m := testModule(t, "validate-fail-fast-severities")
+
+	cases := map[string]struct {
+		Severities map[string]tfdiags.Severity
+		Validated  bool
+	}{
+		"error stops the walk": {
+			nil,
+			false,
+		},
+		"error remapped to a warning": {
+			map[string]tfdiags.Severity{RuleDataSourceLifecycle: tfdiags.Warning},
+			true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := testProvider("aws")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				ValidateFailFast:   true,
+				ValidateSeverities: tc.Severities,
+			})
+
+			var lock sync.Mutex
+			validated := false
+			p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+				lock.Lock()
+				defer lock.Unlock()
+				validated = true
+				return nil, nil
+			}
+
+			diags := c.Validate()
+			if diags.HasErrors() == tc.Validated {
+				t.Fatalf("wrong diagnostics: %s", diags.Err())
+			}
+			if validated != tc.Validated {
+				t.Fatalf("aws_instance.web validated is %t; want %t", validated, tc.Validated)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_sensitiveInterpolation(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			p := testProvider("aws")
+			p.GetSchemaReturn = &ProviderSchema{
+				ResourceTypes: map[string]*configschema.Block{
+					"aws_instance": {
+						Attributes: map[string]*configschema.Attribute{
+							"name":        {Type: cty.String, Optional: true},
+							"description": {Type: cty.String, Optional: true, Sensitive: true},
+						},
+					},
+				},
+			}
+			m := testModule(t, "validate-sensitive-interpolation")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				ValidateSensitiveInterpolation: enabled,
+			})
+
+			diags := c.Validate()
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			var got []string
+			for _, diag := range diags {
+				got = append(got, diag.Description().Summary)
+			}
+
+			var want []string
+			if enabled {
+				want = []string{
+					`aws_instance.foo: the sensitive output module.child.password is used in the attribute "name", which is likely to be displayed in plan output and elsewhere`,
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_replacementChurn(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			p := testProvider("aws")
+			p.GetSchemaReturn = &ProviderSchema{
+				ResourceTypes: map[string]*configschema.Block{
+					"aws_instance": {
+						Attributes: map[string]*configschema.Attribute{
+							"ami":  {Type: cty.String, Required: true, ForceNew: true},
+							"name": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+			}
+			m := testModule(t, "validate-replacement-churn")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				ValidateReplacementChurn: enabled,
+			})
+
+			diags := c.Validate()
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			var got []string
+			for _, diag := range diags {
+				got = append(got, diag.Description().Summary)
+			}
+
+			var want []string
+			if enabled {
+				want = []string{
+					`aws_instance.volatile: the attribute "ami" forces replacement when it changes, but it is set using uuid(), which returns a different value on every run, so the resource will be replaced on every apply`,
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_summary(t *testing.T) {
+	p := testProvider("aws")
+	p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+		return []string{"instance is deprecated"}, nil
+	}
+	m := testModule(t, "validate-summary")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	if got := c.ValidateSummary(); got != nil {
+		t.Fatalf("summary before Validate is %#v; want nil", got)
+	}
+
+	diags := c.Validate()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	got := c.ValidateSummary()
+	if got == nil {
+		t.Fatal("no summary after Validate")
+	}
+	if got.Duration <= 0 {
+		t.Errorf("wrong duration %s; want positive", got.Duration)
+	}
+	got.Duration = 0
+
+	want := &ValidateSummary{
+		Resources: 2,
+		Instances: 4,
+		Providers: 1,
+		Warnings:  4,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong summary\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	js, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"resources":2,"instances":4,"providers":1,"errors":0,"warnings":4,"duration":0}`
+	if string(js) != wantJSON {
+		t.Fatalf("wrong JSON\ngot:  %s\nwant: %s", js, wantJSON)
+	}
+}
+
+func TestContext2Validate_triggerSelfRef(t *testing.T) {
+	m := testModule(t, "validate-trigger-self-ref")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws":  testProviderFuncFixed(testProvider("aws")),
+				"null": testProviderFuncFixed(testProvider("null")),
+			},
+		),
+	})
+
+	diags := c.Validate()
+
+	var got []string
+	for _, diag := range diags {
+		desc := diag.Description()
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+		}
+		got = append(got, fmt.Sprintf("%s: %s: %s", subject.StartString(), desc.Summary, desc.Detail))
+	}
+
+	want := []string{
+		fmt.Sprintf(
+			"%s:10,3: Self-referential trigger: The triggers argument of null_resource.self refers to null_resource.self.id, which belongs to the resource itself. Its value decides when the resource must be replaced, so it can't depend on attributes that aren't known until the replacement has been made.",
+			filepath.Join(fixtureDir, "validate-trigger-self-ref", "main.tf"),
+		),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_tagPolicy(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"tags": {Type: cty.Map(cty.String), Optional: true},
+				},
+			},
+			"aws_eip": {
+				Attributes: map[string]*configschema.Attribute{
+					"vpc": {Type: cty.Bool, Optional: true},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-tag-policy")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Variables: map[string]interface{}{
+			"tags": map[string]interface{}{"env": "prod"},
+		},
+		ValidateTagPolicy: &TagPolicy{
+			Required: []string{"owner", "env"},
+			Patterns: map[string]*regexp.Regexp{
+				"env": regexp.MustCompile(`^(prod|dev)$`),
+			},
+		},
+	})
+
+	diags := c.Validate()
+
+	var got []string
+	for _, diag := range diags {
+		desc := diag.Description()
+		if diag.Severity() != tfdiags.Error {
+			t.Errorf("diagnostic has wrong severity; want error")
+		}
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+		}
+		// Child modules are loaded from a copy in a temporary directory, so
+		// only the base names of the files are compared.
+		got = append(got, fmt.Sprintf(
+			"%s:%d,%d: %s",
+			filepath.Base(subject.Filename), subject.Start.Line, subject.Start.Column, desc.Detail,
+		))
+	}
+
+	want := []string{
+		`main.tf:13,3: The tags of aws_instance.missing are missing the required keys "owner".`,
+		`main.tf:13,3: The value "staging" of the tags key "env" of aws_instance.missing does not match the required pattern ^(prod|dev)$.`,
+		`main.tf:18,10: The tags of aws_instance.untagged are missing the required keys "owner", "env".`,
+		`main.tf:2,3: The tags of module.child.aws_instance.web are missing the required keys "env".`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_ignoreChangesAssigned(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			p := testProvider("aws")
+			m := testModule(t, "validate-ignore-changes-assigned")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				ValidateIgnoredAttributes: enabled,
+			})
+
+			diags := c.Validate()
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			var got []string
+			for _, diag := range diags {
+				desc := diag.Description()
+				if diag.Severity() != tfdiags.Warning {
+					t.Errorf("diagnostic has wrong severity; want warning")
+				}
+				subject := diag.Source().Subject
+				if subject == nil {
+					t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+				}
+				context := diag.Source().Context
+				if context == nil {
+					t.Fatalf("diagnostic has no context range: %s", desc.Summary)
+				}
+				got = append(got, fmt.Sprintf("%s (%s): %s", subject.StartString(), context.ToHCL(), desc.Detail))
+			}
+
+			var want []string
+			if enabled {
+				filename := filepath.Join(fixtureDir, "validate-ignore-changes-assigned", "main.tf")
+				want = []string{
+					fmt.Sprintf(`%s:2,3 (%s:2,3-8,22): The attribute "ami" of aws_instance.ignored is set in the configuration, but "ami" is also listed in lifecycle.ignore_changes, so the configured value has no effect once the resource has been created.`, filename, filename),
+					fmt.Sprintf(`%s:3,3 (%s:3,3-8,22): The attribute "tags" of aws_instance.ignored is set in the configuration, but "tags.Name" is also listed in lifecycle.ignore_changes, so the configured value has no effect once the resource has been created.`, filename, filename),
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_countUnknown(t *testing.T) {
+	applied := &State{
+		Modules: []*ModuleState{
+			{
+				Path: rootModulePath,
+				Resources: map[string]*ResourceState{
+					"aws_instance.a": resourceState("aws_instance", "i-abc123"),
+				},
+			},
+		},
+	}
+
+	for name, state := range map[string]*State{"new": nil, "applied": applied} {
+		t.Run(name, func(t *testing.T) {
+			p := testProvider("aws")
+			p.GetSchemaReturn = &ProviderSchema{
+				ResourceTypes: map[string]*configschema.Block{
+					"aws_instance": {
+						Attributes: map[string]*configschema.Attribute{
+							"foo":   {Type: cty.String, Optional: true, Computed: true},
+							"zones": {Type: cty.List(cty.String), Computed: true},
+						},
+					},
+				},
+			}
+			m := testModule(t, "validate-count-unknown")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				State:  state,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+			})
+
+			diags := c.Validate()
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			var got []string
+			for _, diag := range diags {
+				desc := diag.Description()
+				if diag.Severity() != tfdiags.Warning {
+					t.Errorf("diagnostic has wrong severity; want warning")
+				}
+				subject := diag.Source().Subject
+				if subject == nil {
+					t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+				}
+				got = append(got, fmt.Sprintf("%s: %s", subject.StartString(), desc.Detail))
+			}
+
+			var want []string
+			if state == nil {
+				want = []string{
+					fmt.Sprintf("%s:10,11: The count of aws_instance.by_id depends on aws_instance.a.id, which is not known until that resource has been created, so it can't be expanded into instances until apply.", filepath.Join(fixtureDir, "validate-count-unknown", "main.tf")),
+					fmt.Sprintf("%s:14,11: The count of aws_instance.by_local depends on aws_instance.a.zones, which is not known until that resource has been created, so it can't be expanded into instances until apply.", filepath.Join(fixtureDir, "validate-count-unknown", "main.tf")),
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_terraformBlockTypo(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-terraform-block-typo")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(diags), diags.Err())
+	}
+
+	diag := diags[0]
+	if diag.Severity() != tfdiags.Error {
+		t.Fatalf("diagnostic has wrong severity; want error")
+	}
+	desc := diag.Description()
+	if got, want := desc.Detail, `An argument or block named "requried_version" is not expected in the terraform block. Did you mean "required_version"?`; got != want {
+		t.Fatalf("wrong detail\ngot:  %s\nwant: %s", got, want)
+	}
+	subject := diag.Source().Subject
+	if subject == nil {
+		t.Fatal("diagnostic has no source range")
+	}
+	if got, want := subject.StartString(), filepath.Join(fixtureDir, "validate-terraform-block-typo", "main.tf")+":2,3"; got != want {
+		t.Fatalf("wrong source range %s; want %s", got, want)
+	}
+}
+
+func TestContext2Validate_providersFilter(t *testing.T) {
+	cases := map[string]struct {
+		Targets []string
+		Want    []string
+	}{
+		"all": {
+			Want: []string{"aws_instance", "aws_instance", "data.aws_data_source"},
+		},
+		"targeted": {
+			Targets: []string{"aws_instance.web", "do_droplet.web"},
+			Want:    []string{"aws_instance"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var lock sync.Mutex
+			var got []string
+			record := func(name string) {
+				lock.Lock()
+				defer lock.Unlock()
+				got = append(got, name)
+			}
+
+			aws := testProvider("aws")
+			aws.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+				record(t)
+				return nil, nil
+			}
+			aws.ValidateDataSourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+				record("data." + t)
+				return nil, nil
+			}
+			do := testProvider("do")
+			do.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+				record(t)
+				return nil, nil
+			}
+
+			m := testModule(t, "validate-providers-filter")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(aws),
+						"do":  testProviderFuncFixed(do),
+					},
+				),
+				Targets:           tc.Targets,
+				ValidateProviders: []string{"aws"},
+			})
+
+			diags := c.Validate()
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.Want) {
+				t.Fatalf("wrong validated resources\ngot:  %#v\nwant: %#v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_clock(t *testing.T) {
+	var got map[string]interface{}
+	p := testProvider("aws")
+	p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+		got = c.Config
+		return nil, nil
+	}
+	m := testModule(t, "validate-clock")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		ValidateClock: func() time.Time {
+			return time.Date(2017, 11, 22, 0, 0, 0, 0, time.UTC)
+		},
+	})
+
+	diags := c.Validate()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := map[string]interface{}{
+		"tags": []map[string]interface{}{
+			{
+				"created": "2017-11-22T00:00:00Z",
+				"expires": "2017-11-23T00:00:00Z",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong config\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_schemaOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	schemas := ProviderSchemas{
+		"aws": {
+			Provider: &configschema.Block{},
+			ResourceTypes: map[string]*configschema.Block{
+				"aws_instance": {
+					Attributes: map[string]*configschema.Attribute{
+						"ami": {Type: cty.String, Required: true},
+					},
+					BlockTypes: map[string]*configschema.NestedBlock{
+						"ebs_block_device": {
+							Block: configschema.Block{
+								Attributes: map[string]*configschema.Attribute{
+									"device_name": {Type: cty.String, Required: true},
+									"size":        {Type: cty.Number, Optional: true},
+								},
+							},
+							Nesting: configschema.NestingList,
+						},
+					},
+				},
+			},
+		},
+	}
+	path := filepath.Join(dir, "schemas.json")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := WriteProviderSchemas(schemas, f); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	f.Close()
+
+	m := testModule(t, "validate-schema-only")
+	c := testContext2(t, &ContextOpts{
+		Module:              m,
+		ValidateSchemasPath: path,
+	})
+
+	diags := c.Validate()
+	var errs, warns []string
+	for _, diag := range diags {
+		switch diag.Severity() {
+		case tfdiags.Error:
+			errs = append(errs, diag.Description().Summary)
+		case tfdiags.Warning:
+			warns = append(warns, diag.Description().Summary)
+		}
+	}
+
+	wantErrs := []string{
+		`aws_instance.foo: "bogus": unsupported argument`,
+		`aws_instance.foo: "ebs_block_device.0.device_name": required field is not set`,
+	}
+	if !reflect.DeepEqual(errs, wantErrs) {
+		t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", errs, wantErrs)
+	}
+	if len(warns) != 1 || !strings.Contains(warns[0], "provider-side validation was skipped") {
+		t.Fatalf("wrong warnings: %#v", warns)
+	}
+}
+
+func TestContext2Validate_schemaOnlyMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	schemas := ProviderSchemas{
+		"aws": {
+			Provider: &configschema.Block{},
+			ResourceTypes: map[string]*configschema.Block{
+				"aws_instance": {
+					Attributes: map[string]*configschema.Attribute{
+						"ami": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	path := filepath.Join(dir, "schemas.json")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := WriteProviderSchemas(schemas, f); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	f.Close()
+
+	// The azurerm provider has no schema either, but isn't needed by the
+	// targeted resources.
+	m := testModule(t, "validate-schema-missing")
+	c := testContext2(t, &ContextOpts{
+		Module:              m,
+		ValidateSchemasPath: path,
+		Targets: []string{
+			"aws_instance.web",
+			"google_compute_instance.app",
+			"google_compute_instance.west",
+		},
+	})
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(diags), diags.Err())
+	}
+	desc := diags[0].Description()
+	if got, want := desc.Summary, "Missing provider schemas"; got != want {
+		t.Errorf("wrong summary %q; want %q", got, want)
+	}
+	wantDetail := `The provider schemas given for validation have no schemas for the providers "google", which are needed by provider.google, provider.google.west. Export the schemas of these providers along with the others.`
+	if desc.Detail != wantDetail {
+		t.Errorf("wrong detail\ngot:  %s\nwant: %s", desc.Detail, wantDetail)
+	}
+}
+
+func TestContext2Validate_schemaSnapshot(t *testing.T) {
+	p := testProvider("aws")
+	p.ResourcesReturn = []ResourceType{{Name: "aws_instance"}}
+	p.GetSchemaReturn = &ProviderSchema{
+		Provider: &configschema.Block{},
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {Type: cty.String, Required: true},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-schema-only")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	schemas, err := c.ExportProviderSchemas()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got, want := p.GetSchemaRequest.ResourceTypes, []string{"aws_instance"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong resource types requested\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteProviderSchemas(schemas, &buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	got, err := ReadProviderSchemas(&buf)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, ok := got["aws"].ResourceTypes["aws_instance"].Attributes["ami"]; !ok {
+		t.Fatalf("snapshot is missing the aws_instance schema: %#v", got)
+	}
+}
+
+func TestReadProviderSchemas_formatVersion(t *testing.T) {
+	for _, src := range []string{`{"providers": {}}`, `{"format_version": 2, "providers": {}}`} {
+		_, err := ReadProviderSchemas(strings.NewReader(src))
+		if err == nil {
+			t.Fatalf("%s: expected an error", src)
+		}
+		if !strings.Contains(err.Error(), "Regenerate them") {
+			t.Fatalf("%s: wrong error: %s", src, err)
+		}
+	}
+}
+
+func TestContext2Validate_preventDestroy(t *testing.T) {
+	for _, destroy := range []bool{false, true} {
+		t.Run(fmt.Sprintf("destroy=%t", destroy), func(t *testing.T) {
+			p := testProvider("aws")
+			m := testModule(t, "validate-prevent-destroy")
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				State: &State{
+					Modules: []*ModuleState{
+						&ModuleState{
+							Path: rootModulePath,
+							Resources: map[string]*ResourceState{
+								"aws_instance.foo": &ResourceState{
+									Type:    "aws_instance",
+									Primary: &InstanceState{ID: "i-abc123"},
+								},
+								"aws_instance.bar": &ResourceState{
+									Type:    "aws_instance",
+									Primary: &InstanceState{ID: "i-def456"},
+								},
+							},
+						},
+						&ModuleState{
+							Path: []string{"root", "child"},
+							Resources: map[string]*ResourceState{
+								"aws_instance.baz": &ResourceState{
+									Type:    "aws_instance",
+									Primary: &InstanceState{ID: "i-ghi789"},
+								},
+							},
+						},
+					},
+				},
+				Destroy: destroy,
+			})
+
+			diags := c.Validate()
+			if !destroy {
+				if len(diags) != 0 {
+					t.Fatalf("unexpected diagnostics: %#v", diags)
+				}
+				return
+			}
+
+			var got []string
+			for _, diag := range diags {
+				got = append(got, diag.Description().Summary)
+			}
+			sort.Strings(got)
+			want := []string{
+				"aws_instance.foo: " + validatePreventDestroyErrStr,
+				"module.child.aws_instance.baz: " + validatePreventDestroyErrStr,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_outputSensitivity(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"ami":      {Type: cty.String, Optional: true},
+					"password": {Type: cty.String, Computed: true, Sensitive: true},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-output-sensitivity")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.Description().Summary)
+	}
+	sort.Strings(got)
+	want := []string{
+		`output.child_secret: output "child_secret" is not marked as sensitive, but its value refers to the sensitive output module.child.secret; set sensitive = true to avoid displaying it`,
+		`output.password: output "password" is not marked as sensitive, but its value refers to the sensitive attribute aws_instance.foo.password; set sensitive = true to avoid displaying it`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong warnings\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_outputSensitivityChain(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"ami":      {Type: cty.String, Optional: true},
+					"password": {Type: cty.String, Computed: true, Sensitive: true},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-output-sensitivity-chain")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.Description().Summary)
+	}
+	sort.Strings(got)
+	want := []string{
+		`module.child.module.inner.output.token: output "token" is not marked as sensitive, but its value refers to the sensitive attribute aws_instance.foo.password; set sensitive = true to avoid displaying it`,
+		`module.child.output.token: output "token" is not marked as sensitive, but its value refers to output module.inner.token, which is derived from sensitive data (module.child.module.inner.output.token <- module.child.module.inner.aws_instance.foo.password); set sensitive = true to avoid displaying it`,
+		`output.token: output "token" is not marked as sensitive, but its value refers to output module.child.token, which is derived from sensitive data (module.child.output.token <- module.child.module.inner.output.token <- module.child.module.inner.aws_instance.foo.password); set sensitive = true to avoid displaying it`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong warnings\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_resourceSchemas(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+		DataSources: map[string]*configschema.Block{
+			"aws_ami": {
+				Attributes: map[string]*configschema.Attribute{
+					"name": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-resource-schemas")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		ProviderVersions: map[string]string{
+			"aws": "1.2.0",
+		},
+	})
+
+	diags := c.Validate()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	got := c.ResourceSchemas()
+	want := map[string]*ResourceSchema{
+		"aws_instance.foo": {
+			Provider:        "provider.aws.west",
+			ProviderVersion: "1.2.0",
+			Block:           p.GetSchemaReturn.ResourceTypes["aws_instance"],
+		},
+		"data.aws_ami.ami": {
+			Provider:        "provider.aws",
+			ProviderVersion: "1.2.0",
+			Block:           p.GetSchemaReturn.DataSources["aws_ami"],
+		},
+		"module.child.aws_instance.bar": {
+			Provider:        "provider.aws",
+			ProviderVersion: "1.2.0",
+			Block:           p.GetSchemaReturn.ResourceTypes["aws_instance"],
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong schemas\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// The result must not share anything with the context.
+	got["aws_instance.foo"].Block.Attributes["ami"].Required = true
+	if c.ResourceSchemas()["aws_instance.foo"].Block.Attributes["ami"].Required {
+		t.Fatal("modifying the result modified the context's schemas")
+	}
+}
+
+func TestContext2Validate_resourceSchemasSharedProvider(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {},
+		},
+	}
+	started := 0
+	m := testModule(t, "transform-provider-collapse")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": func() (ResourceProvider, error) {
+					started++
+					return p, nil
+				},
+			},
+		),
+	})
+
+	if diags := c.Validate(); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// Without input, provider.aws.same and provider.aws.input have the same
+	// configuration as provider.aws, so they share its instance, but their
+	// resources still belong to them.
+	if started != 2 {
+		t.Errorf("started %d provider instances; want 2", started)
+	}
+	got := make(map[string]string)
+	for addr, schema := range c.ResourceSchemas() {
+		got[addr] = schema.Provider
+	}
+	want := map[string]string{
+		"aws_instance.a": "provider.aws",
+		"aws_instance.b": "provider.aws.same",
+		"aws_instance.c": "provider.aws.other",
+		"aws_instance.d": "provider.aws.input",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong providers\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2ValidateByModule(t *testing.T) {
+	p := testProvider("aws")
+	p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+		switch v, _ := c.Get("type"); v {
+		case "bad":
+			return nil, []error{fmt.Errorf("type is bad")}
+		case "warn":
+			return []string{"type is dubious"}, nil
+		}
+		return nil, nil
+	}
+	m := testModule(t, "validate-by-module")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags, tree := c.ValidateByModule()
+	if got, want := len(diags), 3; got != want {
+		t.Fatalf("wrong number of diagnostics %d; want %d", got, want)
+	}
+
+	type module struct {
+		Path     string
+		Messages []string
+	}
+	var got []module
+	var visit func(t *ModuleDiagnosticsTree)
+	visit = func(t *ModuleDiagnosticsTree) {
+		m := module{Path: strings.Join(t.Path, ".")}
+		for _, diag := range t.Diagnostics {
+			m.Messages = append(m.Messages, diag.Description().Summary)
+		}
+		got = append(got, m)
+		for _, child := range t.Children {
+			visit(child)
+		}
+	}
+	visit(tree)
+
+	want := []module{
+		{"", []string{"aws_instance.foo: type is bad"}},
+		{"child", []string{"module.child.aws_instance.bar: type is dubious"}},
+		{"child.grandchild", []string{"module.child.module.grandchild.aws_instance.baz: type is bad"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong tree\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2ValidateRecursive(t *testing.T) {
+	p := testProvider("aws")
+	p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+		if v, _ := c.Get("type"); v == "bad" {
+			return nil, []error{fmt.Errorf("type is bad")}
+		}
+		return nil, nil
+	}
+	m := testModule(t, "validate-recursive")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	results := c.ValidateRecursive()
+
+	got := make([]string, len(results))
+	for i, result := range results {
+		var summaries []string
+		for _, diag := range result.Diagnostics {
+			summaries = append(summaries, diag.Description().Summary)
+		}
+		got[i] = fmt.Sprintf("%v: %s", result.Path, strings.Join(summaries, "; "))
+	}
+
+	want := []string{
+		"[]: ",
+		"[child]: aws_instance.web: type is bad",
+		"[child grandchild]: ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong results\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2ValidateRecursive_changedFiles(t *testing.T) {
+	p := testProvider("aws")
+	p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+		if v, _ := c.Get("type"); v == "bad" {
+			return nil, []error{fmt.Errorf("type is bad")}
+		}
+		return nil, nil
+	}
+	m := testModule(t, "validate-recursive")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		ValidateChangedFiles: []string{
+			filepath.Join(fixtureDir, "validate-recursive", "child", "grandchild", "main.tf"),
+		},
+	})
+
+	results := c.ValidateRecursive()
+
+	// The changed file limits the validation of the root module only, so
+	// the child module is still validated in full.
+	var got []string
+	for _, result := range results {
+		for _, diag := range result.Diagnostics {
+			got = append(got, fmt.Sprintf("%v: %s", result.Path, diag.Description().Summary))
+		}
+	}
+	want := []string{
+		"[child]: aws_instance.web: type is bad",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong results\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_providerOverride(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"ami": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-provider-override")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		ValidateProviderOverrides: map[string]string{
+			"aws_instance.foo": "aws.west",
+		},
+	})
+
+	diags := c.Validate()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	got := make(map[string]string)
+	for addr, schema := range c.ResourceSchemas() {
+		got[addr] = schema.Provider
+	}
+	want := map[string]string{
+		"aws_instance.foo": "provider.aws.west",
+		"aws_instance.bar": "provider.aws",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong providers\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_providerOverrideMissing(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-provider-override")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		ValidateProviderOverrides: map[string]string{
+			"aws_instance.foo": "aws.east",
+		},
+	})
+
+	diags := c.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("succeeded; want error")
+	}
+	want := "aws_instance.foo: can't be validated with provider provider.aws.east, which is not in the configuration"
+	if got := diags.Err().Error(); !strings.Contains(got, want) {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestContextResourceAttributeCoverage(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"id":            {Type: cty.String, Computed: true},
+					"ami":           {Type: cty.String, Required: true},
+					"instance_type": {Type: cty.String, Optional: true},
+					"tags":          {Type: cty.Map(cty.String), Optional: true},
+					"subnet_id":     {Type: cty.String, Optional: true, Computed: true},
+				},
+			},
+		},
+		DataSources: map[string]*configschema.Block{
+			"aws_ami": {
+				Attributes: map[string]*configschema.Attribute{
+					"name": {Type: cty.String, Required: true},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-attribute-coverage")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Variables: map[string]interface{}{
+			"ami": "ami-def456",
+		},
+	})
+
+	if diags := c.Validate(); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	got := c.ResourceAttributeCoverage()
+	want := map[string]*AttributeCoverage{
+		"aws_instance.full": {
+			Available: 3,
+			Set:       2,
+			Unset:     []string{"subnet_id"},
+		},
+		"aws_instance.partial": {
+			Available: 3,
+			Set:       0,
+			Unset:     []string{"instance_type", "subnet_id", "tags"},
+		},
+		"data.aws_ami.ubuntu": {
+			Available: 0,
+			Set:       0,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong coverage\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if got, want := got["aws_instance.full"].Ratio(), 2.0/3.0; got != want {
+		t.Errorf("wrong ratio %f; want %f", got, want)
+	}
+	if got := got["data.aws_ami.ubuntu"].Ratio(); got != 1 {
+		t.Errorf("wrong ratio %f for a type without optional attributes; want 1", got)
+	}
+}
+
+func TestContext2Validate_singletonCount(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_account_settings": {},
+			"aws_instance":         {},
+		},
+		Singletons: map[string]bool{
+			"aws_account_settings": true,
+		},
+	}
+	m := testModule(t, "validate-singleton-count")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics; want 1\n%s", len(diags), diags.Err())
+	}
+	want := fmt.Sprintf(
+		`aws_account_settings.main: %s:2,11-11: Invalid count argument; The provider marks "aws_account_settings" as a singleton resource type, of which there can be only one instance, so count can't be set on aws_account_settings.main.`,
+		filepath.Join(fixtureDir, "validate-singleton-count", "main.tf"),
+	)
+	if got := diags.Err().Error(); got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestContext2Validate_instanceLimit(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_eip":      {},
+			"aws_instance": {},
+		},
+		InstanceLimits: map[string]int{
+			"aws_eip": 5,
+		},
+	}
+	m := testModule(t, "validate-instance-limit")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Variables: map[string]interface{}{
+			"replicas": "8",
+		},
+	})
+
+	diags := c.Validate()
+	var got []string
+	for _, diag := range diags {
+		if tfdiags.Rule(diag) != RuleInstanceLimit {
+			t.Errorf("unexpected diagnostic: %s", diag.Description().Summary)
+			continue
+		}
+		got = append(got, diag.Description().Summary)
+	}
+	sort.Strings(got)
+	path := filepath.Join(fixtureDir, "validate-instance-limit", "main.tf")
+	want := []string{
+		fmt.Sprintf(`aws_eip.db: %s:10,11-11: Too many resource instances; The count of aws_eip.db is 8, but the provider limits resources of type "aws_eip" to 5 instances.`, path),
+		fmt.Sprintf(`aws_eip.web: %s:6,11-11: Too many resource instances; The count of aws_eip.web is 6, but the provider limits resources of type "aws_eip" to 5 instances.`, path),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_zeroCount(t *testing.T) {
+	m := testModule(t, "validate-zero-count")
+	opts := &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+		ValidateZeroCount: true,
+	}
+
+	diags := testContext2(t, opts).Validate()
+	var got []string
+	for _, diag := range diags {
+		if diag.Severity() != tfdiags.Warning {
+			t.Errorf("wrong severity %s; want warning", diag.Severity())
+		}
+		if rule := tfdiags.Rule(diag); rule != RuleZeroCount {
+			t.Errorf("wrong rule %q; want %q", rule, RuleZeroCount)
+		}
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Errorf("no subject for %q", diag.Description().Detail)
+			continue
+		}
+		got = append(got, fmt.Sprintf("%s:%d: %s", filepath.Base(subject.Filename), subject.Start.Line, diag.Description().Detail))
+	}
+	sort.Strings(got)
+	want := []string{
+		`main.tf:2: The count of module.child.data.aws_ami.dead is set to 0, so it never has any instances and its configuration has no effect. Remove the resource if it's no longer needed, or set count from a variable if it's meant to be switched on and off.`,
+		`main.tf:6: The count of aws_instance.dead is set to 0, so it never has any instances and its configuration has no effect. Remove the resource if it's no longer needed, or set count from a variable if it's meant to be switched on and off.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// The check is off by default.
+	opts.ValidateZeroCount = false
+	if diags := testContext2(t, opts).Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+}
+
+func TestContext2Validate_missingFiles(t *testing.T) {
+	m := testModule(t, "validate-missing-files")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+	})
+
+	// The walk interpolates each call to file, so a missing file is an
+	// error whether or not its path is a literal.
+	diags := c.Validate()
+	if len(diags) != 3 {
+		t.Fatalf("got %d diagnostics; want 3\n%s", len(diags), diags.Err())
+	}
+	dir, err := filepath.Abs(filepath.Join(fixtureDir, "validate-missing-files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := diags.Err().Error()
+	for _, want := range []string{
+		"aws_instance.web: file: open " + filepath.Join(dir, "missing.txt") + ": no such file or directory",
+		"aws_instance.db: file: open missing.txt: no such file or directory",
+		"module.child.output.missing: file: open " + filepath.Join(dir, "child.txt") + ": no such file or directory",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing error %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestContext2Validate_workspaceLookups(t *testing.T) {
+	m := testModule(t, "validate-workspace-lookups")
+	opts := &ContextOpts{
+		Module: m,
+		Meta:   &ContextMeta{Env: "default"},
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+	}
+
+	if diags := testContext2(t, opts).Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+
+	// Lookups are evaluated during the walk with the current workspace, so
+	// a workspace that a map has no element for is an error.
+	opts.Meta = &ContextMeta{Env: "prod"}
+	diags := testContext2(t, opts).Validate()
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics; want 2\n%s", len(diags), diags.Err())
+	}
+	got := diags.Err().Error()
+	for _, want := range []string{
+		"aws_instance.web: lookup: lookup failed to find 'prod'",
+		`aws_instance.app: key "prod" does not exist in map local.instance_types`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing error %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestContext2Validate_targetVersion(t *testing.T) {
+	m := testModule(t, "validate-target-version")
+	opts := &ContextOpts{
+		Module: m,
+		Meta:   &ContextMeta{Env: "default"},
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+		Provisioners: map[string]ResourceProvisionerFactory{
+			"local-exec": testProvisionerFuncFixed(testProvisioner()),
+		},
+		ValidateTargetVersion: "0.9.11",
+	}
+
+	diags := testContext2(t, opts).Validate()
+	var got []string
+	for _, diag := range diags {
+		if diag.Severity() != tfdiags.Error {
+			t.Errorf("wrong severity %s; want error", diag.Severity())
+		}
+		if rule := tfdiags.Rule(diag); rule != RuleTargetVersion {
+			t.Errorf("wrong rule %q; want %q", rule, RuleTargetVersion)
+		}
+		line := 0
+		if subject := diag.Source().Subject; subject != nil {
+			line = subject.Start.Line
+		}
+		got = append(got, fmt.Sprintf("%d: %s", line, diag.Description().Detail))
+	}
+	sort.Strings(got)
+	want := []string{
+		`0: local.name uses local values, a feature introduced in Terraform 0.10.3, but the configuration is being validated for Terraform 0.9.11.`,
+		`13: aws_instance.web uses terraform.workspace, a feature introduced in Terraform 0.10.0, but the configuration is being validated for Terraform 0.9.11.`,
+		`5: provider.aws uses provider version constraints, a feature introduced in Terraform 0.10.0, but the configuration is being validated for Terraform 0.9.11.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// Targeting an older version reports the earlier features too,
+	// including those used by child modules.
+	opts.ValidateTargetVersion = "0.7.13"
+	diags = testContext2(t, opts).Validate()
+	got = nil
+	for _, diag := range diags {
+		got = append(got, diag.Description().Detail)
+	}
+	sort.Strings(got)
+	want = []string{
+		`aws_instance.web uses conditional expressions, a feature introduced in Terraform 0.8.0, but the configuration is being validated for Terraform 0.7.13.`,
+		`aws_instance.web uses destroy-time provisioners, a feature introduced in Terraform 0.9.0, but the configuration is being validated for Terraform 0.7.13.`,
+		`aws_instance.web uses terraform.workspace, a feature introduced in Terraform 0.10.0, but the configuration is being validated for Terraform 0.7.13.`,
+		`local.name uses local values, a feature introduced in Terraform 0.10.3, but the configuration is being validated for Terraform 0.7.13.`,
+		`module.child.aws_instance.child uses conditional expressions, a feature introduced in Terraform 0.8.0, but the configuration is being validated for Terraform 0.7.13.`,
+		`provider.aws uses provider version constraints, a feature introduced in Terraform 0.10.0, but the configuration is being validated for Terraform 0.7.13.`,
+		`terraform uses the terraform.required_version argument, a feature introduced in Terraform 0.8.0, but the configuration is being validated for Terraform 0.7.13.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// Every feature is available in the current version.
+	opts.ValidateTargetVersion = "0.11.0"
+	if diags := testContext2(t, opts).Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+}
+
+func TestContext2Validate_unresolvableProvider(t *testing.T) {
+	m := testModule(t, "validate-unresolvable-provider")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	var got []string
+	for _, diag := range diags {
+		if diag.Severity() != tfdiags.Error {
+			t.Errorf("wrong severity %s; want error", diag.Severity())
+		}
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Errorf("no subject for %q", diag.Description().Detail)
+			continue
+		}
+		got = append(got, fmt.Sprintf("%s:%d: %s", filepath.Base(subject.Filename), subject.Start.Line, diag.Description().Detail))
+	}
+	sort.Strings(got)
+	want := []string{
+		`main.tf:9: aws_instance.west uses the "aws" provider configuration aws.west, which isn't defined by its module or by any module that calls it. Only default provider configurations are created implicitly, so the alias must be defined by a provider "aws" block with alias = "west".`,
+		`main.tf:9: module.child.aws_instance.south uses the "aws" provider configuration aws.south, which isn't defined by its module or by any module that calls it. Only default provider configurations are created implicitly, so the alias must be defined by a provider "aws" block with alias = "south".`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_computedPlaceholders(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"id":              {Type: cty.String, Computed: true},
+					"ami":             {Type: cty.String, Optional: true},
+					"security_groups": {Type: cty.Set(cty.String), Optional: true, Computed: true},
+					"tags":            {Type: cty.Map(cty.String), Optional: true, Computed: true},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"network": {
+						Nesting: configschema.NestingList,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"name": {Type: cty.String, Optional: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-computed-placeholders")
+	opts := &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		ValidateComputedPlaceholders: true,
+	}
+
+	diags := testContext2(t, opts).Validate()
+
+	// The list of security groups is interpolated into a list, which is
+	// left for the provider to check, and the tags are a map as required.
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.Description().Summary)
+	}
+	sort.Strings(got)
+	want := []string{
+		`aws_instance.app: "ami": must be a single value, such as a string or number, but it is set to aws_instance.web.0.security_groups, which is computed as set of string`,
+		`aws_instance.app: "network.0.name": must be a single value, such as a string or number, but it is set to aws_instance.web.*.id, which is computed as list of string`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// The check is off by default.
+	opts.ValidateComputedPlaceholders = false
+	if diags := testContext2(t, opts).Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+}
+
+func TestContext2Validate_internalAttribute(t *testing.T) {
+	p := testProvider("aws")
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"id":             {Type: cty.String, Computed: true},
+					"ami":            {Type: cty.String, Optional: true},
+					"private_ip":     {Type: cty.String, Computed: true},
+					"internal_state": {Type: cty.String, Computed: true, Internal: true},
+					"tags":           {Type: cty.Map(cty.String), Optional: true},
+				},
+			},
+		},
+	}
+	m := testModule(t, "validate-internal-attribute")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		Provisioners: map[string]ResourceProvisionerFactory{
+			"local-exec": testProvisionerFuncFixed(testProvisioner()),
+		},
+	})
+
+	diags := c.Validate()
+	var got []string
+	for _, diag := range diags {
+		if rule := tfdiags.Rule(diag); rule != RuleInternalAttribute {
+			t.Errorf("wrong rule %q for %s; want %q", rule, diag.Description().Summary, RuleInternalAttribute)
+		}
+		got = append(got, diag.Description().Summary)
+	}
+	sort.Strings(got)
+	want := []string{
+		`aws_instance.app: "tags.State": this argument refers to aws_instance.web.internal_state, but the attribute "internal_state" of aws_instance.web is internal to its provider and can't be referred to from configuration`,
+		`aws_instance.bastion: provisioner local-exec: "command": this argument refers to aws_instance.web.internal_state, but the attribute "internal_state" of aws_instance.web is internal to its provider and can't be referred to from configuration`,
+		`local.state: "value": this argument refers to aws_instance.web.internal_state, but the attribute "internal_state" of aws_instance.web is internal to its provider and can't be referred to from configuration`,
+		`output.state: "value": this argument refers to aws_instance.web.internal_state, but the attribute "internal_state" of aws_instance.web is internal to its provider and can't be referred to from configuration`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_impliedProviders(t *testing.T) {
+	m := testModule(t, "validate-implied-providers")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws":  testProviderFuncFixed(testProvider("aws")),
+				"null": testProviderFuncFixed(testProvider("null")),
+			},
+		),
+		ValidateImpliedProviders: true,
+	})
+
+	diags := c.Validate()
+
+	var got []string
+	for _, diag := range diags {
+		desc := diag.Description()
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+		}
+		got = append(got, fmt.Sprintf("%s:%d: %s: %s", filepath.Base(subject.Filename), subject.Start.Line, desc.Summary, desc.Detail))
+	}
+	sort.Strings(got)
+
+	want := []string{
+		`main.tf:1: Provider not declared in required_providers: module.child.aws_instance.baz belongs to provider "aws" only by the naming convention for its type, since the required_providers block of its module doesn't declare that provider. Declare it there to make the provider explicit.`,
+		`main.tf:9: Provider not declared in required_providers: null_resource.bar belongs to provider "null" only by the naming convention for its type, since the required_providers block of its module doesn't declare that provider. Declare it there to make the provider explicit.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// The check is off by default.
+	c = testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws":  testProviderFuncFixed(testProvider("aws")),
+				"null": testProviderFuncFixed(testProvider("null")),
+			},
+		),
+	})
+	if diags := c.Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+}
+
+func TestContext2Validate_redundantDependsOn(t *testing.T) {
+	m := testModule(t, "validate-redundant-depends-on")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+		ValidateRedundantDependsOn: true,
+	})
+
+	diags := c.Validate()
+
+	var got []string
+	for _, diag := range diags {
+		desc := diag.Description()
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+		}
+		if diag.Severity() != tfdiags.Warning {
+			t.Errorf("diagnostic has wrong severity %#v; want warning", diag.Severity())
+		}
+		got = append(got, fmt.Sprintf("%s:%d,%d: %s: %s", filepath.Base(subject.Filename), subject.Start.Line, subject.Start.Column, desc.Summary, desc.Detail))
+	}
+	sort.Strings(got)
+
+	// The dependency of aws_instance.app on aws_instance.db is needed,
+	// since nothing else in its configuration refers to it.
+	want := []string{
+		`main.tf:5,17: Redundant depends_on entry: module.child.aws_instance.b depends on module.child.aws_instance.a explicitly, but its configuration already refers to that resource, which implies the dependency. The entry can be removed from depends_on.`,
+		`main.tf:7,17: Redundant depends_on entry: aws_instance.app depends on aws_instance.web explicitly, but its configuration already refers to that resource, which implies the dependency. The entry can be removed from depends_on.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// The check is off by default.
+	c = testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+	})
+	if diags := c.Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+}
+
+func TestContext2Validate_providerResourceCycle(t *testing.T) {
+	m := testModule(t, "validate-provider-resource-cycle")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+	})
+
+	diags := c.Validate()
+
+	// The aliased provider configuration refers to the same resource, but
+	// the resource doesn't belong to it and so there is no cycle.
+	var got []string
+	for _, diag := range diags {
+		desc := diag.Description()
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+		}
+		got = append(got, fmt.Sprintf("%s:%d: %s: %s", filepath.Base(subject.Filename), subject.Start.Line, desc.Summary, desc.Detail))
+	}
+	want := []string{
+		`main.tf:1: Provider configuration depends on its own resource: The configuration of provider.aws refers to aws_instance.web.id, but aws_instance.web belongs to that provider configuration. The provider can't be configured until the resource exists, and the resource can't be created until the provider is configured.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2ValidateByRule(t *testing.T) {
+	p := testProvider("aws")
+	p.ValidateResourceReturnErrors = []error{fmt.Errorf("provider says no")}
+	m := testModule(t, "validate-by-rule")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+		ValidateRedundantDependsOn: true,
+		ValidateMaxNestingDepth:    1,
+	})
+
+	diags, byRule := c.ValidateByRule()
+
+	got := make(map[string][]string)
+	for rule, ruleDiags := range byRule {
+		for _, diag := range ruleDiags {
+			if tfdiags.Rule(diag) != rule {
+				t.Errorf("diagnostic %q has rule %q; want %q", diag.Description().Summary, tfdiags.Rule(diag), rule)
+			}
+			got[rule] = append(got[rule], diag.Description().Summary)
+		}
+	}
+	want := map[string][]string{
+		"": {
+			"aws_instance.app: provider says no",
+			"aws_instance.web: provider says no",
+		},
+		RuleNestingDepth: {
+			`aws_instance.app: "nested.0.deeper.0": the configuration is nested more than 1 levels deep here, which is the most that can be validated`,
+		},
+		RuleRedundantDependsOn: {
+			"Redundant depends_on entry",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics by rule\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	var count int
+	for _, ruleDiags := range byRule {
+		count += len(ruleDiags)
+	}
+	if count != len(diags) {
+		t.Fatalf("got %d diagnostics grouped by rule; want %d", count, len(diags))
+	}
+}
+
+func TestContext2Validate_maxModuleDepth(t *testing.T) {
+	m := testModule(t, "validate-module-depth")
+	opts := &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+		ValidateMaxModuleDepth: 2,
+	}
+
+	diags := testContext2(t, opts).Validate()
+
+	var got []string
+	for _, diag := range diags {
+		if diag.Severity() != tfdiags.Warning {
+			t.Errorf("diagnostic has wrong severity %#v; want warning", diag.Severity())
+		}
+		if rule := tfdiags.Rule(diag); rule != RuleModuleDepth {
+			t.Errorf("diagnostic has wrong rule %q; want %q", rule, RuleModuleDepth)
+		}
+		got = append(got, diag.Description().Summary)
+	}
+	want := []string{
+		"module.a.module.b.module.c is nested 3 module calls deep, which is more than the limit of 2.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// The whole tree is within a limit of its depth.
+	opts.ValidateMaxModuleDepth = 3
+	if diags := testContext2(t, opts).Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+}
+
+func TestContext2Validate_dataSourceLifecycle(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "validate-data-lifecycle")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics; want 1\n%s", len(diags), diags.Err())
+	}
+	want := fmt.Sprintf(
+		`data.aws_ami.ubuntu: %s:4,3-3: Invalid data source lifecycle argument; data.aws_ami.ubuntu sets ignore_changes in its lifecycle block, but data sources are only read, never created or destroyed, so lifecycle arguments apply only to managed resources.`,
+		filepath.Join(fixtureDir, "validate-data-lifecycle", "main.tf"),
+	)
+	if got := diags.Err().Error(); got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestContext2ValidateVariableDependencies(t *testing.T) {
+	m := testModule(t, "validate-variable-dependencies")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+		Variables: map[string]interface{}{
+			"ami":    "ami-123",
+			"prefix": "test",
+			"size":   "large",
+			"unused": "unused",
+		},
+	})
+
+	got := c.ValidateVariableDependencies()
+	if got.Diagnostics.HasErrors() {
+		t.Fatalf("unexpected errors: %s", got.Diagnostics.Err())
+	}
+	want := map[string][]string{
+		"aws_instance.lb":               {"module.child.var.size", "var.size"},
+		"aws_instance.none":             {},
+		"aws_instance.web":              {"var.ami", "var.prefix"},
+		"module.child.aws_instance.app": {"module.child.var.size", "var.size"},
+	}
+	if !reflect.DeepEqual(got.Resources, want) {
+		t.Fatalf("wrong dependencies\ngot:  %#v\nwant: %#v", got.Resources, want)
+	}
+
+	if got, want := got.Affected("var.size"), []string{"aws_instance.lb", "module.child.aws_instance.app"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong resources affected by var.size\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if got := got.Affected("var.unused"); len(got) != 0 {
+		t.Errorf("unexpected resources affected by var.unused: %#v", got)
+	}
+}
+
+func TestContext2ValidateReferenceGraph(t *testing.T) {
+	m := testModule(t, "validate-reference-graph")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+		Variables: map[string]interface{}{
+			"region": "us-east-1",
+		},
+	})
+
+	g, diags := c.ValidateReferenceGraph()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	got := strings.TrimSpace(g.String())
+	want := strings.TrimSpace(testContextValidateReferenceGraphStr)
+	if got != want {
+		t.Fatalf("wrong graph\ngot:\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestContext2ValidateOrder(t *testing.T) {
+	m := testModule(t, "validate-order")
+	tests := map[string]struct {
+		Targets []string
+		Want    [][]string
+	}{
+		"untargeted": {
+			nil,
+			[][]string{
+				{"aws_instance.db", "data.aws_ami.base"},
+				{"aws_instance.web", "module.child.aws_instance.app"},
+				{"aws_instance.lb"},
+			},
+		},
+		"targeted": {
+			[]string{"aws_instance.web"},
+			[][]string{
+				{"data.aws_ami.base"},
+				{"aws_instance.web"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(testProvider("aws")),
+					},
+				),
+				Targets: test.Targets,
+			})
+
+			got := c.ValidateOrder()
+			if got.Diagnostics.HasErrors() {
+				t.Fatalf("unexpected errors: %s", got.Diagnostics.Err())
+			}
+			if !reflect.DeepEqual(got.Layers, test.Want) {
+				t.Fatalf("wrong layers\ngot:  %#v\nwant: %#v", got.Layers, test.Want)
+			}
+			var want []string
+			for _, layer := range test.Want {
+				want = append(want, layer...)
+			}
+			if !reflect.DeepEqual(got.Resources, want) {
+				t.Fatalf("wrong order\ngot:  %#v\nwant: %#v", got.Resources, want)
+			}
+		})
+	}
+}
+
+func TestContext2ValidateProviderConfigs(t *testing.T) {
+	m := testModule(t, "validate-provider-configs")
+	tests := map[string]struct {
+		Targets []string
+		Want    []string
+	}{
+		"untargeted": {
+			nil,
+			[]string{"module.child.provider.aws", "provider.aws.west", "provider.null"},
+		},
+		"targeted resource": {
+			[]string{"aws_instance.foo"},
+			[]string{"provider.aws.west"},
+		},
+		"targeted module": {
+			[]string{"module.child"},
+			[]string{"module.child.provider.aws"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testContext2(t, &ContextOpts{
+				Module: m,
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws":  testProviderFuncFixed(testProvider("aws")),
+						"null": testProviderFuncFixed(testProvider("null")),
+					},
+				),
+				Targets: test.Targets,
+			})
+
+			got, err := c.ValidateProviderConfigs()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Fatalf("wrong providers\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_hiddenDependencies(t *testing.T) {
+	m := testModule(t, "validate-hidden-dependencies")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+		ValidateHiddenDependencies: true,
+	})
+
+	diags := c.Validate()
+
+	var got []string
+	for _, diag := range diags {
+		desc := diag.Description()
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+		}
+		if diag.Severity() != tfdiags.Warning {
+			t.Errorf("diagnostic has wrong severity %#v; want warning", diag.Severity())
+		}
+		if rule := tfdiags.Rule(diag); rule != RuleHiddenDependency {
+			t.Errorf("diagnostic has wrong rule %q; want %q", rule, RuleHiddenDependency)
+		}
+		got = append(got, fmt.Sprintf("%s:%d: %s: %s", filepath.Base(subject.Filename), subject.Start.Line, desc.Summary, desc.Detail))
+	}
+	sort.Strings(got)
+
+	want := []string{
+		`main.tf:6: Possible hidden dependency: aws_instance.web.subnet_id and aws_route.web.subnet_id are both set to "subnet-0a1b2c3d", but neither resource refers to the other, so they may be created and destroyed in either order. If one of them must be created first, add it to the depends_on of the other.`,
+		`main.tf:6: Possible hidden dependency: module.child.aws_instance.app.subnet_id and module.child.aws_route_table_association.app.subnet_id are both set to "subnet-4a1b2c3d", but neither resource refers to the other, so they may be created and destroyed in either order. If one of them must be created first, add it to the depends_on of the other.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	// The check is off by default.
+	c = testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+	})
+	if diags := c.Validate(); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	rawConfig := func(raw map[string]interface{}) *config.RawConfig {
+		rc, err := config.NewRawConfig(raw)
+		if err != nil {
+			t.Fatalf("bad raw config: %s", err)
+		}
+		return rc
+	}
+	resource := func(name string, line int, raw map[string]interface{}, dependsOn ...string) *config.Resource {
+		count := rawConfig(map[string]interface{}{"count": "1"})
+		count.Key = "count"
+		return &config.Resource{
+			Mode:      config.ManagedResourceMode,
+			Name:      name,
+			Type:      "aws_instance",
+			RawCount:  count,
+			RawConfig: rawConfig(raw),
+			DependsOn: dependsOn,
+			DeclRange: tfdiags.SourceRange{
+				Filename: "generated",
+				Start:    tfdiags.SourcePos{Line: line, Column: 1},
+				End:      tfdiags.SourcePos{Line: line, Column: 1},
+			},
+		}
+	}
+
+	root := &config.Config{
+		Modules: []*config.Module{
+			{
+				Name:      "child",
+				Source:    "./child",
+				RawConfig: rawConfig(map[string]interface{}{}),
+			},
+		},
+		Resources: []*config.Resource{
+			resource("web", 1, map[string]interface{}{}),
+		},
+	}
+	child := &config.Config{
+		Resources: []*config.Resource{
+			resource("a", 1, map[string]interface{}{}),
+			resource("b", 2, map[string]interface{}{"foo": "${aws_instance.a.id}"}, "aws_instance.a"),
+		},
+	}
+	var paths []string
+	fn := func(path []string, m *module.Module) (*config.Config, error) {
+		paths = append(paths, strings.Join(path, "."))
+		return child, nil
+	}
+
+	diags := ValidateConfig(root, fn, &ContextOpts{
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(testProvider("aws")),
+			},
+		),
+		ValidateRedundantDependsOn: true,
+	})
+
+	if got, want := paths, []string{"child"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong module paths\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	var got []string
+	for _, diag := range diags {
+		desc := diag.Description()
+		subject := diag.Source().Subject
+		if subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", desc.Summary)
+		}
+		got = append(got, fmt.Sprintf("%s:%d: %s", subject.Filename, subject.Start.Line, desc.Summary))
+	}
+	want := []string{
+		`generated:2: Redundant depends_on entry`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong diagnostics\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestContext2Validate_severities(t *testing.T) {
+	cases := map[string]struct {
+		Fixture    string
+		Rule       string
+		Severities map[string]tfdiags.Severity
+		Want       tfdiags.Severity
+	}{
+		"warning to error": {
+			"validate-redundant-depends-on",
+			RuleRedundantDependsOn,
+			map[string]tfdiags.Severity{RuleRedundantDependsOn: tfdiags.Error},
+			tfdiags.Error,
+		},
+		"error to warning": {
+			"validate-data-lifecycle",
+			RuleDataSourceLifecycle,
+			// The empty rule must not affect diagnostics without a rule.
+			map[string]tfdiags.Severity{
+				RuleDataSourceLifecycle: tfdiags.Warning,
+				"":                      tfdiags.Warning,
+			},
+			tfdiags.Warning,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := testProvider("aws")
+			p.ValidateResourceReturnErrors = []error{fmt.Errorf("provider says no")}
+			c := testContext2(t, &ContextOpts{
+				Module: testModule(t, tc.Fixture),
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				ValidateRedundantDependsOn: true,
+				ValidateSeverities:         tc.Severities,
+			})
+
+			diags, tree := c.ValidateByModule()
+			var remapped, others int
+			for _, diag := range diags {
+				if tfdiags.Rule(diag) == tc.Rule {
+					remapped++
+					if diag.Severity() != tc.Want {
+						t.Errorf("wrong severity %s for %q; want %s", diag.Severity(), diag.Description().Summary, tc.Want)
+					}
+					continue
+				}
+				if strings.Contains(diag.Description().Summary, "provider says no") {
+					others++
+					if diag.Severity() != tfdiags.Error {
+						t.Errorf("diagnostic without a rule was remapped to %s", diag.Severity())
+					}
+				}
+			}
+			if remapped == 0 || others == 0 {
+				t.Fatalf("got %d diagnostics for the rule and %d from the provider; want some of each\n%s", remapped, others, diags.Err())
+			}
+
+			for _, diag := range tree.Diagnostics {
+				if tfdiags.Rule(diag) == tc.Rule && diag.Severity() != tc.Want {
+					t.Errorf("wrong severity %s in the module tree; want %s", diag.Severity(), tc.Want)
+				}
+			}
+
+			summary := c.ValidateSummary()
+			var errs int
+			for _, diag := range diags {
+				if diag.Severity() == tfdiags.Error {
+					errs++
+				}
+			}
+			if summary.Errors != errs || summary.Errors+summary.Warnings != len(diags) {
+				t.Errorf("summary counts %d errors and %d warnings; want %d of %d diagnostics to be errors", summary.Errors, summary.Warnings, errs, len(diags))
+			}
+
+			result := c.ValidateWithPolicy(&ValidateFailurePolicy{
+				Rules: map[string]tfdiags.Severity{tc.Rule: tfdiags.Error},
+			})
+			for _, diag := range result.Failures {
+				if tfdiags.Rule(diag) == tc.Rule && tc.Want == tfdiags.Warning {
+					t.Errorf("remapped warning fails validation: %s", diag.Description().Summary)
+				}
+			}
+		})
+	}
+}
+
+func TestContext2ValidateWithPolicy(t *testing.T) {
+	cases := map[string]struct {
+		Errors bool
+		Policy *ValidateFailurePolicy
+		Failed bool
+	}{
+		"warnings, default policy": {
+			false,
+			nil,
+			false,
+		},
+		"warnings, error threshold": {
+			false,
+			&ValidateFailurePolicy{Severity: tfdiags.Error},
+			false,
+		},
+		"warnings, warning threshold": {
+			false,
+			&ValidateFailurePolicy{Severity: tfdiags.Warning},
+			true,
+		},
+		"warnings, warning threshold for the rule": {
+			false,
+			&ValidateFailurePolicy{
+				Rules: map[string]tfdiags.Severity{RuleRedundantDependsOn: tfdiags.Warning},
+			},
+			true,
+		},
+		"warnings, error threshold for the rule": {
+			false,
+			&ValidateFailurePolicy{
+				Severity: tfdiags.Warning,
+				Rules:    map[string]tfdiags.Severity{RuleRedundantDependsOn: tfdiags.Error},
+			},
+			false,
+		},
+		"errors, default policy": {
+			true,
+			nil,
+			true,
+		},
+		"errors, warning threshold": {
+			true,
+			&ValidateFailurePolicy{Severity: tfdiags.Warning},
+			true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := testProvider("aws")
+			if tc.Errors {
+				p.ValidateResourceReturnErrors = []error{fmt.Errorf("provider says no")}
+			}
+			c := testContext2(t, &ContextOpts{
+				Module: testModule(t, "validate-redundant-depends-on"),
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				ValidateRedundantDependsOn: true,
+			})
+
+			result := c.ValidateWithPolicy(tc.Policy)
+			if len(result.Diagnostics) == 0 {
+				t.Fatal("no diagnostics")
+			}
+			if result.Failed != tc.Failed {
+				t.Fatalf("Failed is %t; want %t\n%s", result.Failed, tc.Failed, result.Diagnostics.Err())
+			}
+			if got, want := len(result.Failures) > 0, tc.Failed; got != want {
+				t.Fatalf("got %d failures; want failures %t", len(result.Failures), want)
+			}
+		})
+	}
+}
+
+func TestContextChangedFileTargets(t *testing.T) {
+	dir := filepath.Join(fixtureDir, "validate-changed-files")
+	cases := map[string]struct {
+		Files    []string
+		Targets  []string
+		Complete bool
+	}{
+		"resource with dependents": {
+			[]string{"main.tf"},
+			[]string{"aws_instance.app", "aws_instance.web", "module.child.aws_instance.leaf"},
+			true,
+		},
+		"resource without dependents": {
+			[]string{"db.tf"},
+			[]string{"aws_instance.db"},
+			true,
+		},
+		"several files": {
+			[]string{"db.tf", "app.tf"},
+			[]string{"aws_instance.app", "aws_instance.db"},
+			true,
+		},
+		"child module resource": {
+			[]string{"child/leaf.tf"},
+			[]string{"module.child.aws_instance.other"},
+			true,
+		},
+		"outside the configuration": {
+			[]string{"../validate-bad-rc/main.tf"},
+			nil,
+			true,
+		},
+		"not configuration": {
+			[]string{"README.md"},
+			nil,
+			false,
+		},
+		"template": {
+			[]string{"templates/db.tpl"},
+			nil,
+			false,
+		},
+		"child module variable": {
+			[]string{"child/main.tf"},
+			nil,
+			false,
+		},
+		"module call": {
+			[]string{"modules.tf"},
+			nil,
+			false,
+		},
+		"deleted file": {
+			[]string{"gone.tf"},
+			nil,
+			false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := testContext2(t, &ContextOpts{
+				Module: testModule(t, "validate-changed-files"),
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(testProvider("aws")),
+					},
+				),
+			})
+
+			files := make([]string, len(tc.Files))
+			for i, f := range tc.Files {
+				files[i] = filepath.Join(dir, f)
+			}
+			targets, complete, err := c.changedFileTargets(files)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if complete != tc.Complete {
+				t.Fatalf("complete is %t; want %t", complete, tc.Complete)
+			}
+			if !reflect.DeepEqual(targets, tc.Targets) {
+				t.Fatalf("wrong targets\ngot:  %#v\nwant: %#v", targets, tc.Targets)
+			}
+		})
+	}
+}
+
+func TestContext2Validate_changedFiles(t *testing.T) {
+	dir := filepath.Join(fixtureDir, "validate-changed-files")
+	cases := map[string]struct {
+		Files     []string
+		Validated []string
+	}{
+		"resource": {
+			[]string{"db.tf"},
+			[]string{"aws_instance.db"},
+		},
+		"module call": {
+			[]string{"modules.tf"},
+			[]string{
+				"aws_instance.app",
+				"aws_instance.db",
+				"aws_instance.web",
+				"module.child.aws_instance.leaf",
+				"module.child.aws_instance.other",
+			},
+		},
+		"template": {
+			[]string{"templates/db.tpl"},
+			[]string{
+				"aws_instance.app",
+				"aws_instance.db",
+				"aws_instance.web",
+				"module.child.aws_instance.leaf",
+				"module.child.aws_instance.other",
+			},
+		},
+		"outside the configuration": {
+			[]string{"../validate-bad-rc/main.tf"},
+			nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := testProvider("aws")
+			p.GetSchemaReturn = &ProviderSchema{
+				ResourceTypes: map[string]*configschema.Block{
+					"aws_instance": {
+						Attributes: map[string]*configschema.Attribute{
+							"foo": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+			}
+
+			files := make([]string, len(tc.Files))
+			for i, f := range tc.Files {
+				files[i] = filepath.Join(dir, f)
+			}
+			c := testContext2(t, &ContextOpts{
+				Module: testModule(t, "validate-changed-files"),
+				ProviderResolver: ResourceProviderResolverFixed(
+					map[string]ResourceProviderFactory{
+						"aws": testProviderFuncFixed(p),
+					},
+				),
+				ValidateChangedFiles: files,
+			})
+
+			if diags := c.Validate(); len(diags) != 0 {
+				t.Fatalf("unexpected diagnostics: %s", diags.Err())
+			}
+
+			// Schemas are recorded for the resources that were validated.
+			var validated []string
+			for addr := range c.ResourceSchemas() {
+				validated = append(validated, addr)
+			}
+			sort.Strings(validated)
+			if !reflect.DeepEqual(validated, tc.Validated) {
+				t.Fatalf("wrong resources validated\ngot:  %#v\nwant: %#v", validated, tc.Validated)
+			}
+		})
+	}
+}
+
+const testContextValidateReferenceGraphStr = `
+aws_instance.db
+aws_instance.web
+  aws_instance.db
+meta.count-boundary (count boundary fixup)
+module.child.output.id
+  module.child.var.id
+module.child.var.id
+  aws_instance.web
+output.id
+  module.child.output.id
+provider.aws
+  var.region
+provider.aws (close)
+root
+var.region
+`
+
+// schemaCountingProvider is a MockResourceProvider that counts the schema
+// requests for each resource type.
+type schemaCountingProvider struct {
+	*MockResourceProvider
+
+	countLock sync.Mutex
+	counts    map[string]int
+}
+
+func (p *schemaCountingProvider) GetSchema(req *ProviderSchemaRequest) (*ProviderSchema, error) {
+	p.countLock.Lock()
+	for _, name := range req.ResourceTypes {
+		p.counts[name]++
+	}
+	p.countLock.Unlock()
+	return p.MockResourceProvider.GetSchema(req)
+}
+
+func TestContext2Validate_providerSchemaCached(t *testing.T) {
+	p := &schemaCountingProvider{
+		MockResourceProvider: testProvider("aws"),
+		counts:               make(map[string]int),
+	}
+	p.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {},
+		},
+	}
+	m := testModule(t, "validate-provider-schema-cache")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": func() (ResourceProvider, error) { return p, nil },
+			},
+		),
+	})
+
+	diags := c.Validate()
+	if diags.HasErrors() {
+		t.Fatalf("bad: %s", diags.Err())
+	}
+	if got := p.counts["aws_instance"]; got != 1 {
+		t.Fatalf("schema of aws_instance requested %d times; want 1", got)
+	}
+}
@@ -0,0 +1,97 @@
+package terraform
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// VariableDependencies are the input variables that each resource in a
+// configuration depends on, as returned by
+// Context.ValidateVariableDependencies.
+type VariableDependencies struct {
+	// Diagnostics are the diagnostics returned by Validate.
+	Diagnostics tfdiags.Diagnostics
+
+	// Resources are the sorted addresses of the variables, such as
+	// "var.region" or "module.child.var.size", that each resource depends
+	// on, by the address of the resource. Every resource is included, with
+	// no variables if it depends on none.
+	Resources map[string][]string
+}
+
+// Affected returns the sorted addresses of the resources that depend on the
+// variable with the given address, such as "var.region", and so may change
+// when the variable's value does.
+func (d *VariableDependencies) Affected(variable string) []string {
+	var addrs []string
+	for addr, vars := range d.Resources {
+		if i := sort.SearchStrings(vars, variable); i < len(vars) && vars[i] == variable {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// ValidateVariableDependencies validates the configuration as for Validate
+// and, if there are no errors, returns the input variables that each
+// resource depends on, as found from the graph for the validate walk with
+// the context's targets applied.
+//
+// A resource depends on a variable if the graph connects them, directly or
+// through other objects such as local values, module outputs and providers.
+// The variables of a child module are set by the arguments of the module
+// call, so a resource that depends on one of them also depends on the
+// variables that the argument refers to, in the calling module, and both
+// are included.
+func (c *Context) ValidateVariableDependencies() *VariableDependencies {
+	result := &VariableDependencies{Diagnostics: c.Validate()}
+	if result.Diagnostics.HasErrors() {
+		return result
+	}
+
+	graph, err := c.Graph(GraphTypeValidate, nil)
+	if err != nil {
+		result.Diagnostics = result.Diagnostics.Append(err)
+		return result
+	}
+
+	result.Resources = make(map[string][]string)
+	for _, v := range graph.Vertices() {
+		rn, ok := v.(GraphNodeResource)
+		if !ok || rn.ResourceAddr() == nil {
+			continue
+		}
+		deps, err := graph.Ancestors(v)
+		if err != nil {
+			result.Diagnostics = result.Diagnostics.Append(err)
+			return result
+		}
+
+		addr := rn.ResourceAddr().String()
+		// A resource may have more than one vertex, whose dependencies
+		// are merged.
+		seen := make(map[string]bool)
+		for _, name := range result.Resources[addr] {
+			seen[name] = true
+		}
+		for _, dep := range deps.List() {
+			switch dv := dep.(type) {
+			case *NodeRootVariable:
+				seen[dv.Name()] = true
+			case *NodeApplyableModuleVariable:
+				seen[dv.Name()] = true
+			}
+		}
+
+		vars := make([]string, 0, len(seen))
+		for name := range seen {
+			vars = append(vars, name)
+		}
+		sort.Strings(vars)
+		result.Resources[addr] = vars
+	}
+
+	return result
+}
@@ -0,0 +1,20 @@
+package terraform
+
+import (
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// VertexDiagnostics returns the diagnostics produced by each vertex of the
+// graph during the most recent call to Validate, keyed by vertex name, such
+// as "aws_instance.foo". Vertices that produced no diagnostics are not
+// included. The result can be passed to GraphDotDiagnostics along with the
+// validate graph.
+//
+// The result is a copy, and so it may be modified by the caller.
+func (c *Context) VertexDiagnostics() map[string]tfdiags.Diagnostics {
+	ret := make(map[string]tfdiags.Diagnostics, len(c.vertexDiagnostics))
+	for k, v := range c.vertexDiagnostics {
+		ret[k] = append(tfdiags.Diagnostics(nil), v...)
+	}
+	return ret
+}
@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/terraform/config"
@@ -35,4 +36,24 @@ func (n *EvalCheckPreventDestroy) Eval(ctx EvalContext) (interface{}, error) {
 	return nil, nil
 }
 
+// EvalValidatePreventDestroy is an EvalNode implementation that returns a
+// validation error if a resource has PreventDestroy configured. It is used
+// when validating a destroy, for resource instances that the destroy would
+// remove.
+type EvalValidatePreventDestroy struct {
+	Resource *config.Resource
+}
+
+func (n *EvalValidatePreventDestroy) Eval(ctx EvalContext) (interface{}, error) {
+	if n.Resource == nil || !n.Resource.Lifecycle.PreventDestroy {
+		return nil, nil
+	}
+
+	return nil, &EvalValidateError{
+		Errors: []error{errors.New(validatePreventDestroyErrStr)},
+	}
+}
+
+const validatePreventDestroyErrStr = `destroying this resource is not allowed because it currently has lifecycle.prevent_destroy set to true. To avoid this error and continue with the destroy, either disable lifecycle.prevent_destroy or adjust the scope of the destroy using the -target flag.`
+
 const preventDestroyErrStr = `%s: the plan would destroy this resource, but it currently has lifecycle.prevent_destroy set to true. To avoid this error and continue with the plan, either disable lifecycle.prevent_destroy or adjust the scope of the plan using the -target flag.`
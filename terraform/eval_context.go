@@ -86,4 +86,28 @@ type EvalContext interface {
 	// State returns the global state as well as the lock that should
 	// be used to modify that state.
 	State() (*State, *sync.RWMutex)
+
+	// ResourceSchemas returns the map in which the schemas used to
+	// validate resources are recorded, keyed by resource address, as well
+	// as the lock that should be used to modify it. The map is nil when
+	// the schemas aren't being recorded.
+	ResourceSchemas() (map[string]*ResourceSchema, *sync.Mutex)
+
+	// OutputSensitivities returns the map in which the outputs found to be
+	// sensitive while validating are recorded, keyed by
+	// outputSensitivityKey, as well as the lock that should be used to
+	// modify it. The map is nil when sensitivity isn't being recorded.
+	OutputSensitivities() (map[string]*outputSensitivity, *sync.Mutex)
+
+	// ProviderSchemas returns the map in which the schemas fetched from
+	// providers are cached, keyed by providerSchemaKey, as well as the lock
+	// that should be used to modify it. The map is nil when schemas aren't
+	// being cached.
+	ProviderSchemas() (map[string]*ProviderSchema, *sync.Mutex)
+
+	// ProviderOverride returns the resolved name of the provider
+	// configuration, such as "provider.aws.west", that the resource
+	// instance at the given address is to use in place of the one it is
+	// configured with, or an empty string if its provider isn't overridden.
+	ProviderOverride(*ResourceAddress) string
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform/config"
 )
@@ -29,6 +30,10 @@ type BuiltinEvalContext struct {
 	InterpolaterVars    map[string]map[string]interface{}
 	InterpolaterVarLock *sync.Mutex
 
+	// Now, if non-nil, is the clock used by time-dependent interpolation
+	// functions in place of the real one.
+	Now func() time.Time
+
 	Components          contextComponentFactory
 	Hooks               []Hook
 	InputValue          UIInput
@@ -42,6 +47,30 @@ type BuiltinEvalContext struct {
 	StateValue          *State
 	StateLock           *sync.RWMutex
 
+	// ResourceSchemasValue, if non-nil, is where the schemas used to
+	// validate resources are recorded.
+	ResourceSchemasValue map[string]*ResourceSchema
+	ResourceSchemasLock  *sync.Mutex
+
+	// OutputSensitivitiesValue, if non-nil, is where the outputs found to
+	// be sensitive while validating are recorded.
+	OutputSensitivitiesValue map[string]*outputSensitivity
+	OutputSensitivitiesLock  *sync.Mutex
+
+	// ProviderSchemasValue, if non-nil, is where the schemas fetched from
+	// providers are cached.
+	ProviderSchemasValue map[string]*ProviderSchema
+	ProviderSchemasLock  *sync.Mutex
+
+	// ProviderOverrides are the provider configurations that resources use
+	// in place of their own, as for ProviderOverrideTransformer.
+	ProviderOverrides map[string]string
+
+	// SharedProviders maps the names of providers that weren't initialized
+	// to the names of the providers whose instances they share, as recorded
+	// by CollapseEquivalentProviderTransformer.
+	SharedProviders map[string]string
+
 	once sync.Once
 }
 
@@ -106,6 +135,9 @@ func (ctx *BuiltinEvalContext) Provider(n string) ResourceProvider {
 	ctx.ProviderLock.Lock()
 	defer ctx.ProviderLock.Unlock()
 
+	if shared, ok := ctx.SharedProviders[n]; ok {
+		n = shared
+	}
 	return ctx.ProviderCache[n]
 }
 
@@ -246,7 +278,7 @@ func (ctx *BuiltinEvalContext) Interpolate(
 		}
 
 		// Do the interpolation
-		if err := cfg.Interpolate(vs); err != nil {
+		if err := cfg.InterpolateWithClock(vs, ctx.Now); err != nil {
 			return nil, err
 		}
 	}
@@ -275,7 +307,7 @@ func (ctx *BuiltinEvalContext) InterpolateProvider(
 		}
 
 		// Do the interpolation
-		if err := cfg.Interpolate(vs); err != nil {
+		if err := cfg.InterpolateWithClock(vs, ctx.Now); err != nil {
 			return nil, err
 		}
 	}
@@ -317,5 +349,21 @@ func (ctx *BuiltinEvalContext) State() (*State, *sync.RWMutex) {
 	return ctx.StateValue, ctx.StateLock
 }
 
+func (ctx *BuiltinEvalContext) ResourceSchemas() (map[string]*ResourceSchema, *sync.Mutex) {
+	return ctx.ResourceSchemasValue, ctx.ResourceSchemasLock
+}
+
+func (ctx *BuiltinEvalContext) OutputSensitivities() (map[string]*outputSensitivity, *sync.Mutex) {
+	return ctx.OutputSensitivitiesValue, ctx.OutputSensitivitiesLock
+}
+
+func (ctx *BuiltinEvalContext) ProviderSchemas() (map[string]*ProviderSchema, *sync.Mutex) {
+	return ctx.ProviderSchemasValue, ctx.ProviderSchemasLock
+}
+
+func (ctx *BuiltinEvalContext) ProviderOverride(addr *ResourceAddress) string {
+	return providerOverride(ctx.ProviderOverrides, addr)
+}
+
 func (ctx *BuiltinEvalContext) init() {
 }
@@ -84,6 +84,22 @@ type MockEvalContext struct {
 	StateCalled bool
 	StateState  *State
 	StateLock   *sync.RWMutex
+
+	ResourceSchemasCalled  bool
+	ResourceSchemasSchemas map[string]*ResourceSchema
+	ResourceSchemasLock    *sync.Mutex
+
+	OutputSensitivitiesCalled        bool
+	OutputSensitivitiesSensitivities map[string]*outputSensitivity
+	OutputSensitivitiesLock          *sync.Mutex
+
+	ProviderSchemasCalled  bool
+	ProviderSchemasSchemas map[string]*ProviderSchema
+	ProviderSchemasLock    *sync.Mutex
+
+	ProviderOverrideCalled bool
+	ProviderOverrideAddr   *ResourceAddress
+	ProviderOverrideName   string
 }
 
 func (c *MockEvalContext) Stopped() <-chan struct{} {
@@ -198,3 +214,24 @@ func (c *MockEvalContext) State() (*State, *sync.RWMutex) {
 	c.StateCalled = true
 	return c.StateState, c.StateLock
 }
+
+func (c *MockEvalContext) ResourceSchemas() (map[string]*ResourceSchema, *sync.Mutex) {
+	c.ResourceSchemasCalled = true
+	return c.ResourceSchemasSchemas, c.ResourceSchemasLock
+}
+
+func (c *MockEvalContext) OutputSensitivities() (map[string]*outputSensitivity, *sync.Mutex) {
+	c.OutputSensitivitiesCalled = true
+	return c.OutputSensitivitiesSensitivities, c.OutputSensitivitiesLock
+}
+
+func (c *MockEvalContext) ProviderSchemas() (map[string]*ProviderSchema, *sync.Mutex) {
+	c.ProviderSchemasCalled = true
+	return c.ProviderSchemasSchemas, c.ProviderSchemasLock
+}
+
+func (c *MockEvalContext) ProviderOverride(addr *ResourceAddress) string {
+	c.ProviderOverrideCalled = true
+	c.ProviderOverrideAddr = addr
+	return c.ProviderOverrideName
+}
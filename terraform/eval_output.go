@@ -3,6 +3,7 @@ package terraform
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/hashicorp/terraform/config"
 )
@@ -132,3 +133,138 @@ func (n *EvalWriteOutput) Eval(ctx EvalContext) (interface{}, error) {
 
 	return nil, nil
 }
+
+// EvalValidateOutputSensitivity is an EvalNode implementation that checks
+// that an output that isn't marked as sensitive doesn't refer to sensitive
+// values: resource attributes that the provider's schema marks as sensitive
+// and outputs of child modules that are sensitive, either because they're
+// marked as such or because they're derived from sensitive values
+// themselves. Outputs that are found to be sensitive are recorded for the
+// outputs of the calling module to refer to in turn, so that the whole
+// chain of outputs through which a sensitive value passes can be reported.
+//
+// It relies on the schemas recorded by EvalValidateResource for the
+// resources the output refers to, and on the child module outputs having
+// been validated first, and so it does nothing outside of the validate walk.
+// References that can't be resolved are assumed not to be sensitive.
+type EvalValidateOutputSensitivity struct {
+	Name      string
+	Sensitive bool
+	Value     *config.RawConfig
+}
+
+// outputSensitivity describes an output that was found to be sensitive by
+// EvalValidateOutputSensitivity.
+type outputSensitivity struct {
+	// Marked is true if the output is marked as sensitive in configuration.
+	Marked bool
+
+	// Chain is the addresses of the objects through which the sensitive
+	// value reaches the output, starting with the output itself and ending
+	// with the sensitive resource attribute or marked output it comes from.
+	Chain []string
+}
+
+// outputSensitivityKey returns the key of the output with the given name in
+// the module with the given normalized path, for OutputSensitivities.
+func outputSensitivityKey(path []string, name string) string {
+	return PathCacheKey(path) + "|" + name
+}
+
+// outputAddress returns the address of the output with the given name in
+// the module with the given normalized path, for use in messages.
+func outputAddress(path []string, name string) string {
+	var prefix string
+	for _, step := range path[1:] {
+		prefix += "module." + step + "."
+	}
+	return prefix + "output." + name
+}
+
+func (n *EvalValidateOutputSensitivity) Eval(ctx EvalContext) (interface{}, error) {
+	schemas, schemasLock := ctx.ResourceSchemas()
+	if schemas == nil || n.Value == nil {
+		return nil, nil
+	}
+	outputs, outputsLock := ctx.OutputSensitivities()
+	path := normalizeModulePath(ctx.Path())
+	self := outputAddress(path, n.Name)
+
+	var sources []string
+	var chain []string
+	for _, k := range sortedInterpolatedVariableKeys(n.Value.Variables) {
+		switch v := n.Value.Variables[k].(type) {
+		case *config.ResourceVariable:
+			addr := &ResourceAddress{
+				Path:  path[1:],
+				Mode:  v.Mode,
+				Type:  v.Type,
+				Name:  v.Name,
+				Index: -1,
+			}
+			attrName := strings.SplitN(v.Field, ".", 2)[0]
+
+			schemasLock.Lock()
+			schema := schemas[addr.String()]
+			schemasLock.Unlock()
+			if schema == nil || schema.Block == nil {
+				continue
+			}
+			if attr := schema.Block.Attributes[attrName]; attr != nil && attr.Sensitive {
+				sources = append(sources, fmt.Sprintf("the sensitive attribute %s.%s", v.ResourceId(), attrName))
+				if chain == nil {
+					chain = []string{fmt.Sprintf("%s.%s", addr.String(), attrName)}
+				}
+			}
+
+		case *config.ModuleVariable:
+			if outputs == nil {
+				continue
+			}
+			childPath := append(append([]string(nil), path...), v.Name)
+
+			outputsLock.Lock()
+			child := outputs[outputSensitivityKey(childPath, v.Field)]
+			outputsLock.Unlock()
+			if child == nil {
+				continue
+			}
+			if child.Marked {
+				sources = append(sources, fmt.Sprintf("the sensitive output %s", v.FullKey()))
+			} else {
+				sources = append(sources, fmt.Sprintf(
+					"output %s, which is derived from sensitive data (%s)",
+					v.FullKey(), strings.Join(child.Chain, " <- "),
+				))
+			}
+			if chain == nil {
+				chain = child.Chain
+			}
+		}
+	}
+
+	if outputs != nil && (n.Sensitive || len(sources) != 0) {
+		outputsLock.Lock()
+		outputs[outputSensitivityKey(path, n.Name)] = &outputSensitivity{
+			Marked: n.Sensitive,
+			Chain:  append([]string{self}, chain...),
+		}
+		outputsLock.Unlock()
+	}
+
+	switch {
+	case len(sources) != 0 && !n.Sensitive:
+		warns := make([]string, len(sources))
+		for i, source := range sources {
+			warns[i] = fmt.Sprintf(
+				"output %q is not marked as sensitive, but its value refers to %s; set sensitive = true to avoid displaying it",
+				n.Name, source,
+			)
+		}
+		return nil, &EvalValidateError{Warnings: warns}
+	case len(sources) == 0 && n.Sensitive:
+		log.Printf("[INFO] output %q is marked as sensitive, but doesn't refer to any known sensitive values", n.Name)
+	}
+
+	return nil, nil
+}
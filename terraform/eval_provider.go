@@ -2,6 +2,7 @@ package terraform
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/hashicorp/terraform/config"
 )
@@ -76,17 +77,39 @@ func (n *EvalCloseProvider) Eval(ctx EvalContext) (interface{}, error) {
 type EvalGetProvider struct {
 	Name   string
 	Output *ResourceProvider
+
+	// Addr, if set, is the address of the resource instance that the
+	// provider is for, which the EvalContext may override the provider of.
+	// NameOutput, if set, is where the name of the provider actually
+	// retrieved is written.
+	Addr       *ResourceAddress
+	NameOutput *string
 }
 
 func (n *EvalGetProvider) Eval(ctx EvalContext) (interface{}, error) {
-	result := ctx.Provider(n.Name)
+	name := n.Name
+	if n.Addr != nil {
+		if override := ctx.ProviderOverride(n.Addr); override != "" {
+			log.Printf("[DEBUG] %s: using provider %s in place of %s", n.Addr, override, name)
+			name = override
+		}
+	}
+
+	result := ctx.Provider(name)
 	if result == nil {
+		if name != n.Name {
+			return nil, fmt.Errorf(
+				"%s: provider %s, which overrides %s, not initialized", n.Addr, name, n.Name)
+		}
 		return nil, fmt.Errorf("provider %s not initialized", n.Name)
 	}
 
 	if n.Output != nil {
 		*n.Output = result
 	}
+	if n.NameOutput != nil {
+		*n.NameOutput = name
+	}
 
 	return nil, nil
 }
@@ -130,6 +130,45 @@ func TestEvalGetProvider(t *testing.T) {
 	}
 }
 
+func TestEvalGetProvider_override(t *testing.T) {
+	var actual ResourceProvider
+	var name string
+	addr := &ResourceAddress{Type: "aws_instance", Name: "foo", Index: -1}
+	n := &EvalGetProvider{Name: "foo", Output: &actual, Addr: addr, NameOutput: &name}
+	provider := &MockResourceProvider{}
+	ctx := &MockEvalContext{
+		ProviderProvider:     provider,
+		ProviderOverrideName: "bar",
+	}
+	if _, err := n.Eval(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if actual != provider {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	if ctx.ProviderOverrideAddr != addr {
+		t.Fatalf("bad: %#v", ctx.ProviderOverrideAddr)
+	}
+	if ctx.ProviderName != "bar" {
+		t.Fatalf("bad: %#v", ctx.ProviderName)
+	}
+	if name != "bar" {
+		t.Fatalf("bad: %#v", name)
+	}
+
+	// An override to a provider that isn't initialized fails.
+	ctx.ProviderProvider = nil
+	_, err := n.Eval(ctx)
+	if err == nil {
+		t.Fatal("should error")
+	}
+	want := "aws_instance.foo: provider bar, which overrides foo, not initialized"
+	if err.Error() != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, want)
+	}
+}
+
 func TestEvalInputProvider(t *testing.T) {
 	var provider ResourceProvider = &MockResourceProvider{
 		InputFn: func(ui UIInput, c *ResourceConfig) (*ResourceConfig, error) {
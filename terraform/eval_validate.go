@@ -2,9 +2,21 @@ package terraform
 
 import (
 	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hil"
+	"github.com/hashicorp/hil/ast"
 	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/helper/didyoumean"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/mitchellh/mapstructure"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // EvalValidateError is the error structure returned if there were
@@ -59,11 +71,165 @@ RETURN:
 	return nil, err
 }
 
+// EvalValidateSingletonCount is an EvalNode implementation that validates
+// that count isn't set on a managed resource whose type the provider's
+// schema marks as a singleton. Nothing is checked if the provider doesn't
+// support schemas or doesn't mark any resource types as singletons.
+type EvalValidateSingletonCount struct {
+	Provider     *ResourceProvider
+	ProviderName *string
+	Resource     *config.Resource
+	Addr         *ResourceAddress
+}
+
+func (n *EvalValidateSingletonCount) Eval(ctx EvalContext) (interface{}, error) {
+	r := n.Resource
+	if r.Mode != config.ManagedResourceMode {
+		return nil, nil
+	}
+	countSet := r.CountRange.Start.Line > 0
+	if !countSet && r.RawCount != nil {
+		countSet = r.RawCount.Raw[r.RawCount.Key] != "1"
+	}
+	if !countSet {
+		return nil, nil
+	}
+
+	schema, err := resourceProviderSchema(ctx, *n.ProviderName, *n.Provider, r.Mode, r.Type)
+	if err != nil {
+		log.Printf("[DEBUG] no schema to check whether %s is a singleton: %s", r.Type, err)
+		return nil, nil
+	}
+	if schema == nil || !schema.Singletons[r.Type] {
+		return nil, nil
+	}
+
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid count argument",
+		Detail: fmt.Sprintf(
+			"The provider marks %q as a singleton resource type, of which there can be only one instance, so count can't be set on %s.",
+			r.Type, n.Addr,
+		),
+	}
+	if r.CountRange.Filename != "" {
+		diag.Subject = r.CountRange.ToHCL().Ptr()
+	}
+
+	return nil, &EvalValidateError{
+		Errors: []error{withRule(RuleSingletonCount, diag)},
+	}
+}
+
+// EvalValidateInstanceLimit is an EvalNode implementation that validates
+// that the count of a managed resource doesn't exceed the number of
+// instances that the provider's schema allows for its type. The count must
+// already have been interpolated, and nothing is checked if it isn't known,
+// if the provider doesn't support schemas or if the schema doesn't limit
+// the resource type.
+type EvalValidateInstanceLimit struct {
+	Provider     *ResourceProvider
+	ProviderName *string
+	Resource     *config.Resource
+	Addr         *ResourceAddress
+}
+
+func (n *EvalValidateInstanceLimit) Eval(ctx EvalContext) (interface{}, error) {
+	r := n.Resource
+	if r.Mode != config.ManagedResourceMode || r.RawCount == nil {
+		return nil, nil
+	}
+	if r.RawCount.Value() == unknownValue() {
+		return nil, nil
+	}
+	count, err := r.Count()
+	if err != nil {
+		// EvalValidateCount reports counts that aren't valid integers.
+		return nil, nil
+	}
+
+	schema, err := resourceProviderSchema(ctx, *n.ProviderName, *n.Provider, r.Mode, r.Type)
+	if err != nil {
+		log.Printf("[DEBUG] no schema to check the instance limit of %s: %s", r.Type, err)
+		return nil, nil
+	}
+	if schema == nil {
+		return nil, nil
+	}
+	limit, ok := schema.InstanceLimits[r.Type]
+	if !ok || count <= limit {
+		return nil, nil
+	}
+
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Too many resource instances",
+		Detail: fmt.Sprintf(
+			"The count of %s is %d, but the provider limits resources of type %q to %d instances.",
+			n.Addr, count, r.Type, limit,
+		),
+	}
+	if r.CountRange.Filename != "" {
+		diag.Subject = r.CountRange.ToHCL().Ptr()
+	}
+
+	return nil, &EvalValidateError{
+		Errors: []error{withRule(RuleInstanceLimit, diag)},
+	}
+}
+
+// EvalValidateDataSourceLifecycle is an EvalNode implementation that
+// validates that a data source doesn't set any lifecycle arguments, such as
+// ignore_changes, which apply only to managed resources. Data sources are
+// loaded without interpreting their lifecycle blocks, which would otherwise
+// be silently passed to the provider along with the rest of the
+// configuration.
+type EvalValidateDataSourceLifecycle struct {
+	Resource *config.Resource
+	Addr     *ResourceAddress
+}
+
+func (n *EvalValidateDataSourceLifecycle) Eval(ctx EvalContext) (interface{}, error) {
+	r := n.Resource
+	if r.Mode != config.DataResourceMode || r.RawConfig == nil {
+		return nil, nil
+	}
+	args, _ := rawMapValues(r.RawConfig.Raw["lifecycle"])
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	for _, name := range sortedRawKeys(args) {
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid data source lifecycle argument",
+			Detail: fmt.Sprintf(
+				"%s sets %s in its lifecycle block, but data sources are only read, never created or destroyed, so lifecycle arguments apply only to managed resources.",
+				n.Addr, name,
+			),
+		}
+		if rng, ok := r.AttributeRanges["lifecycle"]; ok && rng.Filename != "" {
+			diag.Subject = rng.ToHCL().Ptr()
+		}
+		errs = append(errs, withRule(RuleDataSourceLifecycle, diag))
+	}
+
+	return nil, &EvalValidateError{
+		Errors: errs,
+	}
+}
+
 // EvalValidateProvider is an EvalNode implementation that validates
 // the configuration of a resource.
 type EvalValidateProvider struct {
 	Provider *ResourceProvider
 	Config   **ResourceConfig
+
+	// Decl, if set, is the provider block being validated, whose arguments
+	// are also checked against the provider's schema for its own
+	// configuration. Both sets of errors are reported together.
+	Decl *config.ProviderConfig
 }
 
 func (n *EvalValidateProvider) Eval(ctx EvalContext) (interface{}, error) {
@@ -71,6 +237,7 @@ func (n *EvalValidateProvider) Eval(ctx EvalContext) (interface{}, error) {
 	config := *n.Config
 
 	warns, errs := provider.Validate(config)
+	errs = append(errs, n.schemaErrors(provider)...)
 	if len(warns) == 0 && len(errs) == 0 {
 		return nil, nil
 	}
@@ -81,12 +248,130 @@ func (n *EvalValidateProvider) Eval(ctx EvalContext) (interface{}, error) {
 	}
 }
 
+// schemaErrors validates the provider block against the provider's schema
+// for its own configuration, without configuring the provider.
+//
+// Arguments and blocks that the schema doesn't define are reported, along
+// with arguments whose literal values have the wrong kind of type, such as a
+// list given for a string. Values that contain interpolations aren't type
+// checked, since their types aren't known until they are evaluated. Nothing
+// is checked if the provider doesn't support schemas or if there is no
+// provider block.
+func (n *EvalValidateProvider) schemaErrors(provider ResourceProvider) []error {
+	if n.Decl == nil || n.Decl.RawConfig == nil {
+		return nil
+	}
+
+	schema, err := provider.GetSchema(&ProviderSchemaRequest{})
+	if err != nil {
+		log.Printf("[DEBUG] no schema to validate the configuration of provider %s: %s", n.Decl.FullName(), err)
+		return nil
+	}
+	if schema == nil || schema.Provider == nil {
+		return nil
+	}
+
+	var subject *hcl.Range
+	if n.Decl.DeclRange.Filename != "" {
+		subject = n.Decl.DeclRange.ToHCL().Ptr()
+	}
+	return providerConfigSchemaErrors(schema.Provider, n.Decl.RawConfig.Raw, subject)
+}
+
+// providerConfigSchemaErrors returns an error for each argument or block in
+// the given raw provider configuration that the given schema doesn't define,
+// with a suggestion of a similar name that it does define, and for each
+// argument whose literal value has the wrong kind of type. subject, if
+// non-nil, is the range of the provider block, given as the subject of each
+// error.
+func providerConfigSchemaErrors(schema *configschema.Block, raw map[string]interface{}, subject *hcl.Range) []error {
+	var names []string
+	for name := range schema.Attributes {
+		names = append(names, name)
+	}
+	for name := range schema.BlockTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, k := range sortedRawKeys(raw) {
+		if attr, ok := schema.Attributes[k]; ok {
+			if want := literalTypeMismatch(attr.Type, raw[k]); want != "" {
+				errs = append(errs, withRule(RuleArgumentType, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Incorrect attribute value type",
+					Detail:   fmt.Sprintf("The argument %q must be %s.", k, want),
+					Subject:  subject,
+				}))
+			}
+			continue
+		}
+		if _, ok := schema.BlockTypes[k]; ok {
+			continue
+		}
+
+		detail := fmt.Sprintf("An argument named %q is not expected here.", k)
+		if suggestion := didyoumean.NameSuggestion(k, names); suggestion != "" {
+			detail += fmt.Sprintf(" Did you mean %q?", suggestion)
+		}
+		errs = append(errs, withRule(RuleUnsupportedArgument, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported argument",
+			Detail:   detail,
+			Subject:  subject,
+		}))
+	}
+	return errs
+}
+
+// literalTypeMismatch returns a description of the kind of value that the
+// given type requires if the given raw value can't be converted to it, or
+// an empty string if it can, or if this can't be known before the value is
+// interpolated.
+func literalTypeMismatch(ty cty.Type, raw interface{}) string {
+	var isString, isList, isMap bool
+	switch v := raw.(type) {
+	case string:
+		if strings.Contains(v, "${") {
+			return ""
+		}
+		isString = true
+	case int, float64, bool:
+		isString = true
+	case []interface{}:
+		isList = true
+	case map[string]interface{}, []map[string]interface{}:
+		isMap = true
+	default:
+		return ""
+	}
+
+	switch {
+	case ty.IsPrimitiveType() && !isString:
+		return "a single value, such as a string or number"
+	case (ty.IsListType() || ty.IsSetType()) && !isList:
+		return "a list"
+	case ty.IsMapType() && !isMap:
+		return "a map"
+	}
+	return ""
+}
+
 // EvalValidateProvisioner is an EvalNode implementation that validates
 // the configuration of a resource.
 type EvalValidateProvisioner struct {
 	Provisioner *ResourceProvisioner
 	Config      **ResourceConfig
 	ConnConfig  **ResourceConfig
+
+	// ProvisionerConfig, if set, is the configuration of the provisioner
+	// before interpolation, whose source ranges are used in diagnostics.
+	ProvisionerConfig *config.Provisioner
+
+	// ResourceAddr is the address of the resource instance that the
+	// provisioner belongs to.
+	ResourceAddr *ResourceAddress
 }
 
 func (n *EvalValidateProvisioner) Eval(ctx EvalContext) (interface{}, error) {
@@ -109,6 +394,7 @@ func (n *EvalValidateProvisioner) Eval(ctx EvalContext) (interface{}, error) {
 		w, e := n.validateConnConfig(*n.ConnConfig)
 		warns = append(warns, w...)
 		errs = append(errs, e...)
+		errs = append(errs, n.validateConnTimeout(*n.ConnConfig)...)
 	}
 
 	if len(warns) == 0 && len(errs) == 0 {
@@ -183,6 +469,219 @@ func (n *EvalValidateProvisioner) validateConnConfig(connConfig *ResourceConfig)
 	return
 }
 
+// validateConnTimeout returns an error if the timeout in the given
+// connection config is set to a string that doesn't parse as a duration,
+// such as "5 minutes". Provisioner blocks have no timeout argument of their
+// own, so the connection's timeout, whether set in the provisioner block or
+// inherited from the resource, is the only one that applies to running a
+// provisioner. The communicators ignore a timeout that doesn't parse and
+// use their default instead, logging only a warning, when the provisioner
+// runs during apply. Timeouts that aren't known until then are not checked.
+func (n *EvalValidateProvisioner) validateConnTimeout(connConfig *ResourceConfig) []error {
+	raw, ok := connConfig.Config["timeout"].(string)
+	if !ok || connConfig.IsComputed("timeout") {
+		return nil
+	}
+	if _, err := time.ParseDuration(raw); err == nil {
+		return nil
+	}
+
+	where := ""
+	if p := n.ProvisionerConfig; p != nil && n.ResourceAddr != nil {
+		where = fmt.Sprintf(" of the %s provisioner of %s", p.Type, n.ResourceAddr)
+	}
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid connection timeout",
+		Detail: fmt.Sprintf(
+			"The connection timeout %q%s is not a valid duration, such as \"30s\" or \"5m\", so the communicator would ignore it and use its default timeout instead.",
+			raw, where,
+		),
+	}
+	if p := n.ProvisionerConfig; p != nil {
+		rng, ok := p.ValueRanges["connection.timeout"]
+		if !ok {
+			rng = p.DeclRange
+		}
+		if rng.Filename != "" {
+			diag.Subject = rng.ToHCL().Ptr()
+		}
+	}
+	return []error{diag}
+}
+
+// EvalValidateProvisionerReferences is an EvalNode implementation that
+// validates the references in the configuration of a provisioner before it
+// is interpolated: references to local values that are not declared in the
+// module, and to attributes of self that the resource's schema doesn't
+// have, which would otherwise only be reported when the provisioner runs
+// during apply. Attributes of self are checked only if a schema was
+// recorded for the resource. References to undeclared variables, resources
+// and modules are already reported by config.Validate.
+type EvalValidateProvisionerReferences struct {
+	ProvisionerConfig *config.Provisioner
+
+	// Module is the configuration of the module containing the resource,
+	// which declares the local values that the provisioner can refer to.
+	Module *config.Config
+
+	// ResourceAddr is the address of the resource instance that the
+	// provisioner belongs to, which self refers to.
+	ResourceAddr *ResourceAddress
+}
+
+func (n *EvalValidateProvisionerReferences) Eval(ctx EvalContext) (interface{}, error) {
+	if n.Module == nil {
+		return nil, nil
+	}
+	errs := n.referenceErrors(ctx)
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return nil, &EvalValidateError{
+		Errors: errs,
+	}
+}
+
+// referenceErrors returns an error for each of the provisioner's invalid
+// references.
+func (n *EvalValidateProvisionerReferences) referenceErrors(ctx EvalContext) []error {
+	p := n.ProvisionerConfig
+	m := n.Module
+
+	var schema *configschema.Block
+	if n.ResourceAddr != nil {
+		if schemas, lock := ctx.ResourceSchemas(); schemas != nil {
+			addr := n.ResourceAddr.Copy()
+			addr.Index = -1
+			lock.Lock()
+			if rs, ok := schemas[addr.String()]; ok {
+				schema = rs.Block
+			}
+			lock.Unlock()
+		}
+	}
+
+	locals := make(map[string]bool)
+	for _, l := range m.Locals {
+		locals[l.Name] = true
+	}
+
+	var errs []error
+	walkProvisionerStrings("", p.RawConfig.Raw, func(path, s string) {
+		root, err := hil.Parse(s)
+		if err != nil {
+			// Syntax errors are reported when the configuration is loaded.
+			return
+		}
+
+		root.Accept(func(node ast.Node) ast.Node {
+			va, ok := node.(*ast.VariableAccess)
+			if !ok {
+				return node
+			}
+			v, err := config.NewInterpolatedVariable(va.Name)
+			if err != nil {
+				return node
+			}
+
+			var summary, detail string
+			rule := RuleUndeclaredReference
+			switch v := v.(type) {
+			case *config.LocalVariable:
+				if !locals[v.Name] {
+					summary = "Reference to undeclared local value"
+					detail = fmt.Sprintf("A local value named %q has not been declared.", v.Name)
+				}
+			case *config.SelfVariable:
+				field := strings.SplitN(v.Field, ".", 2)[0]
+				if schema == nil || field == "id" || field == "count" {
+					break
+				}
+				_, isAttr := schema.Attributes[field]
+				_, isBlock := schema.BlockTypes[field]
+				if !isAttr && !isBlock {
+					rule = RuleUnsupportedSelfAttribute
+					summary = "Unsupported attribute"
+					detail = fmt.Sprintf("The resource %s, which self refers to, has no attribute named %q.", n.ResourceAddr, field)
+				}
+			}
+			if summary == "" {
+				return node
+			}
+
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  summary,
+				Detail: fmt.Sprintf(
+					"The %s provisioner of %s refers to %s in %s. %s",
+					p.Type, n.ResourceAddr, va.Name, path, detail,
+				),
+			}
+			if rng := provisionerReferenceRange(p, path, va); rng.Filename != "" {
+				diag.Subject = rng.ToHCL().Ptr()
+			}
+			errs = append(errs, withRule(rule, diag))
+			return node
+		})
+	})
+
+	return errs
+}
+
+// walkProvisionerStrings calls fn for each string in the given raw
+// provisioner configuration, with the keys leading to it joined with dots.
+// Elements of lists have the path of the list itself.
+func walkProvisionerStrings(path string, raw interface{}, fn func(path, s string)) {
+	join := func(k string) string {
+		if path == "" {
+			return k
+		}
+		return path + "." + k
+	}
+
+	switch v := raw.(type) {
+	case string:
+		fn(path, v)
+	case map[string]interface{}:
+		for _, k := range sortedRawKeys(v) {
+			walkProvisionerStrings(join(k), v[k], fn)
+		}
+	case []map[string]interface{}:
+		for _, m := range v {
+			walkProvisionerStrings(path, m, fn)
+		}
+	case []interface{}:
+		for _, e := range v {
+			walkProvisionerStrings(path, e, fn)
+		}
+	}
+}
+
+// provisionerReferenceRange returns the source range of the given
+// reference in the value at the given path of the provisioner's
+// configuration. The range is exact if the value is a single-line quoted
+// string; otherwise it is the start of the value or, failing that, of the
+// provisioner block.
+func provisionerReferenceRange(p *config.Provisioner, path string, va *ast.VariableAccess) tfdiags.SourceRange {
+	rng, ok := p.ValueRanges[path]
+	if !ok {
+		return p.DeclRange
+	}
+	if va.Posx.Line != 1 {
+		return rng
+	}
+
+	// The value's range starts at its opening quote, and the reference's
+	// position is relative to the first character after it.
+	rng.Start.Column += va.Posx.Column
+	rng.Start.Byte += va.Posx.Column
+	rng.End = rng.Start
+	rng.End.Column += len(va.Name)
+	rng.End.Byte += len(va.Name)
+	return rng
+}
+
 // EvalValidateResource is an EvalNode implementation that validates
 // the configuration of a resource.
 type EvalValidateResource struct {
@@ -192,6 +691,25 @@ type EvalValidateResource struct {
 	ResourceType string
 	ResourceMode config.ResourceMode
 
+	// ProviderName, if set, is the name of the provider configuration that
+	// the resource is validated with, which is recorded along with its
+	// schema.
+	ProviderName *string
+
+	// MaxNestingDepth, if positive, is the number of levels that blocks and
+	// map values can be nested in the configuration before it is reported
+	// as too deeply nested, in place of DefaultValidateMaxNestingDepth. The
+	// checks that walk nested blocks are skipped for configuration that is
+	// too deeply nested.
+	MaxNestingDepth int
+
+	// ComputedPlaceholders, if true, causes each argument that is set to a
+	// computed attribute of another resource, and to nothing else, to be
+	// checked as if the attribute had a placeholder value of the type in
+	// the schema recorded for that resource, as by computedPlaceholderErrors.
+	// Otherwise the unknown value of the attribute isn't checked at all.
+	ComputedPlaceholders bool
+
 	// IgnoreWarnings means that warnings will not be passed through. This allows
 	// "just-in-time" passes of validation to continue execution through warnings.
 	IgnoreWarnings bool
@@ -212,12 +730,48 @@ func (n *EvalValidateResource) Eval(ctx EvalContext) (interface{}, error) {
 		warns, errs = provider.ValidateDataSource(n.ResourceType, cfg)
 	}
 
+	// Setting both a deprecated attribute and its replacement is
+	// contradictory, but we can only detect that when the provider's schema
+	// records which attribute replaces which. Likewise, only the schema
+	// records the fields that object attributes must have.
+	var depthErr error
+	if cfg != nil {
+		maxDepth := n.MaxNestingDepth
+		if maxDepth <= 0 {
+			maxDepth = DefaultValidateMaxNestingDepth
+		}
+		if depthErr = nestingDepthError(cfg.Raw, "", 0, maxDepth); depthErr != nil {
+			errs = append(errs, withRule(RuleNestingDepth, depthErr))
+		}
+	}
+	providerSchema, schema := n.schema(ctx, provider)
+	if schema != nil {
+		if cfg != nil && depthErr == nil {
+			errs = append(errs, deprecatedReplacementErrors(schema, cfg.Raw, "")...)
+			errs = append(errs, objectShapeErrors(schema, cfg.Raw, "")...)
+			if n.ComputedPlaceholders {
+				errs = append(errs, computedPlaceholderErrors(schema, cfg.Raw, "", n.placeholderType(ctx))...)
+			}
+		}
+		n.recordSchema(ctx, schema)
+	}
+	if cfg != nil && depthErr == nil {
+		schemas, lock := ctx.ResourceSchemas()
+		path := normalizeModulePath(ctx.Path())[1:]
+		for _, err := range internalReferenceErrors(cfg.Raw, path, schemas, lock) {
+			errs = append(errs, withRule(RuleInternalAttribute, err))
+		}
+	}
+	if warn := n.deprecatedTypeWarning(providerSchema); warn != "" {
+		warns = append(warns, warn)
+	}
+
 	// If the resource name doesn't match the name regular
 	// expression, show an error.
 	if !config.NameRegexp.Match([]byte(n.ResourceName)) {
-		errs = append(errs, fmt.Errorf(
+		errs = append(errs, withRule(RuleInvalidResourceName, fmt.Errorf(
 			"%s: resource name can only contain letters, numbers, "+
-				"dashes, and underscores.", n.ResourceName))
+				"dashes, and underscores.", n.ResourceName)))
 	}
 
 	if (len(warns) == 0 || n.IgnoreWarnings) && len(errs) == 0 {
@@ -229,3 +783,489 @@ func (n *EvalValidateResource) Eval(ctx EvalContext) (interface{}, error) {
 		Errors:   errs,
 	}
 }
+
+// schema returns the provider's schema along with its schema for the
+// resource type being validated, or nils if the provider doesn't support
+// schemas.
+func (n *EvalValidateResource) schema(ctx EvalContext, provider ResourceProvider) (*ProviderSchema, *configschema.Block) {
+	providerName := ""
+	if n.ProviderName != nil {
+		providerName = *n.ProviderName
+	}
+
+	schema, err := resourceProviderSchema(ctx, providerName, provider, n.ResourceMode, n.ResourceType)
+	if err != nil {
+		log.Printf("[DEBUG] no schema for %s: %s", n.ResourceType, err)
+		return nil, nil
+	}
+	if schema == nil {
+		return nil, nil
+	}
+	if n.ResourceMode == config.DataResourceMode {
+		return schema, schema.DataSources[n.ResourceType]
+	}
+	return schema, schema.ResourceTypes[n.ResourceType]
+}
+
+// providerSchemaKey returns the key of the schema that the named provider
+// configuration has for the given resource type or data source, for
+// ProviderSchemas.
+func providerSchemaKey(providerName string, mode config.ResourceMode, typeName string) string {
+	return providerName + "|" + mode.String() + "|" + typeName
+}
+
+// resourceProviderSchema returns the schema that the given provider has for
+// the given resource type or data source. If the context caches provider
+// schemas and the name of the provider configuration is known, the schema
+// is requested from the provider only the first time, so that the checks
+// of every resource and instance of a type don't each ask for it again.
+// A schema the provider failed to return is cached as nil.
+func resourceProviderSchema(ctx EvalContext, providerName string, provider ResourceProvider, mode config.ResourceMode, typeName string) (*ProviderSchema, error) {
+	req := &ProviderSchemaRequest{}
+	switch mode {
+	case config.ManagedResourceMode:
+		req.ResourceTypes = []string{typeName}
+	case config.DataResourceMode:
+		req.DataSources = []string{typeName}
+	}
+
+	schemas, lock := ctx.ProviderSchemas()
+	if schemas == nil || providerName == "" {
+		return provider.GetSchema(req)
+	}
+
+	key := providerSchemaKey(providerName, mode, typeName)
+	lock.Lock()
+	defer lock.Unlock()
+	if schema, ok := schemas[key]; ok {
+		return schema, nil
+	}
+	schema, err := provider.GetSchema(req)
+	schemas[key] = schema
+	return schema, err
+}
+
+// deprecatedTypeWarning returns a warning if the given provider schema marks
+// the type of the managed resource as deprecated, naming the resource type
+// that replaces it if there is one, or the empty string otherwise.
+func (n *EvalValidateResource) deprecatedTypeWarning(schema *ProviderSchema) string {
+	if schema == nil || n.ResourceMode != config.ManagedResourceMode {
+		return ""
+	}
+	replacement, ok := schema.DeprecatedResourceTypes[n.ResourceType]
+	if !ok {
+		return ""
+	}
+	if replacement == "" {
+		return fmt.Sprintf("%s.%s: resource type %q is deprecated", n.ResourceType, n.ResourceName, n.ResourceType)
+	}
+	return fmt.Sprintf(
+		"%s.%s: resource type %q is deprecated and replaced by %q; use %q instead",
+		n.ResourceType, n.ResourceName, n.ResourceType, replacement, replacement)
+}
+
+// placeholderType returns a function that returns the type of the
+// placeholder value for the given raw value of an argument of the resource,
+// and the reference that the value is taken from. The raw value must be a
+// single interpolation of a computed attribute of a resource in the same
+// module whose schema has been recorded, or of an element of such an
+// attribute; otherwise the type is cty.NilType. A splat gives a list of the
+// attribute's type.
+func (n *EvalValidateResource) placeholderType(ctx EvalContext) func(raw interface{}) (cty.Type, string) {
+	schemas, lock := ctx.ResourceSchemas()
+	path := normalizeModulePath(ctx.Path())[1:]
+
+	return func(raw interface{}) (cty.Type, string) {
+		str, ok := raw.(string)
+		if !ok || schemas == nil || !strings.Contains(str, "${") {
+			return cty.NilType, ""
+		}
+		root, err := hil.Parse(str)
+		if err != nil {
+			return cty.NilType, ""
+		}
+		out, ok := root.(*ast.Output)
+		if !ok || len(out.Exprs) != 1 {
+			return cty.NilType, ""
+		}
+		va, ok := out.Exprs[0].(*ast.VariableAccess)
+		if !ok {
+			return cty.NilType, ""
+		}
+		rv, _, schema := referencedResourceSchema(va.Name, path, schemas, lock)
+		if schema == nil {
+			return cty.NilType, ""
+		}
+
+		fields := strings.Split(rv.Field, ".")
+		attr := schema.Block.Attributes[fields[0]]
+		if attr == nil || !attr.Computed || len(fields) > 2 {
+			return cty.NilType, ""
+		}
+		ty := attr.Type
+		if len(fields) == 2 {
+			if !ty.IsListType() && !ty.IsSetType() && !ty.IsMapType() {
+				return cty.NilType, ""
+			}
+			ty = ty.ElementType()
+		}
+		if rv.Multi && rv.Index == -1 {
+			ty = cty.List(ty)
+		}
+		return ty, va.Name
+	}
+}
+
+// referencedResourceSchema returns the resource variable for the given
+// interpolated variable name, such as "aws_instance.web.id", along with the
+// address of the resource it refers to in the module at the given path and
+// the schema recorded for that resource in the given map, locking it with
+// the given lock if it isn't nil. The schema is nil if the name isn't a
+// reference to a resource or no schema has been recorded for the resource.
+func referencedResourceSchema(name string, path []string, schemas map[string]*ResourceSchema, lock *sync.Mutex) (*config.ResourceVariable, *ResourceAddress, *ResourceSchema) {
+	iv, err := config.NewInterpolatedVariable(name)
+	if err != nil {
+		return nil, nil, nil
+	}
+	rv, ok := iv.(*config.ResourceVariable)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	addr := &ResourceAddress{
+		Path:  path,
+		Mode:  rv.Mode,
+		Type:  rv.Type,
+		Name:  rv.Name,
+		Index: -1,
+	}
+	if lock != nil {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	schema := schemas[addr.String()]
+	if schema == nil || schema.Block == nil {
+		return rv, addr, nil
+	}
+	return rv, addr, schema
+}
+
+// internalReferenceErrors returns an error for each reference in the given
+// raw configuration, of an object in the module at the given path, to an
+// attribute of a resource in the same module that the schema recorded for
+// that resource in the given map marks as internal, locking the map with the
+// given lock if it isn't nil. References to resources whose schemas haven't
+// been recorded, or whose schemas don't mark any attributes as internal, are
+// never reported.
+func internalReferenceErrors(raw map[string]interface{}, path []string, schemas map[string]*ResourceSchema, lock *sync.Mutex) []error {
+	if schemas == nil {
+		return nil
+	}
+
+	var errs []error
+	walkProvisionerStrings("", raw, func(argPath, s string) {
+		if !strings.Contains(s, "${") {
+			return
+		}
+		root, err := hil.Parse(s)
+		if err != nil {
+			return
+		}
+
+		root.Accept(func(node ast.Node) ast.Node {
+			va, ok := node.(*ast.VariableAccess)
+			if !ok {
+				return node
+			}
+			rv, addr, schema := referencedResourceSchema(va.Name, path, schemas, lock)
+			if schema == nil {
+				return node
+			}
+
+			field := strings.SplitN(rv.Field, ".", 2)[0]
+			if attr := schema.Block.Attributes[field]; attr == nil || !attr.Internal {
+				return node
+			}
+			errs = append(errs, fmt.Errorf(
+				"%q: this argument refers to %s, but the attribute %q of %s is internal to its provider and can't be referred to from configuration",
+				argPath, va.Name, field, addr,
+			))
+			return node
+		})
+	})
+
+	return errs
+}
+
+// recordSchema records the schema used to validate the resource in the
+// context, if schemas are being recorded.
+func (n *EvalValidateResource) recordSchema(ctx EvalContext, schema *configschema.Block) {
+	schemas, lock := ctx.ResourceSchemas()
+	if schemas == nil {
+		return
+	}
+
+	addr := &ResourceAddress{
+		Path:  normalizeModulePath(ctx.Path())[1:],
+		Mode:  n.ResourceMode,
+		Type:  n.ResourceType,
+		Name:  n.ResourceName,
+		Index: -1,
+	}
+	providerName := ""
+	if n.ProviderName != nil {
+		providerName = *n.ProviderName
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	schemas[addr.String()] = &ResourceSchema{
+		Provider: providerName,
+		Block:    schema,
+	}
+}
+
+// walkSchemaBlocks calls fn with the given raw configuration of a block and
+// its schema, and then with those of each of its nested blocks in turn,
+// recursively. prefix is the path of the block, to be prepended to attribute
+// names to give their full paths. Nested blocks are visited in order of
+// their names, and repeated blocks in the order they are set.
+func walkSchemaBlocks(schema *configschema.Block, raw map[string]interface{}, prefix string, fn func(schema *configschema.Block, raw map[string]interface{}, prefix string)) {
+	fn(schema, raw, prefix)
+
+	names := make([]string, 0, len(schema.BlockTypes))
+	for name := range schema.BlockTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		blockS := &schema.BlockTypes[name].Block
+		switch v := raw[name].(type) {
+		case map[string]interface{}:
+			walkSchemaBlocks(blockS, v, prefix+name+".", fn)
+		case []map[string]interface{}:
+			for i, elem := range v {
+				walkSchemaBlocks(blockS, elem, fmt.Sprintf("%s%s.%d.", prefix, name, i), fn)
+			}
+		case []interface{}:
+			for i, elem := range v {
+				if m, ok := elem.(map[string]interface{}); ok {
+					walkSchemaBlocks(blockS, m, fmt.Sprintf("%s%s.%d.", prefix, name, i), fn)
+				}
+			}
+		}
+	}
+}
+
+// deprecatedReplacementErrors returns an error for each deprecated attribute
+// that is set in the given raw configuration of a block along with the
+// attribute that replaces it, recursing into nested blocks. prefix is
+// prepended to attribute names to give their full paths.
+func deprecatedReplacementErrors(schema *configschema.Block, raw map[string]interface{}, prefix string) []error {
+	var errs []error
+
+	walkSchemaBlocks(schema, raw, prefix, func(schema *configschema.Block, raw map[string]interface{}, prefix string) {
+		names := make([]string, 0, len(schema.Attributes))
+		for name := range schema.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			replacement := schema.Attributes[name].ReplacedBy
+			if replacement == "" {
+				continue
+			}
+			_, deprecatedSet := raw[name]
+			_, replacementSet := raw[replacement]
+			if deprecatedSet && replacementSet {
+				errs = append(errs, withRule(RuleDeprecatedAttribute, fmt.Errorf(
+					"%q is deprecated and replaced by %q; set only %q",
+					prefix+name, prefix+replacement, prefix+replacement)))
+			}
+		}
+	})
+
+	return errs
+}
+
+// computedPlaceholderErrors returns an error for each attribute set in the
+// given raw configuration of a block to a single reference whose placeholder
+// value, as given by placeholder, has a type of the wrong kind for the
+// attribute in the schema, recursing into nested blocks. prefix is
+// prepended to attribute names to give their full paths.
+func computedPlaceholderErrors(schema *configschema.Block, raw map[string]interface{}, prefix string, placeholder func(interface{}) (cty.Type, string)) []error {
+	var errs []error
+
+	walkSchemaBlocks(schema, raw, prefix, func(schema *configschema.Block, raw map[string]interface{}, prefix string) {
+		for _, name := range sortedRawKeys(raw) {
+			attr, ok := schema.Attributes[name]
+			if !ok {
+				continue
+			}
+			ty, ref := placeholder(raw[name])
+			if ty == cty.NilType {
+				continue
+			}
+			if want := placeholderTypeMismatch(attr.Type, ty); want != "" {
+				errs = append(errs, withRule(RuleComputedPlaceholderType, fmt.Errorf(
+					"%q: must be %s, but it is set to %s, which is computed as %s",
+					prefix+name, want, ref, ty.FriendlyName())))
+			}
+		}
+	})
+
+	return errs
+}
+
+// placeholderTypeMismatch returns a description of the kind of value that
+// the want type requires if a value of the got type can't be converted to
+// it, as for literalTypeMismatch, or an empty string if it can.
+func placeholderTypeMismatch(want, got cty.Type) string {
+	if got == cty.DynamicPseudoType {
+		return ""
+	}
+	isString := got.IsPrimitiveType()
+	isList := got.IsListType() || got.IsSetType() || got.IsTupleType()
+	isMap := got.IsMapType() || got.IsObjectType()
+
+	switch {
+	case want.IsPrimitiveType() && !isString:
+		return "a single value, such as a string or number"
+	case (want.IsListType() || want.IsSetType()) && !isList:
+		return "a list"
+	case want.IsMapType() && !isMap:
+		return "a map"
+	}
+	return ""
+}
+
+// DefaultValidateMaxNestingDepth is the number of levels that blocks and
+// map values can be nested in the configuration of a resource before it is
+// reported as too deeply nested, unless another limit is given.
+const DefaultValidateMaxNestingDepth = 100
+
+// nestingDepthError returns an error for the first map in the given raw
+// value, whose path is given, that is nested more than maxDepth levels
+// deep, counting the given depth for the value itself, or nil if there is
+// none. A nested block counts as a map, while lists such as those of
+// repeated blocks don't add a level of their own. Elements are searched in
+// order, so that the path reported is predictable.
+func nestingDepthError(raw interface{}, path string, depth, maxDepth int) error {
+	join := func(k string) string {
+		if path == "" {
+			return k
+		}
+		return path + "." + k
+	}
+
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		if depth > maxDepth {
+			return fmt.Errorf(
+				"%q: the configuration is nested more than %d levels deep here, which is the most that can be validated",
+				path, maxDepth)
+		}
+		for _, k := range sortedRawKeys(v) {
+			if err := nestingDepthError(v[k], join(k), depth+1, maxDepth); err != nil {
+				return err
+			}
+		}
+	case []map[string]interface{}:
+		for i, elem := range v {
+			if err := nestingDepthError(elem, join(fmt.Sprint(i)), depth, maxDepth); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			if err := nestingDepthError(elem, join(fmt.Sprint(i)), depth, maxDepth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// objectShapeErrors returns an error for each field that is missing from, or
+// not defined by, the object type of an attribute whose value is set in the
+// given raw configuration of a block, recursing into objects nested within
+// attribute values and into nested blocks. prefix is prepended to attribute
+// names to give their full paths.
+func objectShapeErrors(schema *configschema.Block, raw map[string]interface{}, prefix string) []error {
+	var errs []error
+
+	walkSchemaBlocks(schema, raw, prefix, func(schema *configschema.Block, raw map[string]interface{}, prefix string) {
+		for _, name := range sortedRawKeys(raw) {
+			if attr, ok := schema.Attributes[name]; ok {
+				errs = append(errs, objectValueShapeErrors(attr.Type, raw[name], prefix+name)...)
+			}
+		}
+	})
+
+	return errs
+}
+
+// objectValueShapeErrors returns an error for each field that is missing
+// from, or not defined by, an object of the given type within the given raw
+// value, whose path is given. Objects are found in the value itself and in
+// the elements of lists, sets and maps. Values that are interpolated, and so
+// aren't known until they are evaluated, aren't checked.
+func objectValueShapeErrors(ty cty.Type, raw interface{}, path string) []error {
+	var errs []error
+
+	switch {
+	case ty.IsObjectType():
+		obj, known := rawMapValues(raw)
+		if !known {
+			return nil
+		}
+		atys := ty.AttributeTypes()
+		fields := make([]string, 0, len(atys))
+		for name := range atys {
+			fields = append(fields, name)
+		}
+		sort.Strings(fields)
+
+		for _, name := range fields {
+			v, ok := obj[name]
+			if !ok {
+				errs = append(errs, withRule(RuleMissingObjectField, fmt.Errorf("%q: required field is missing", path+"."+name)))
+				continue
+			}
+			errs = append(errs, objectValueShapeErrors(atys[name], v, path+"."+name)...)
+		}
+		for _, name := range sortedRawKeys(obj) {
+			if _, ok := atys[name]; ok {
+				continue
+			}
+			msg := fmt.Sprintf("%q: unsupported field, which the object type of %q doesn't define", path+"."+name, path)
+			if suggestion := didyoumean.NameSuggestion(name, fields); suggestion != "" {
+				msg += fmt.Sprintf("; did you mean %q?", suggestion)
+			}
+			errs = append(errs, withRule(RuleUnsupportedObjectField, fmt.Errorf("%s", msg)))
+		}
+
+	case ty.IsListType() || ty.IsSetType():
+		switch v := raw.(type) {
+		case []interface{}:
+			for i, elem := range v {
+				errs = append(errs, objectValueShapeErrors(ty.ElementType(), elem, fmt.Sprintf("%s.%d", path, i))...)
+			}
+		case []map[string]interface{}:
+			for i, elem := range v {
+				errs = append(errs, objectValueShapeErrors(ty.ElementType(), elem, fmt.Sprintf("%s.%d", path, i))...)
+			}
+		}
+
+	case ty.IsMapType():
+		elems, known := rawMapValues(raw)
+		if !known {
+			return nil
+		}
+		for _, k := range sortedRawKeys(elems) {
+			errs = append(errs, objectValueShapeErrors(ty.ElementType(), elems[k], path+"."+k)...)
+		}
+	}
+
+	return errs
+}
@@ -2,6 +2,8 @@ package terraform
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/terraform/config"
 )
@@ -28,33 +30,7 @@ func (n *EvalValidateResourceSelfRef) Eval(ctx EvalContext) (interface{}, error)
 			continue
 		}
 
-		// Build an address from the variable
-		varAddr := &ResourceAddress{
-			Path:         addr.Path,
-			Mode:         rv.Mode,
-			Type:         rv.Type,
-			Name:         rv.Name,
-			Index:        rv.Index,
-			InstanceType: TypePrimary,
-		}
-
-		// If the variable access is a multi-access (*), then we just
-		// match the index so that we'll match our own addr if everything
-		// else matches.
-		if rv.Multi && rv.Index == -1 {
-			varAddr.Index = addr.Index
-		}
-
-		// This is a weird thing where ResourceAddres has index "-1" when
-		// index isn't set at all. This means index "0" for resource access.
-		// So, if we have this scenario, just set our varAddr to -1 so it
-		// matches.
-		if addr.Index == -1 && varAddr.Index == 0 {
-			varAddr.Index = -1
-		}
-
-		// If the addresses match, then this is a self reference
-		if varAddr.Equals(addr) && varAddr.Index == addr.Index {
+		if isSelfReference(addr, rv) {
 			errs = append(errs, fmt.Errorf(
 				"%s: self reference not allowed: %q",
 				addr, k))
@@ -72,3 +48,106 @@ func (n *EvalValidateResourceSelfRef) Eval(ctx EvalContext) (interface{}, error)
 		Errors: errs,
 	}
 }
+
+// isSelfReference returns true if the given resource variable refers to the
+// resource instance with the given address.
+func isSelfReference(addr *ResourceAddress, rv *config.ResourceVariable) bool {
+	// Build an address from the variable
+	varAddr := &ResourceAddress{
+		Path:         addr.Path,
+		Mode:         rv.Mode,
+		Type:         rv.Type,
+		Name:         rv.Name,
+		Index:        rv.Index,
+		InstanceType: TypePrimary,
+	}
+
+	// If the variable access is a multi-access (*), then we just
+	// match the index so that we'll match our own addr if everything
+	// else matches.
+	if rv.Multi && rv.Index == -1 {
+		varAddr.Index = addr.Index
+	}
+
+	// This is a weird thing where ResourceAddres has index "-1" when
+	// index isn't set at all. This means index "0" for resource access.
+	// So, if we have this scenario, just set our varAddr to -1 so it
+	// matches.
+	if addr.Index == -1 && varAddr.Index == 0 {
+		varAddr.Index = -1
+	}
+
+	// If the addresses match, then this is a self reference
+	return varAddr.Equals(addr) && varAddr.Index == addr.Index
+}
+
+// selfReferencesThroughLocals returns an error for each chain of local
+// values that leads from the given configuration of the resource with the
+// given address back to the resource itself. locals are the local values of
+// the resource's module.
+//
+// Such a chain is a cycle in the graph, and so this must be checked before
+// the graph is built in order to report the cycle with its full chain of
+// references.
+func selfReferencesThroughLocals(addr *ResourceAddress, conf *config.RawConfig, locals []*config.Local) []error {
+	byName := make(map[string]*config.Local, len(locals))
+	for _, l := range locals {
+		byName[l.Name] = l
+	}
+
+	visited := make(map[string]bool)
+
+	// chain returns the local values that lead from the given local value
+	// back to the resource, or nil if there are none.
+	var chain func(name string) []string
+	chain = func(name string) []string {
+		l, ok := byName[name]
+		if !ok || visited[name] {
+			return nil
+		}
+		visited[name] = true
+
+		for _, k := range sortedInterpolatedVariableKeys(l.RawConfig.Variables) {
+			switch v := l.RawConfig.Variables[k].(type) {
+			case *config.ResourceVariable:
+				if isSelfReference(addr, v) {
+					return []string{"local." + name}
+				}
+			case *config.LocalVariable:
+				if rest := chain(v.Name); rest != nil {
+					return append([]string{"local." + name}, rest...)
+				}
+			}
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, k := range sortedInterpolatedVariableKeys(conf.Variables) {
+		lv, ok := conf.Variables[k].(*config.LocalVariable)
+		if !ok {
+			continue
+		}
+		hops := chain(lv.Name)
+		if hops == nil {
+			continue
+		}
+
+		hops = append(append([]string{addr.String()}, hops...), addr.String())
+		errs = append(errs, fmt.Errorf(
+			"%s: self reference not allowed through local values: %s",
+			addr, strings.Join(hops, " -> ")))
+	}
+	return errs
+}
+
+// sortedInterpolatedVariableKeys returns the keys of the given variables in
+// lexicographic order.
+func sortedInterpolatedVariableKeys(vars map[string]config.InterpolatedVariable) []string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,10 +2,17 @@ package terraform
 
 import (
 	"errors"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestEvalValidateResource_managedResource(t *testing.T) {
@@ -179,6 +186,436 @@ func TestEvalValidateResource_ignoreWarnings(t *testing.T) {
 	}
 }
 
+func TestEvalValidateResource_deprecatedReplacement(t *testing.T) {
+	mp := testProvider("aws")
+	mp.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"ami":      {Type: cty.String, Optional: true, ReplacedBy: "image_id"},
+					"image_id": {Type: cty.String, Optional: true},
+					"name":     {Type: cty.String, Optional: true},
+					"label":    {Type: cty.String, Optional: true},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"disk": {
+						Nesting: configschema.NestingList,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"size":    {Type: cty.Number, Optional: true, ReplacedBy: "size_gb"},
+								"size_gb": {Type: cty.Number, Optional: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	p := ResourceProvider(mp)
+	rc := testResourceConfig(t, map[string]interface{}{
+		"ami":      "ami-abc123",
+		"image_id": "ami-abc123",
+		"name":     "foo",
+		"label":    "foo",
+		"disk": []map[string]interface{}{
+			{"size_gb": 10},
+			{"size": 20, "size_gb": 20},
+		},
+	})
+	node := &EvalValidateResource{
+		Provider:     &p,
+		Config:       &rc,
+		ResourceName: "foo",
+		ResourceType: "aws_instance",
+		ResourceMode: config.ManagedResourceMode,
+	}
+
+	_, err := node.Eval(&MockEvalContext{})
+	if err == nil {
+		t.Fatal("Expected an error, got none!")
+	}
+
+	var got []string
+	for _, err := range err.(*EvalValidateError).Errors {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`"ami" is deprecated and replaced by "image_id"; set only "image_id"`,
+		`"disk.1.size" is deprecated and replaced by "disk.1.size_gb"; set only "disk.1.size_gb"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestEvalValidateResource_deprecatedResourceType(t *testing.T) {
+	mp := testProvider("aws")
+	mp.GetSchemaReturn = &ProviderSchema{
+		DeprecatedResourceTypes: map[string]string{
+			"aws_elb_old": "aws_elb",
+			"aws_legacy":  "",
+		},
+	}
+
+	cases := map[string]struct {
+		Type string
+		Mode config.ResourceMode
+		Want []string
+	}{
+		"replaced": {
+			"aws_elb_old",
+			config.ManagedResourceMode,
+			[]string{`aws_elb_old.foo: resource type "aws_elb_old" is deprecated and replaced by "aws_elb"; use "aws_elb" instead`},
+		},
+		"no replacement": {
+			"aws_legacy",
+			config.ManagedResourceMode,
+			[]string{`aws_legacy.foo: resource type "aws_legacy" is deprecated`},
+		},
+		"not deprecated": {
+			"aws_instance",
+			config.ManagedResourceMode,
+			nil,
+		},
+		"data source": {
+			"aws_elb_old",
+			config.DataResourceMode,
+			nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := ResourceProvider(mp)
+			rc := &ResourceConfig{}
+			node := &EvalValidateResource{
+				Provider:     &p,
+				Config:       &rc,
+				ResourceName: "foo",
+				ResourceType: tc.Type,
+				ResourceMode: tc.Mode,
+			}
+
+			_, err := node.Eval(&MockEvalContext{})
+			var got []string
+			if err != nil {
+				verr := err.(*EvalValidateError)
+				if len(verr.Errors) > 0 {
+					t.Fatalf("unexpected errors: %s", verr.Errors)
+				}
+				got = verr.Warnings
+			}
+			if !reflect.DeepEqual(got, tc.Want) {
+				t.Fatalf("wrong warnings\ngot:  %#v\nwant: %#v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestEvalValidateResource_readOnlyAttributes(t *testing.T) {
+	mp := &schemaOnlyResourceProvider{Schema: &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"ami":        {Type: cty.String, Required: true},
+					"arn":        {Type: cty.String, Computed: true},
+					"private_ip": {Type: cty.String, Optional: true, Computed: true},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"disk": {
+						Nesting: configschema.NestingList,
+						Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"size":      {Type: cty.Number, Optional: true},
+								"volume_id": {Type: cty.String, Computed: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+
+	p := ResourceProvider(mp)
+	rc := testResourceConfig(t, map[string]interface{}{
+		"ami":        "ami-abc123",
+		"arn":        "arn:aws:ec2:foo",
+		"private_ip": "10.0.0.1",
+		"disk": []map[string]interface{}{
+			{"size": 10},
+			{"size": 20, "volume_id": "vol-abc123"},
+		},
+	})
+	node := &EvalValidateResource{
+		Provider:     &p,
+		Config:       &rc,
+		ResourceName: "foo",
+		ResourceType: "aws_instance",
+		ResourceMode: config.ManagedResourceMode,
+	}
+
+	_, err := node.Eval(&MockEvalContext{})
+	if err == nil {
+		t.Fatal("Expected an error, got none!")
+	}
+
+	var got []string
+	for _, err := range err.(*EvalValidateError).Errors {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`"arn": this attribute is read-only, since its value is computed by the provider; remove it from the configuration`,
+		`"disk.1.volume_id": this attribute is read-only, since its value is computed by the provider; remove it from the configuration`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestEvalValidateResource_objectShape(t *testing.T) {
+	mp := testProvider("aws")
+	mp.GetSchemaReturn = &ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"aws_s3_bucket": {
+				Attributes: map[string]*configschema.Attribute{
+					"bucket": {Type: cty.String, Required: true},
+					"logging": {
+						Type: cty.Object(map[string]cty.Type{
+							"target": cty.String,
+							"retention": cty.Object(map[string]cty.Type{
+								"days": cty.Number,
+							}),
+						}),
+						Optional: true,
+					},
+					"rules": {
+						Type: cty.List(cty.Object(map[string]cty.Type{
+							"id":     cty.String,
+							"prefix": cty.String,
+						})),
+						Optional: true,
+					},
+					"versioning": {
+						Type: cty.Object(map[string]cty.Type{
+							"enabled": cty.Bool,
+						}),
+						Optional: true,
+					},
+				},
+			},
+		},
+	}
+
+	p := ResourceProvider(mp)
+	rc := testResourceConfig(t, map[string]interface{}{
+		"bucket": "example",
+		"logging": []map[string]interface{}{
+			{
+				"target": "logs",
+				"retention": []map[string]interface{}{
+					{"dayz": 7},
+				},
+			},
+		},
+		"rules": []interface{}{
+			map[string]interface{}{"id": "a", "prefix": "tmp/"},
+			map[string]interface{}{"id": "b", "prefix": "${var.prefix}", "expire": true},
+		},
+		"versioning": "${var.versioning}",
+	})
+	node := &EvalValidateResource{
+		Provider:     &p,
+		Config:       &rc,
+		ResourceName: "foo",
+		ResourceType: "aws_s3_bucket",
+		ResourceMode: config.ManagedResourceMode,
+	}
+
+	_, err := node.Eval(&MockEvalContext{})
+	if err == nil {
+		t.Fatal("Expected an error, got none!")
+	}
+
+	var got []string
+	for _, err := range err.(*EvalValidateError).Errors {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`"logging.retention.days": required field is missing`,
+		`"logging.retention.dayz": unsupported field, which the object type of "logging.retention" doesn't define; did you mean "days"?`,
+		`"rules.1.expire": unsupported field, which the object type of "rules.1" doesn't define`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestEvalValidateResource_maxNestingDepth(t *testing.T) {
+	// nested returns a configuration in which "rule" blocks are nested to
+	// the given depth.
+	nested := func(depth int) map[string]interface{} {
+		raw := map[string]interface{}{"name": "innermost"}
+		for i := 0; i < depth; i++ {
+			raw = map[string]interface{}{
+				"rule": []map[string]interface{}{raw},
+			}
+		}
+		return raw
+	}
+
+	tests := map[string]struct {
+		Depth   int
+		WantErr string
+	}{
+		"at the limit": {
+			3,
+			"",
+		},
+		"beyond the limit": {
+			4,
+			`"rule.0.rule.0.rule.0.rule.0": the configuration is nested more than 3 levels deep here, which is the most that can be validated`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mp := testProvider("aws")
+			p := ResourceProvider(mp)
+			rc := testResourceConfig(t, nested(test.Depth))
+			node := &EvalValidateResource{
+				Provider:        &p,
+				Config:          &rc,
+				ResourceName:    "foo",
+				ResourceType:    "aws_instance",
+				ResourceMode:    config.ManagedResourceMode,
+				MaxNestingDepth: 3,
+			}
+
+			_, err := node.Eval(&MockEvalContext{})
+			if test.WantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected an error, got none!")
+			}
+
+			var got []string
+			for _, err := range err.(*EvalValidateError).Errors {
+				got = append(got, err.Error())
+			}
+			want := []string{test.WantErr}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestEvalValidateProvider_schema(t *testing.T) {
+	mp := testProvider("aws")
+	mp.GetSchemaReturn = &ProviderSchema{
+		Provider: &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"region":              {Type: cty.String, Optional: true},
+				"allowed_account_ids": {Type: cty.Set(cty.String), Optional: true},
+				"max_retries":         {Type: cty.Number, Optional: true},
+				"profile":             {Type: cty.String, Optional: true},
+			},
+			BlockTypes: map[string]*configschema.NestedBlock{
+				"assume_role": {
+					Nesting: configschema.NestingList,
+				},
+			},
+		},
+	}
+	mp.ValidateReturnErrors = []error{errors.New("provider error")}
+
+	raw, err := config.NewRawConfig(map[string]interface{}{
+		"regoin":              "us-west-2",
+		"allowed_account_ids": "123456",
+		"max_retries":         []interface{}{"5"},
+		"profile":             "${var.profile}",
+		"assume_role":         []map[string]interface{}{{}},
+		"bogus":               "yes",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	p := ResourceProvider(mp)
+	rc := NewResourceConfig(raw)
+	node := &EvalValidateProvider{
+		Provider: &p,
+		Config:   &rc,
+		Decl: &config.ProviderConfig{
+			Name:      "aws",
+			RawConfig: raw,
+			DeclRange: tfdiags.SourceRange{
+				Filename: "main.tf",
+				Start:    tfdiags.SourcePos{Line: 1, Column: 1},
+				End:      tfdiags.SourcePos{Line: 1, Column: 15},
+			},
+		},
+	}
+
+	_, err = node.Eval(&MockEvalContext{})
+	if err == nil {
+		t.Fatal("Expected an error, got none!")
+	}
+
+	var got []string
+	for _, err := range err.(*EvalValidateError).Errors {
+		re, ok := err.(*ruleError)
+		if !ok {
+			got = append(got, err.Error())
+			continue
+		}
+		diag, ok := re.err.(*hcl.Diagnostic)
+		if !ok {
+			t.Fatalf("error is %#v; want *hcl.Diagnostic", re.err)
+		}
+		got = append(got, diag.Subject.String()+": "+diag.Summary+"; "+diag.Detail)
+	}
+	want := []string{
+		`provider error`,
+		`main.tf:1,1-15: Incorrect attribute value type; The argument "allowed_account_ids" must be a list.`,
+		`main.tf:1,1-15: Unsupported argument; An argument named "bogus" is not expected here.`,
+		`main.tf:1,1-15: Incorrect attribute value type; The argument "max_retries" must be a single value, such as a string or number.`,
+		`main.tf:1,1-15: Unsupported argument; An argument named "regoin" is not expected here. Did you mean "region"?`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestEvalValidateProvider_noSchema(t *testing.T) {
+	raw, err := config.NewRawConfig(map[string]interface{}{
+		"bogus": "yes",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	p := ResourceProvider(testProvider("aws"))
+	rc := NewResourceConfig(raw)
+	node := &EvalValidateProvider{
+		Provider: &p,
+		Config:   &rc,
+		Decl: &config.ProviderConfig{
+			Name:      "aws",
+			RawConfig: raw,
+		},
+	}
+
+	if _, err := node.Eval(&MockEvalContext{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
 func TestEvalValidateProvisioner_valid(t *testing.T) {
 	mp := &MockResourceProvisioner{}
 	var p ResourceProvisioner = mp
@@ -252,6 +689,157 @@ func TestEvalValidateProvisioner_warning(t *testing.T) {
 	}
 }
 
+func TestEvalValidateProvisionerReferences(t *testing.T) {
+	ctx := &MockEvalContext{
+		ResourceSchemasSchemas: map[string]*ResourceSchema{
+			"aws_instance.web": {
+				Provider: "aws",
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"foo": {Type: cty.String, Optional: true},
+					},
+				},
+			},
+		},
+		ResourceSchemasLock: &sync.Mutex{},
+	}
+
+	m := testModule(t, "validate-provisioner-references")
+	mod := m.Config()
+	pc := mod.Resources[0].Provisioners[0]
+
+	addr, err := ParseResourceAddress("aws_instance.web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := &EvalValidateProvisionerReferences{
+		ProvisionerConfig: pc,
+		Module:            mod,
+		ResourceAddr:      addr,
+	}
+
+	_, err = node.Eval(ctx)
+	if err == nil {
+		t.Fatalf("node.Eval succeeded; want error")
+	}
+	valErr, ok := err.(*EvalValidateError)
+	if !ok {
+		t.Fatalf("node.Eval error is %#v; want *EvalValidateError", err)
+	}
+
+	var got []string
+	for _, err := range valErr.Errors {
+		re, ok := err.(*ruleError)
+		if !ok {
+			t.Fatalf("error is %#v; want *ruleError", err)
+		}
+		if re.rule != RuleUndeclaredReference && re.rule != RuleUnsupportedSelfAttribute {
+			t.Errorf("error has wrong rule %q", re.rule)
+		}
+		diag, ok := re.err.(*hcl.Diagnostic)
+		if !ok {
+			t.Fatalf("error is %#v; want *hcl.Diagnostic", re.err)
+		}
+		if diag.Subject == nil {
+			t.Fatalf("diagnostic has no source range: %s", diag.Summary)
+		}
+		got = append(got, diag.Subject.String()+": "+diag.Detail)
+	}
+
+	filename := filepath.Join(fixtureDir, "validate-provisioner-references", "main.tf")
+	want := []string{
+		filename + `:10,17-26: The local-exec provisioner of aws_instance.web refers to self.nope in environment.ADDR. The resource aws_instance.web, which self refers to, has no attribute named "nope".`,
+		filename + `:11,17-30: The local-exec provisioner of aws_instance.web refers to local.missing in environment.NAME. A local value named "missing" has not been declared.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong errors\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestEvalValidateProvisioner_connectionTimeout(t *testing.T) {
+	tests := map[string]struct {
+		Timeout string
+		Want    string
+	}{
+		"valid": {
+			"5m",
+			"",
+		},
+		"invalid": {
+			"5 minutes",
+			`The connection timeout "5 minutes" of the shell provisioner of aws_instance.foo is not a valid duration, such as "30s" or "5m", so the communicator would ignore it and use its default timeout instead.`,
+		},
+		"unknown": {
+			config.UnknownVariableValue,
+			"",
+		},
+	}
+
+	addr, err := ParseResourceAddress("aws_instance.foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p ResourceProvisioner = &MockResourceProvisioner{}
+			ctx := &MockEvalContext{}
+
+			cfg := &ResourceConfig{}
+			connInfo, err := config.NewRawConfig(map[string]interface{}{
+				"timeout": test.Timeout,
+			})
+			if err != nil {
+				t.Fatalf("failed to make connInfo: %s", err)
+			}
+			connConfig := NewResourceConfig(connInfo)
+
+			rng := tfdiags.SourceRange{
+				Filename: "main.tf",
+				Start:    tfdiags.SourcePos{Line: 5, Column: 15, Byte: 80},
+				End:      tfdiags.SourcePos{Line: 5, Column: 15, Byte: 80},
+			}
+			node := &EvalValidateProvisioner{
+				Provisioner: &p,
+				Config:      &cfg,
+				ConnConfig:  &connConfig,
+				ProvisionerConfig: &config.Provisioner{
+					Type:        "shell",
+					ValueRanges: map[string]tfdiags.SourceRange{"connection.timeout": rng},
+				},
+				ResourceAddr: addr,
+			}
+
+			_, err = node.Eval(ctx)
+			if test.Want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			valErr, ok := err.(*EvalValidateError)
+			if !ok {
+				t.Fatalf("node.Eval error is %#v; want *EvalValidateError", err)
+			}
+			if len(valErr.Errors) != 1 {
+				t.Fatalf("wrong number of errors in %#v; want one error", valErr.Errors)
+			}
+			diag, ok := valErr.Errors[0].(*hcl.Diagnostic)
+			if !ok {
+				t.Fatalf("error is %#v; want *hcl.Diagnostic", valErr.Errors[0])
+			}
+			if diag.Detail != test.Want {
+				t.Errorf("wrong detail\ngot:  %s\nwant: %s", diag.Detail, test.Want)
+			}
+			if diag.Subject == nil || diag.Subject.Start.Line != 5 {
+				t.Errorf("wrong subject %#v; want line 5 of main.tf", diag.Subject)
+			}
+		})
+	}
+}
+
 func TestEvalValidateProvisioner_connectionInvalid(t *testing.T) {
 	var p ResourceProvisioner = &MockResourceProvisioner{}
 	ctx := &MockEvalContext{}
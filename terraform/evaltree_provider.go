@@ -67,6 +67,7 @@ func ProviderEvalTree(n *NodeApplyableProvider, config *config.ProviderConfig) E
 				&EvalValidateProvider{
 					Provider: &provider,
 					Config:   &resourceConfig,
+					Decl:     config,
 				},
 			},
 		},
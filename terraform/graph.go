@@ -5,10 +5,19 @@ import (
 	"log"
 	"runtime/debug"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/dag"
 )
 
+// graphWalkerExpandTimer can be optionally implemented by a GraphWalker to
+// be told how long the dynamic expansion of each vertex took.
+type graphWalkerExpandTimer interface {
+	ExpandDone(dag.Vertex, time.Duration)
+}
+
 // RootModuleName is the name given to the root module implicitly.
 const RootModuleName = "root"
 
@@ -44,10 +53,68 @@ func (g *Graph) Walk(walker GraphWalker) error {
 	return g.walk(walker)
 }
 
+// WalkLayers walks the graph with the given walker one topological layer
+// at a time, as returned by dag.AcyclicGraph.Layers. All of the vertices in
+// a layer are visited concurrently, and the next layer is not started until
+// every vertex in the current layer has completed.
+//
+// After each layer completes, stop is called and if it returns true then
+// the walk ends without visiting any of the remaining layers. The given
+// layer is the zero-based index of the layer that just completed.
+//
+// Dynamic subgraphs and nested subgraphs are still walked in full as part
+// of the vertex that produces them.
+func (g *Graph) WalkLayers(walker GraphWalker, stop func(layer int) bool) error {
+	walkFn, done := g.walkFunc(walker)
+	defer done()
+
+	layers, err := g.Layers()
+	if err != nil {
+		return err
+	}
+
+	for i, layer := range layers {
+		var wg sync.WaitGroup
+		var errLock sync.Mutex
+		var errs error
+		for _, v := range layer {
+			wg.Add(1)
+			go func(v dag.Vertex) {
+				defer wg.Done()
+				if err := walkFn(v); err != nil {
+					errLock.Lock()
+					errs = multierror.Append(errs, err)
+					errLock.Unlock()
+				}
+			}(v)
+		}
+		wg.Wait()
+
+		if errs != nil {
+			return errs
+		}
+		if stop != nil && stop(i) {
+			log.Printf("[INFO] terraform: layered walk stopped after layer %d of %d", i+1, len(layers))
+			return nil
+		}
+	}
+
+	return nil
+}
+
 func (g *Graph) walk(walker GraphWalker) error {
+	walkFn, done := g.walkFunc(walker)
+	defer done()
+
+	return g.AcyclicGraph.Walk(walkFn)
+}
+
+// walkFunc prepares the given walker to walk this graph, returning the
+// function to call for each vertex and a function that must be called once
+// the walk is complete.
+func (g *Graph) walkFunc(walker GraphWalker) (dag.WalkFunc, func()) {
 	// The callbacks for enter/exiting a graph
 	ctx := walker.EnterPath(g.Path)
-	defer walker.ExitPath(g.Path)
 
 	// Get the path for logs
 	path := strings.Join(ctx.Path(), ".")
@@ -65,7 +132,10 @@ func (g *Graph) walk(walker GraphWalker) error {
 
 	debugBuf := dbug.NewFileWriter(debugName)
 	g.SetDebugWriter(debugBuf)
-	defer debugBuf.Close()
+	done := func() {
+		debugBuf.Close()
+		walker.ExitPath(g.Path)
+	}
 
 	// Walk the graph.
 	var walkFn dag.WalkFunc
@@ -138,7 +208,11 @@ func (g *Graph) walk(walker GraphWalker) error {
 
 			g.DebugVertexInfo(v, fmt.Sprintf("expanding %T(%s)", v, path))
 
+			start := time.Now()
 			g, err := ev.DynamicExpand(vertexCtx)
+			if et, ok := walker.(graphWalkerExpandTimer); ok {
+				et.ExpandDone(v, time.Since(start))
+			}
 			if err != nil {
 				rerr = err
 				return
@@ -168,5 +242,5 @@ func (g *Graph) walk(walker GraphWalker) error {
 		return nil
 	}
 
-	return g.AcyclicGraph.Walk(walkFn)
+	return walkFn, done
 }
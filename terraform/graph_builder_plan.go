@@ -40,6 +40,43 @@ type PlanGraphBuilder struct {
 	// Validate will do structural validation of the graph.
 	Validate bool
 
+	// CollapseProviders, if true, will route resources to a single provider
+	// node when several provider configurations are identical. This is only
+	// safe for the validate walk. ProviderInput is the provider input that
+	// will be available during the walk, which is used to decide which
+	// provider configurations are identical. SharedProviders, if non-nil,
+	// is where the names of the providers whose instances are shared are
+	// recorded, as for CollapseEquivalentProviderTransformer.
+	CollapseProviders bool
+	ProviderInput     map[string]map[string]interface{}
+	SharedProviders   map[string]string
+
+	// ValidateDestroy, if true, indicates that the graph is being built to
+	// validate the configuration for a destroy. It is used only by
+	// ValidateGraphBuilder.
+	ValidateDestroy bool
+
+	// ValidateProviders, if non-empty, is the provider types whose
+	// resources are to be validated, excluding all others. It is used only
+	// by ValidateGraphBuilder.
+	ValidateProviders []string
+
+	// ValidateProviderOverrides, if non-empty, are the provider
+	// configurations that resources are to be validated with in place of
+	// their own, as for ProviderOverrideTransformer. It is used only by
+	// ValidateGraphBuilder.
+	ValidateProviderOverrides map[string]string
+
+	// ValidateMaxNestingDepth is the nesting depth limit for the
+	// configuration of resources, as for EvalValidateResource. It is used
+	// only by ValidateGraphBuilder.
+	ValidateMaxNestingDepth int
+
+	// ValidateComputedPlaceholders is whether the references to computed
+	// attributes in the configuration of resources are type-checked, as
+	// for EvalValidateResource. It is used only by ValidateGraphBuilder.
+	ValidateComputedPlaceholders bool
+
 	// CustomConcrete can be set to customize the node types created
 	// for various parts of the plan. This is useful in order to customize
 	// the plan behavior.
@@ -99,7 +136,12 @@ func (b *PlanGraphBuilder) Steps() []GraphTransformer {
 		// Add root variables
 		&RootVariableTransformer{Module: b.Module},
 
-		TransformProviders(b.Providers, b.ConcreteProvider, b.Module),
+		TransformProviders(b.Providers, b.ConcreteProvider, b.Module,
+			GraphTransformIf(
+				func() bool { return len(b.ValidateProviderOverrides) > 0 },
+				&ProviderOverrideTransformer{Overrides: b.ValidateProviderOverrides},
+			),
+		),
 
 		// Provisioner-related transformations. Only add these if requested.
 		GraphTransformIf(
@@ -118,6 +160,9 @@ func (b *PlanGraphBuilder) Steps() []GraphTransformer {
 		// Remove modules no longer present in the config
 		&RemovedModuleTransformer{Module: b.Module, State: b.State},
 
+		// Record where each object was declared, for diagnostics
+		&AttachSourceRangeTransformer{Module: b.Module},
+
 		// Connect so that the references are ready for targeting. We'll
 		// have to connect again later for providers and so on.
 		&ReferenceTransformer{},
@@ -136,6 +181,15 @@ func (b *PlanGraphBuilder) Steps() []GraphTransformer {
 			IgnoreIndices: true,
 		},
 
+		// Share provider nodes that are identical, if requested.
+		GraphTransformIf(
+			func() bool { return b.CollapseProviders },
+			&CollapseEquivalentProviderTransformer{
+				ProviderInput: b.ProviderInput,
+				Shared:        b.SharedProviders,
+			},
+		),
+
 		// Close opened plugin connections
 		&CloseProviderTransformer{},
 		&CloseProvisionerTransformer{},
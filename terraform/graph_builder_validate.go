@@ -14,6 +14,11 @@ func ValidateGraphBuilder(p *PlanGraphBuilder) GraphBuilder {
 	// We're going to customize the concrete functions
 	p.CustomConcrete = true
 
+	// Validation doesn't configure providers, so there's no need to start
+	// a separate instance for each identical provider configuration, unless
+	// provider overrides name particular provider configurations.
+	p.CollapseProviders = len(p.ValidateProviderOverrides) == 0
+
 	// Set the provider to the normal provider. This will ask for input.
 	p.ConcreteProvider = func(a *NodeAbstractProvider) dag.Vertex {
 		return &NodeApplyableProvider{
@@ -26,6 +31,12 @@ func ValidateGraphBuilder(p *PlanGraphBuilder) GraphBuilder {
 			NodeAbstractCountResource: &NodeAbstractCountResource{
 				NodeAbstractResource: a,
 			},
+			Destroy:         p.ValidateDestroy,
+			Providers:       p.ValidateProviders,
+			Module:          p.Module,
+			MaxNestingDepth: p.ValidateMaxNestingDepth,
+
+			ComputedPlaceholders: p.ValidateComputedPlaceholders,
 		}
 	}
 
@@ -0,0 +1,78 @@
+package terraform
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// GraphDotDiagnostics returns the dot formatting of a visual representation
+// of the given Terraform graph, as for GraphDot, with each vertex annotated
+// with the diagnostics it produced: the "diagnostics" attribute is the
+// number of diagnostics, and vertices with errors or warnings are given a
+// "severity" attribute and colored red or orange respectively.
+//
+// The diagnostics are keyed by vertex name, as returned by
+// Context.VertexDiagnostics after a call to Validate. Vertices without an
+// entry are annotated as having no diagnostics.
+func GraphDotDiagnostics(g *Graph, diags map[string]tfdiags.Diagnostics, opts *dag.DotOpts) (string, error) {
+	annotated := &Graph{Path: g.Path}
+	wrapped := make(map[dag.Vertex]dag.Vertex)
+	for _, v := range g.Vertices() {
+		av := &graphNodeDotDiagnostics{
+			Vertex:      v,
+			Diagnostics: diags[dag.VertexName(v)],
+		}
+		wrapped[v] = av
+		annotated.Add(av)
+	}
+	for _, e := range g.Edges() {
+		annotated.Connect(dag.BasicEdge(wrapped[e.Source()], wrapped[e.Target()]))
+	}
+
+	return GraphDot(annotated, opts)
+}
+
+// graphNodeDotDiagnostics wraps a vertex of a graph to annotate its dot
+// representation with the diagnostics that it produced.
+type graphNodeDotDiagnostics struct {
+	Vertex      dag.Vertex
+	Diagnostics tfdiags.Diagnostics
+}
+
+func (n *graphNodeDotDiagnostics) Name() string {
+	return dag.VertexName(n.Vertex)
+}
+
+// GraphNodeDotter impl.
+func (n *graphNodeDotDiagnostics) DotNode(name string, opts *dag.DotOpts) *dag.DotNode {
+	dotter, ok := n.Vertex.(dag.GraphNodeDotter)
+	if !ok {
+		return nil
+	}
+	node := dotter.DotNode(name, opts)
+	if node == nil {
+		return nil
+	}
+
+	attrs := make(map[string]string, len(node.Attrs)+3)
+	for k, v := range node.Attrs {
+		attrs[k] = v
+	}
+	attrs["diagnostics"] = strconv.Itoa(len(n.Diagnostics))
+
+	switch {
+	case n.Diagnostics.HasErrors():
+		attrs["severity"] = "error"
+		attrs["color"] = "red"
+	case len(n.Diagnostics) > 0:
+		attrs["severity"] = "warning"
+		attrs["color"] = "orange"
+	}
+
+	return &dag.DotNode{
+		Name:  node.Name,
+		Attrs: attrs,
+	}
+}
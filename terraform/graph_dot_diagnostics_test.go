@@ -0,0 +1,60 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGraphDotDiagnostics(t *testing.T) {
+	p := testProvider("aws")
+	p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+		if _, ok := c.Config["fail"]; ok {
+			return nil, []error{fmt.Errorf("fail is set")}
+		}
+		if _, ok := c.Config["warn"]; ok {
+			return []string{"warn is set", "warn is set again"}, nil
+		}
+		return nil, nil
+	}
+	m := testModule(t, "validate-graph-diagnostics")
+	c := testContext2(t, &ContextOpts{
+		Module: m,
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	})
+
+	if diags := c.Validate(); !diags.HasErrors() {
+		t.Fatal("succeeded; want errors")
+	}
+	g, err := c.Graph(GraphTypeValidate, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual, err := GraphDotDiagnostics(g, c.VertexDiagnostics(), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, want := range []string{
+		`"[root] aws_instance.fail" [color = "red", diagnostics = "1", label = "aws_instance.fail", severity = "error", shape = "box"`,
+		`"[root] aws_instance.ok" [diagnostics = "0", label = "aws_instance.ok", shape = "box"`,
+		`"[root] aws_instance.warn" [color = "orange", diagnostics = "2", label = "aws_instance.warn", severity = "warning", shape = "box"`,
+		`"[root] aws_instance.ok" -> "[root] aws_instance.warn"`,
+	} {
+		if !strings.Contains(actual, want) {
+			t.Errorf("output does not contain %s\n\n%s", want, actual)
+		}
+	}
+
+	again, err := GraphDotDiagnostics(g, c.VertexDiagnostics(), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if again != actual {
+		t.Fatalf("output is not deterministic\n\n%s\n\n%s", actual, again)
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // ContextGraphWalker is the GraphWalker implementation used with the
@@ -25,6 +27,29 @@ type ContextGraphWalker struct {
 	ValidationWarnings []string
 	ValidationErrors   []error
 
+	// moduleValidations holds the same warnings and errors as above, grouped
+	// by the module path of the vertex that produced them and keyed by
+	// PathCacheKey of that path.
+	moduleValidations map[string]*moduleValidation
+
+	// vertexDiagnostics holds the same warnings and errors again, keyed by
+	// the name of the vertex that produced them and without the name
+	// prefixed to the messages.
+	vertexDiagnostics map[string]tfdiags.Diagnostics
+
+	// outputSensitivities records the outputs found to be sensitive while
+	// validating, so that outputs in calling modules can refer to them.
+	outputSensitivities     map[string]*outputSensitivity
+	outputSensitivitiesLock sync.Mutex
+
+	// providerSchemas caches the schemas fetched from providers while
+	// validating, keyed by providerSchemaKey.
+	providerSchemas     map[string]*ProviderSchema
+	providerSchemasLock sync.Mutex
+
+	// validateCounts counts the vertices walked while validating.
+	validateCounts validateCounts
+
 	errorLock           sync.Mutex
 	once                sync.Once
 	contexts            map[string]*BuiltinEvalContext
@@ -92,6 +117,17 @@ func (w *ContextGraphWalker) EnterPath(path []string) EvalContext {
 		InterpolaterVars:    w.interpolaterVars,
 		InterpolaterVarLock: &w.interpolaterVarLock,
 	}
+	if w.Operation == walkValidate {
+		ctx.Now = w.Context.validateClock
+		ctx.ResourceSchemasValue = w.Context.resourceSchemas
+		ctx.ResourceSchemasLock = &w.Context.resourceSchemasLock
+		ctx.OutputSensitivitiesValue = w.outputSensitivities
+		ctx.OutputSensitivitiesLock = &w.outputSensitivitiesLock
+		ctx.ProviderSchemasValue = w.providerSchemas
+		ctx.ProviderSchemasLock = &w.providerSchemasLock
+		ctx.ProviderOverrides = w.Context.validateProviderOverrides
+		ctx.SharedProviders = w.Context.sharedProviders
+	}
 
 	w.contexts[key] = ctx
 	return ctx
@@ -106,7 +142,35 @@ func (w *ContextGraphWalker) EnterEvalTree(v dag.Vertex, n EvalNode) EvalNode {
 
 	// We want to filter the evaluation tree to only include operations
 	// that belong in this operation.
-	return EvalFilter(n, EvalNodeFilterOp(w.Operation))
+	n = EvalFilter(n, EvalNodeFilterOp(w.Operation))
+
+	if w.Operation == walkValidate {
+		w.validateCounts.add(v)
+	}
+
+	if _, ok := v.(*NodeValidatableResourceInstance); ok && w.validateTimings() != nil {
+		n = &evalTimed{
+			Node:    n,
+			Timings: w.validateTimings(),
+			Phase:   validatePhaseResourceValidation,
+		}
+	}
+	return n
+}
+
+// ExpandDone records the time taken by dynamic expansion, for
+// graphWalkerExpandTimer.
+func (w *ContextGraphWalker) ExpandDone(v dag.Vertex, d time.Duration) {
+	w.validateTimings().add(validatePhaseExpand, d)
+}
+
+// validateTimings returns the timings to record the walk in, or nil if it
+// isn't being timed.
+func (w *ContextGraphWalker) validateTimings() *ValidateTimings {
+	if w.Operation != walkValidate {
+		return nil
+	}
+	return w.Context.validateTimings
 }
 
 func (w *ContextGraphWalker) ExitEvalTree(
@@ -132,16 +196,32 @@ func (w *ContextGraphWalker) ExitEvalTree(
 		return err
 	}
 
+	path := RootModulePath
+	if sp, ok := v.(GraphNodeSubPath); ok {
+		path = normalizeModulePath(sp.Path())
+	}
+	key := PathCacheKey(path)
+	mv, ok := w.moduleValidations[key]
+	if !ok {
+		mv = &moduleValidation{Path: path}
+		w.moduleValidations[key] = mv
+	}
+
+	name := dag.VertexName(v)
+	vd := w.vertexDiagnostics[name]
 	for _, msg := range verr.Warnings {
-		w.ValidationWarnings = append(
-			w.ValidationWarnings,
-			fmt.Sprintf("%s: %s", dag.VertexName(v), msg))
+		warn := fmt.Sprintf("%s: %s", name, msg)
+		w.ValidationWarnings = append(w.ValidationWarnings, warn)
+		mv.Warnings = append(mv.Warnings, warn)
+		vd = vd.Append(tfdiags.SimpleWarning(msg))
 	}
 	for _, e := range verr.Errors {
-		w.ValidationErrors = append(
-			w.ValidationErrors,
-			errwrap.Wrapf(fmt.Sprintf("%s: {{err}}", dag.VertexName(v)), e))
+		err := errwrap.Wrapf(fmt.Sprintf("%s: {{err}}", name), e)
+		w.ValidationErrors = append(w.ValidationErrors, err)
+		mv.Errors = append(mv.Errors, err)
+		vd = vd.Append(validateErrorDiagnostics(e))
 	}
+	w.vertexDiagnostics[name] = vd
 
 	return nil
 }
@@ -151,4 +231,16 @@ func (w *ContextGraphWalker) init() {
 	w.providerCache = make(map[string]ResourceProvider, 5)
 	w.provisionerCache = make(map[string]ResourceProvisioner, 5)
 	w.interpolaterVars = make(map[string]map[string]interface{}, 5)
+	w.moduleValidations = make(map[string]*moduleValidation)
+	w.vertexDiagnostics = make(map[string]tfdiags.Diagnostics)
+	w.outputSensitivities = make(map[string]*outputSensitivity)
+	w.providerSchemas = make(map[string]*ProviderSchema)
+}
+
+// moduleValidation is the validation warnings and errors produced by the
+// vertices of a single module.
+type moduleValidation struct {
+	Path     []string
+	Warnings []string
+	Errors   []error
 }
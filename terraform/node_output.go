@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // NodeApplyableOutput represents an output that is "applyable":
@@ -13,6 +14,10 @@ import (
 type NodeApplyableOutput struct {
 	PathValue []string
 	Config    *config.Output // Config is the output in the config
+
+	// DeclRange is where the output was declared in the configuration,
+	// set from GraphNodeAttachSourceRange.
+	DeclRange tfdiags.SourceRange
 }
 
 func (n *NodeApplyableOutput) Name() string {
@@ -29,6 +34,11 @@ func (n *NodeApplyableOutput) Path() []string {
 	return n.PathValue
 }
 
+// GraphNodeAttachSourceRange
+func (n *NodeApplyableOutput) AttachSourceRange(rng tfdiags.SourceRange) {
+	n.DeclRange = rng
+}
+
 // RemovableIfNotTargeted
 func (n *NodeApplyableOutput) RemoveIfNotTargeted() bool {
 	// We need to add this so that this node will be removed if
@@ -90,6 +100,14 @@ func (n *NodeApplyableOutput) EvalTree() EvalNode {
 					Value:     n.Config.RawConfig,
 				},
 			},
+			&EvalOpFilter{
+				Ops: []walkOperation{walkValidate},
+				Node: &EvalValidateOutputSensitivity{
+					Name:      n.Config.Name,
+					Sensitive: n.Config.Sensitive,
+					Value:     n.Config.RawConfig,
+				},
+			},
 		},
 	}
 }
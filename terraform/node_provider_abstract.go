@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // ConcreteProviderNodeFunc is a callback type used to convert an
@@ -23,6 +24,11 @@ type NodeAbstractProvider struct {
 	// set if you already have that information.
 
 	Config *config.ProviderConfig
+
+	// DeclRange is where the provider was configured, set from
+	// GraphNodeAttachSourceRange. It is empty for providers that have no
+	// configuration block.
+	DeclRange tfdiags.SourceRange
 }
 
 func ResolveProviderName(name string, path []string) string {
@@ -83,13 +89,22 @@ func (n *NodeAbstractProvider) AttachProvider(c *config.ProviderConfig) {
 	n.Config = c
 }
 
+// GraphNodeAttachSourceRange
+func (n *NodeAbstractProvider) AttachSourceRange(rng tfdiags.SourceRange) {
+	n.DeclRange = rng
+}
+
 // GraphNodeDotter impl.
 func (n *NodeAbstractProvider) DotNode(name string, opts *dag.DotOpts) *dag.DotNode {
+	attrs := map[string]string{
+		"label": n.Name(),
+		"shape": "diamond",
+	}
+	if n.DeclRange.Filename != "" {
+		attrs["tooltip"] = n.DeclRange.StartString()
+	}
 	return &dag.DotNode{
-		Name: name,
-		Attrs: map[string]string{
-			"label": n.Name(),
-			"shape": "diamond",
-		},
+		Name:  name,
+		Attrs: attrs,
 	}
 }
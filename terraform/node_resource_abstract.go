@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // ConcreteResourceNodeFunc is a callback type used to convert an
@@ -36,6 +37,10 @@ type NodeAbstractResource struct {
 
 	// The address of the provider this resource will use
 	ResolvedProvider string
+
+	// DeclRange is where the resource was declared in the configuration,
+	// set from GraphNodeAttachSourceRange.
+	DeclRange tfdiags.SourceRange
 }
 
 func (n *NodeAbstractResource) Name() string {
@@ -235,13 +240,22 @@ func (n *NodeAbstractResource) AttachResourceConfig(c *config.Resource) {
 	n.Config = c
 }
 
+// GraphNodeAttachSourceRange
+func (n *NodeAbstractResource) AttachSourceRange(rng tfdiags.SourceRange) {
+	n.DeclRange = rng
+}
+
 // GraphNodeDotter impl.
 func (n *NodeAbstractResource) DotNode(name string, opts *dag.DotOpts) *dag.DotNode {
+	attrs := map[string]string{
+		"label": n.Name(),
+		"shape": "box",
+	}
+	if n.DeclRange.Filename != "" {
+		attrs["tooltip"] = n.DeclRange.StartString()
+	}
 	return &dag.DotNode{
-		Name: name,
-		Attrs: map[string]string{
-			"label": n.Name(),
-			"shape": "box",
-		},
+		Name:  name,
+		Attrs: attrs,
 	}
 }
@@ -1,6 +1,10 @@
 package terraform
 
 import (
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
 	"github.com/hashicorp/terraform/dag"
 )
 
@@ -8,6 +12,29 @@ import (
 // only.
 type NodeValidatableResource struct {
 	*NodeAbstractCountResource
+
+	// Destroy is true if the configuration is being validated for a
+	// destroy, in which case instances that exist in the state and have
+	// prevent_destroy set produce errors.
+	Destroy bool
+
+	// Providers, if non-empty, is the provider types whose resources are
+	// being validated. Resources belonging to other providers are not
+	// expanded, and so their instances are not validated.
+	Providers []string
+
+	// Module is the root of the module tree being validated, in which the
+	// configuration of the module containing the resource is found.
+	Module *module.Tree
+
+	// MaxNestingDepth is the nesting depth limit for the configuration of
+	// the resource, as for EvalValidateResource.
+	MaxNestingDepth int
+
+	// ComputedPlaceholders is whether the references to computed
+	// attributes in the configuration of the resource are type-checked,
+	// as for EvalValidateResource.
+	ComputedPlaceholders bool
 }
 
 // GraphNodeEvalable
@@ -15,11 +42,50 @@ func (n *NodeValidatableResource) EvalTree() EvalNode {
 	// Ensure we're validating
 	c := n.NodeAbstractCountResource
 	c.Validate = true
-	return c.EvalTree()
+	nodes := []EvalNode{
+		c.EvalTree(),
+		&EvalValidateDataSourceLifecycle{
+			Resource: n.Config,
+			Addr:     n.Addr,
+		},
+	}
+	if !n.providerSelected() {
+		return &EvalSequence{Nodes: nodes}
+	}
+
+	// Whether count can be set at all depends on the resource type, which
+	// is checked once for the resource rather than for each instance.
+	var provider ResourceProvider
+	var providerName string
+	nodes = append(nodes,
+		&EvalGetProvider{
+			Name:       n.ResolvedProvider,
+			Output:     &provider,
+			Addr:       n.Addr,
+			NameOutput: &providerName,
+		},
+		&EvalValidateSingletonCount{
+			Provider:     &provider,
+			ProviderName: &providerName,
+			Resource:     n.Config,
+			Addr:         n.Addr,
+		},
+		&EvalValidateInstanceLimit{
+			Provider:     &provider,
+			ProviderName: &providerName,
+			Resource:     n.Config,
+			Addr:         n.Addr,
+		},
+	)
+	return &EvalSequence{Nodes: nodes}
 }
 
 // GraphNodeDynamicExpandable
 func (n *NodeValidatableResource) DynamicExpand(ctx EvalContext) (*Graph, error) {
+	if !n.providerSelected() {
+		return nil, nil
+	}
+
 	// Grab the state which we read
 	state, lock := ctx.State()
 	lock.RLock()
@@ -40,9 +106,14 @@ func (n *NodeValidatableResource) DynamicExpand(ctx EvalContext) (*Graph, error)
 		// Add the config and state since we don't do that via transforms
 		a.Config = n.Config
 		a.ResolvedProvider = n.ResolvedProvider
+		a.DeclRange = n.DeclRange
 
 		return &NodeValidatableResourceInstance{
 			NodeAbstractResource: a,
+			Destroy:              n.Destroy,
+			ModuleConfig:         n.moduleConfig(),
+			MaxNestingDepth:      n.MaxNestingDepth,
+			ComputedPlaceholders: n.ComputedPlaceholders,
 		}
 	}
 
@@ -55,6 +126,9 @@ func (n *NodeValidatableResource) DynamicExpand(ctx EvalContext) (*Graph, error)
 			Addr:     n.ResourceAddr(),
 		},
 
+		// Check that the expansion produced distinct instances
+		&DuplicateResourceInstanceTransformer{},
+
 		// Attach the state
 		&AttachStateTransformer{State: state},
 
@@ -81,10 +155,31 @@ func (n *NodeValidatableResource) DynamicExpand(ctx EvalContext) (*Graph, error)
 // This represents a _single_ resource instance to validate.
 type NodeValidatableResourceInstance struct {
 	*NodeAbstractResource
+
+	// Destroy is as for NodeValidatableResource.
+	Destroy bool
+
+	// ModuleConfig is the configuration of the module containing the
+	// resource, against which the references in its provisioners are
+	// checked. If it is nil, they are not checked.
+	ModuleConfig *config.Config
+
+	// MaxNestingDepth and ComputedPlaceholders are as for
+	// NodeValidatableResource.
+	MaxNestingDepth      int
+	ComputedPlaceholders bool
 }
 
 // GraphNodeEvalable
 func (n *NodeValidatableResourceInstance) EvalTree() EvalNode {
+	return &EvalSequence{Nodes: n.EvalNodes()}
+}
+
+// EvalNodes returns the nodes that EvalTree evaluates, in the order they
+// will be evaluated. Building the nodes does not require an EvalContext,
+// so this can be used to inspect the validation steps for a particular
+// configuration without running them.
+func (n *NodeValidatableResourceInstance) EvalNodes() []EvalNode {
 	addr := n.NodeAbstractResource.Addr
 
 	// Build the resource for eval
@@ -101,42 +196,60 @@ func (n *NodeValidatableResourceInstance) EvalTree() EvalNode {
 	// evaluation. Most of this are written to by-address below.
 	var config *ResourceConfig
 	var provider ResourceProvider
+	var providerName string
 
-	seq := &EvalSequence{
-		Nodes: []EvalNode{
-			&EvalValidateResourceSelfRef{
-				Addr:   &addr,
-				Config: &n.Config.RawConfig,
-			},
-			&EvalGetProvider{
-				Name:   n.ResolvedProvider,
-				Output: &provider,
-			},
-			&EvalInterpolate{
-				Config:   n.Config.RawConfig.Copy(),
-				Resource: resource,
-				Output:   &config,
-			},
-			&EvalValidateResource{
-				Provider:     &provider,
-				Config:       &config,
-				ResourceName: n.Config.Name,
-				ResourceType: n.Config.Type,
-				ResourceMode: n.Config.Mode,
-			},
+	nodes := []EvalNode{
+		&EvalValidateResourceSelfRef{
+			Addr:   &addr,
+			Config: &n.Config.RawConfig,
+		},
+		&EvalGetProvider{
+			Name:       n.ResolvedProvider,
+			Output:     &provider,
+			Addr:       addr,
+			NameOutput: &providerName,
+		},
+		&EvalInterpolate{
+			Config:   n.Config.RawConfig.Copy(),
+			Resource: resource,
+			Output:   &config,
+		},
+		&EvalValidateResource{
+			Provider:        &provider,
+			Config:          &config,
+			ResourceName:    n.Config.Name,
+			ResourceType:    n.Config.Type,
+			ResourceMode:    n.Config.Mode,
+			ProviderName:    &providerName,
+			MaxNestingDepth: n.MaxNestingDepth,
+
+			ComputedPlaceholders: n.ComputedPlaceholders,
 		},
 	}
 
+	// A destroy would remove the instance if it exists, which
+	// prevent_destroy forbids.
+	if n.Destroy && n.ResourceState != nil && n.ResourceState.Primary != nil {
+		nodes = append(nodes, &EvalValidatePreventDestroy{
+			Resource: n.Config,
+		})
+	}
+
 	// Validate all the provisioners
 	for _, p := range n.Config.Provisioners {
 		var provisioner ResourceProvisioner
 		var connConfig *ResourceConfig
-		seq.Nodes = append(
-			seq.Nodes,
+		nodes = append(
+			nodes,
 			&EvalGetProvisioner{
 				Name:   p.Type,
 				Output: &provisioner,
 			},
+			&EvalValidateProvisionerReferences{
+				ProvisionerConfig: p,
+				Module:            n.ModuleConfig,
+				ResourceAddr:      addr,
+			},
 			&EvalInterpolate{
 				Config:   p.RawConfig.Copy(),
 				Resource: resource,
@@ -148,12 +261,39 @@ func (n *NodeValidatableResourceInstance) EvalTree() EvalNode {
 				Output:   &connConfig,
 			},
 			&EvalValidateProvisioner{
-				Provisioner: &provisioner,
-				Config:      &config,
-				ConnConfig:  &connConfig,
+				Provisioner:       &provisioner,
+				Config:            &config,
+				ConnConfig:        &connConfig,
+				ProvisionerConfig: p,
+				ResourceAddr:      addr,
 			},
 		)
 	}
 
-	return seq
+	return nodes
+}
+
+// moduleConfig returns the configuration of the module containing the
+// resource, or nil if it isn't known.
+func (n *NodeValidatableResource) moduleConfig() *config.Config {
+	t := n.Module.Child(n.Addr.Path)
+	if t == nil {
+		return nil
+	}
+	return t.Config()
+}
+
+// providerSelected returns whether the resource belongs to one of the
+// provider types in n.Providers, or true if no provider types are given.
+func (n *NodeValidatableResource) providerSelected() bool {
+	if len(n.Providers) == 0 {
+		return true
+	}
+	typeName := strings.SplitN(n.Config.ProviderFullName(), ".", 2)[0]
+	for _, p := range n.Providers {
+		if p == typeName {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,78 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/config"
+)
+
+func TestNodeValidatableResourceInstance_EvalNodes(t *testing.T) {
+	provisioners := []*config.Provisioner{
+		&config.Provisioner{
+			Type:      "shell",
+			RawConfig: config.TestRawConfig(t, map[string]interface{}{}),
+			ConnInfo:  config.TestRawConfig(t, map[string]interface{}{}),
+		},
+	}
+
+	cases := map[string]struct {
+		Provisioners []*config.Provisioner
+		Want         []string
+	}{
+		"no provisioners": {
+			nil,
+			[]string{
+				"*terraform.EvalValidateResourceSelfRef",
+				"*terraform.EvalGetProvider",
+				"*terraform.EvalInterpolate",
+				"*terraform.EvalValidateResource",
+			},
+		},
+		"one provisioner": {
+			provisioners,
+			[]string{
+				"*terraform.EvalValidateResourceSelfRef",
+				"*terraform.EvalGetProvider",
+				"*terraform.EvalInterpolate",
+				"*terraform.EvalValidateResource",
+				"*terraform.EvalGetProvisioner",
+				"*terraform.EvalValidateProvisionerReferences",
+				"*terraform.EvalInterpolate",
+				"*terraform.EvalInterpolate",
+				"*terraform.EvalValidateProvisioner",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			n := &NodeValidatableResourceInstance{
+				NodeAbstractResource: &NodeAbstractResource{
+					Addr: &ResourceAddress{
+						Mode:  config.ManagedResourceMode,
+						Type:  "aws_instance",
+						Name:  "foo",
+						Index: -1,
+					},
+					Config: &config.Resource{
+						Mode:         config.ManagedResourceMode,
+						Name:         "foo",
+						Type:         "aws_instance",
+						RawConfig:    config.TestRawConfig(t, map[string]interface{}{}),
+						Provisioners: tc.Provisioners,
+					},
+				},
+			}
+
+			var got []string
+			for _, node := range n.EvalNodes() {
+				got = append(got, fmt.Sprintf("%T", node))
+			}
+			if !reflect.DeepEqual(got, tc.Want) {
+				t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, tc.Want)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/hashicorp/terraform/version"
 )
 
@@ -87,6 +88,14 @@ type Plan struct {
 // it must be Equal to the state stored in plan, but may have a newer
 // serial.
 func (p *Plan) Context(opts *ContextOpts) (*Context, error) {
+	thisVersion := version.String()
+	if p.TerraformVersion != "" && p.TerraformVersion != thisVersion {
+		return nil, fmt.Errorf(
+			"plan was created with a different version of Terraform (created with %s, but running %s)",
+			p.TerraformVersion, thisVersion,
+		)
+	}
+
 	var err error
 	opts, err = p.contextOpts(opts)
 	if err != nil {
@@ -95,6 +104,47 @@ func (p *Plan) Context(opts *ContextOpts) (*Context, error) {
 	return NewContext(opts)
 }
 
+// ValidatePlan reads a plan in the format written by WritePlan and
+// validates the configuration recorded in it, with the variable values,
+// targets and state that the plan was created with, just as Validate
+// validated the configuration that the plan was created from. The source
+// files of the configuration are not read again.
+//
+// The fields of opts that the plan records are overridden, as for
+// Plan.Context, and opts itself is not modified. Unlike Plan.Context, a plan
+// created by a different version of Terraform can be validated, as long as
+// it was written in the current plan file format.
+func ValidatePlan(src io.Reader, opts *ContextOpts) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	p, err := ReadPlan(src)
+	if err != nil {
+		diags = diags.Append(fmt.Errorf("Failed to read plan: %s", err))
+		return diags
+	}
+	if p.Module == nil {
+		diags = diags.Append(errors.New("The plan does not contain a configuration to validate."))
+		return diags
+	}
+
+	var base ContextOpts
+	if opts != nil {
+		base = *opts
+	}
+	ctxOpts, err := p.contextOpts(&base)
+	if err != nil {
+		diags = diags.Append(err)
+		return diags
+	}
+	ctx, err := NewContext(ctxOpts)
+	if err != nil {
+		diags = diags.Append(err)
+		return diags
+	}
+
+	return diags.Append(ctx.Validate())
+}
+
 // contextOpts mutates the given base ContextOpts in place to use input
 // objects obtained from the receiving plan.
 func (p *Plan) contextOpts(base *ContextOpts) (*ContextOpts, error) {
@@ -120,14 +170,6 @@ func (p *Plan) contextOpts(base *ContextOpts) (*ContextOpts, error) {
 		log.Println("[WARN] Plan state and ContextOpts state are not equal")
 	}
 
-	thisVersion := version.String()
-	if p.TerraformVersion != "" && p.TerraformVersion != thisVersion {
-		return nil, fmt.Errorf(
-			"plan was created with a different version of Terraform (created with %s, but running %s)",
-			p.TerraformVersion, thisVersion,
-		)
-	}
-
 	opts.Variables = make(map[string]interface{})
 	for k, v := range p.Vars {
 		opts.Variables[k] = v
@@ -2,6 +2,7 @@ package terraform
 
 import (
 	"bytes"
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
@@ -167,3 +168,89 @@ func TestPlanContextOptsOverrideStateGood(t *testing.T) {
 		t.Errorf("wrong result\ngot:  %#v\nwant %#v", got, want)
 	}
 }
+
+func TestValidatePlan(t *testing.T) {
+	p := testProvider("aws")
+	p.ValidateResourceFn = func(t string, c *ResourceConfig) ([]string, []error) {
+		if v, _ := c.Get("ami"); v == "bad" {
+			return nil, []error{fmt.Errorf("ami %q does not exist", v)}
+		}
+		return nil, nil
+	}
+	m := testModule(t, "validate-plan")
+	opts := &ContextOpts{
+		ProviderResolver: ResourceProviderResolverFixed(
+			map[string]ResourceProviderFactory{
+				"aws": testProviderFuncFixed(p),
+			},
+		),
+	}
+
+	plan := &Plan{
+		Module: m,
+		Vars: map[string]interface{}{
+			"ami": "bad",
+		},
+		// Plans created by other versions can still be validated.
+		TerraformVersion: "0.1.0",
+	}
+	buf := new(bytes.Buffer)
+	if err := WritePlan(plan, buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	got := ValidatePlan(buf, opts)
+	if !got.HasErrors() {
+		t.Fatal("succeeded; want errors")
+	}
+	if opts.Module != nil || opts.Variables != nil {
+		t.Fatal("opts were modified")
+	}
+
+	// The result must be the same as validating the original configuration.
+	c := testContext2(t, &ContextOpts{
+		Module:           m,
+		ProviderResolver: opts.ProviderResolver,
+		Variables: map[string]interface{}{
+			"ami": "bad",
+		},
+	})
+	want := c.Validate()
+	if got, want := got.Err().Error(), want.Err().Error(); got != want {
+		t.Fatalf("wrong errors\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestValidatePlan_nilOpts(t *testing.T) {
+	plan := &Plan{
+		Module: testModule(t, "validate-plan"),
+		Vars: map[string]interface{}{
+			"ami": "ami-123",
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := WritePlan(plan, buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Without options there are no providers, but that is the only error.
+	diags := ValidatePlan(buf, nil)
+	if !diags.HasErrors() {
+		t.Fatal("succeeded; want error")
+	}
+	want := `provider.aws: unknown provider "aws"`
+	if got := diags.Err().Error(); got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestValidatePlan_badFile(t *testing.T) {
+	diags := ValidatePlan(strings.NewReader("tfplan\x01"), &ContextOpts{})
+	if !diags.HasErrors() {
+		t.Fatal("succeeded; want error")
+	}
+	want := "Failed to read plan: unknown plan file version: 1"
+	if got := diags.Err().Error(); got != want {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform/config/configschema"
+)
+
+// schemaOnlyResourceProvider is a ResourceProvider that validates
+// configuration against a schema given in advance, without starting a
+// provider plugin. It supports validation only: all of the other operations
+// of a provider return errors.
+type schemaOnlyResourceProvider struct {
+	Schema *ProviderSchema
+}
+
+// schemaOnlyResourceProviderFactories returns a factory for each of the
+// providers in the given schemas that produces a schemaOnlyResourceProvider.
+func schemaOnlyResourceProviderFactories(schemas ProviderSchemas) map[string]ResourceProviderFactory {
+	ret := make(map[string]ResourceProviderFactory, len(schemas))
+	for name, schema := range schemas {
+		p := &schemaOnlyResourceProvider{Schema: schema}
+		ret[name] = ResourceProviderFactoryFixed(p)
+	}
+	return ret
+}
+
+var errSchemaOnlyProvider = fmt.Errorf("provider is not available when validating using provider schemas only")
+
+func (p *schemaOnlyResourceProvider) GetSchema(req *ProviderSchemaRequest) (*ProviderSchema, error) {
+	ret := &ProviderSchema{
+		Provider:      p.Schema.Provider,
+		ResourceTypes: make(map[string]*configschema.Block),
+		DataSources:   make(map[string]*configschema.Block),
+	}
+	for _, name := range req.ResourceTypes {
+		if block, ok := p.Schema.ResourceTypes[name]; ok {
+			ret.ResourceTypes[name] = block
+		}
+		if p.Schema.Singletons[name] {
+			if ret.Singletons == nil {
+				ret.Singletons = make(map[string]bool)
+			}
+			ret.Singletons[name] = true
+		}
+		if replacement, ok := p.Schema.DeprecatedResourceTypes[name]; ok {
+			if ret.DeprecatedResourceTypes == nil {
+				ret.DeprecatedResourceTypes = make(map[string]string)
+			}
+			ret.DeprecatedResourceTypes[name] = replacement
+		}
+		if limit, ok := p.Schema.InstanceLimits[name]; ok {
+			if ret.InstanceLimits == nil {
+				ret.InstanceLimits = make(map[string]int)
+			}
+			ret.InstanceLimits[name] = limit
+		}
+	}
+	for _, name := range req.DataSources {
+		if block, ok := p.Schema.DataSources[name]; ok {
+			ret.DataSources[name] = block
+		}
+	}
+	return ret, nil
+}
+
+func (p *schemaOnlyResourceProvider) Input(input UIInput, c *ResourceConfig) (*ResourceConfig, error) {
+	return c, nil
+}
+
+func (p *schemaOnlyResourceProvider) Validate(c *ResourceConfig) ([]string, []error) {
+	if p.Schema.Provider == nil {
+		return nil, nil
+	}
+	errs := schemaConfigErrors(p.Schema.Provider, c.Raw, "")
+	return nil, append(errs, readOnlyAttributeErrors(p.Schema.Provider, c.Raw, "")...)
+}
+
+func (p *schemaOnlyResourceProvider) Configure(c *ResourceConfig) error {
+	return nil
+}
+
+func (p *schemaOnlyResourceProvider) Resources() []ResourceType {
+	ret := make([]ResourceType, 0, len(p.Schema.ResourceTypes))
+	for name := range p.Schema.ResourceTypes {
+		ret = append(ret, ResourceType{Name: name, SchemaAvailable: true})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+func (p *schemaOnlyResourceProvider) Stop() error {
+	return nil
+}
+
+func (p *schemaOnlyResourceProvider) ValidateResource(t string, c *ResourceConfig) ([]string, []error) {
+	block, ok := p.Schema.ResourceTypes[t]
+	if !ok {
+		return nil, []error{fmt.Errorf("no schema is available for the resource type %q", t)}
+	}
+	errs := schemaConfigErrors(block, c.Raw, "")
+	return nil, append(errs, readOnlyAttributeErrors(block, c.Raw, "")...)
+}
+
+func (p *schemaOnlyResourceProvider) Apply(*InstanceInfo, *InstanceState, *InstanceDiff) (*InstanceState, error) {
+	return nil, errSchemaOnlyProvider
+}
+
+func (p *schemaOnlyResourceProvider) Diff(*InstanceInfo, *InstanceState, *ResourceConfig) (*InstanceDiff, error) {
+	return nil, errSchemaOnlyProvider
+}
+
+func (p *schemaOnlyResourceProvider) Refresh(*InstanceInfo, *InstanceState) (*InstanceState, error) {
+	return nil, errSchemaOnlyProvider
+}
+
+func (p *schemaOnlyResourceProvider) ImportState(*InstanceInfo, string) ([]*InstanceState, error) {
+	return nil, errSchemaOnlyProvider
+}
+
+func (p *schemaOnlyResourceProvider) ValidateDataSource(t string, c *ResourceConfig) ([]string, []error) {
+	block, ok := p.Schema.DataSources[t]
+	if !ok {
+		return nil, []error{fmt.Errorf("no schema is available for the data source %q", t)}
+	}
+	errs := schemaConfigErrors(block, c.Raw, "")
+	return nil, append(errs, readOnlyAttributeErrors(block, c.Raw, "")...)
+}
+
+func (p *schemaOnlyResourceProvider) DataSources() []DataSource {
+	ret := make([]DataSource, 0, len(p.Schema.DataSources))
+	for name := range p.Schema.DataSources {
+		ret = append(ret, DataSource{Name: name, SchemaAvailable: true})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+func (p *schemaOnlyResourceProvider) ReadDataDiff(*InstanceInfo, *ResourceConfig) (*InstanceDiff, error) {
+	return nil, errSchemaOnlyProvider
+}
+
+func (p *schemaOnlyResourceProvider) ReadDataApply(*InstanceInfo, *InstanceDiff) (*InstanceState, error) {
+	return nil, errSchemaOnlyProvider
+}
+
+// schemaConfigErrors returns an error for each problem found when checking
+// the given raw configuration of a block against its schema: arguments and
+// blocks that the schema doesn't define, and required arguments that aren't
+// set. Read-only arguments are checked separately by
+// readOnlyAttributeErrors. prefix is prepended to names to give their full paths.
+func schemaConfigErrors(schema *configschema.Block, raw map[string]interface{}, prefix string) []error {
+	var errs []error
+
+	walkSchemaBlocks(schema, raw, prefix, func(schema *configschema.Block, raw map[string]interface{}, prefix string) {
+		for _, name := range sortedRawKeys(raw) {
+			_, isAttr := schema.Attributes[name]
+			_, isBlock := schema.BlockTypes[name]
+			if !isAttr && !isBlock {
+				errs = append(errs, fmt.Errorf("%q: unsupported argument", prefix+name))
+			}
+		}
+
+		names := make([]string, 0, len(schema.Attributes))
+		for name := range schema.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, set := raw[name]; !set && schema.Attributes[name].Required {
+				errs = append(errs, fmt.Errorf("%q: required field is not set", prefix+name))
+			}
+		}
+	})
+
+	return errs
+}
+
+// readOnlyAttributeErrors returns an error for each attribute that is set in
+// the given raw configuration of a block but which the schema marks as
+// computed and not optional, and so can be set only by the provider,
+// recursing into nested blocks. Attributes that are both optional and
+// computed may be set. prefix is prepended to attribute names to give their
+// full paths. Providers built with helper/schema report these themselves.
+func readOnlyAttributeErrors(schema *configschema.Block, raw map[string]interface{}, prefix string) []error {
+	var errs []error
+
+	walkSchemaBlocks(schema, raw, prefix, func(schema *configschema.Block, raw map[string]interface{}, prefix string) {
+		for _, name := range sortedRawKeys(raw) {
+			attr, ok := schema.Attributes[name]
+			if !ok || !attr.Computed || attr.Optional || attr.Required {
+				continue
+			}
+			errs = append(errs, withRule(RuleReadOnlyAttribute, fmt.Errorf(
+				"%q: this attribute is read-only, since its value is computed by the provider; remove it from the configuration",
+				prefix+name)))
+		}
+	})
+
+	return errs
+}
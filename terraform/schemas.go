@@ -1,6 +1,11 @@
 package terraform
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/hashicorp/terraform/config/configschema"
 )
 
@@ -24,6 +29,23 @@ type ProviderSchema struct {
 	Provider      *configschema.Block
 	ResourceTypes map[string]*configschema.Block
 	DataSources   map[string]*configschema.Block
+
+	// Singletons are the names of the resource types that are logically
+	// singletons, such as account-wide settings, and so can't have count
+	// set. Providers that don't mark any resource types leave this nil.
+	Singletons map[string]bool `json:",omitempty"`
+
+	// DeprecatedResourceTypes are the names of the resource types that are
+	// deprecated, each mapped to the name of the resource type that
+	// replaces it, or to the empty string if there's no replacement.
+	// Providers that don't deprecate any resource types leave this nil.
+	DeprecatedResourceTypes map[string]string `json:",omitempty"`
+
+	// InstanceLimits are the maximum numbers of instances that a resource
+	// of each type can practically have, such as because of a quota, by
+	// resource type name. Providers that don't limit any resource types
+	// leave this nil.
+	InstanceLimits map[string]int `json:",omitempty"`
 }
 
 // ProviderSchemaRequest is used to describe to a ResourceProvider which
@@ -32,3 +54,69 @@ type ProviderSchemaRequest struct {
 	ResourceTypes []string
 	DataSources   []string
 }
+
+// providerSchemasFormatVersion is the version of the file format written by
+// WriteProviderSchemas. It must be incremented whenever the format changes
+// incompatibly, so that files written in an older format are rejected
+// rather than misread.
+const providerSchemasFormatVersion = 1
+
+// providerSchemasFile is the JSON structure written by WriteProviderSchemas.
+type providerSchemasFile struct {
+	FormatVersion int             `json:"format_version"`
+	Providers     ProviderSchemas `json:"providers"`
+}
+
+// ReadProviderSchemas reads provider schemas in the JSON form written by
+// WriteProviderSchemas. An error is returned if they were written in a
+// different version of the format, in which case they must be regenerated.
+func ReadProviderSchemas(src io.Reader) (ProviderSchemas, error) {
+	var file providerSchemasFile
+	if err := json.NewDecoder(src).Decode(&file); err != nil {
+		return nil, fmt.Errorf("Decoding provider schemas failed: %s", err)
+	}
+	if file.FormatVersion != providerSchemasFormatVersion {
+		return nil, fmt.Errorf(
+			"Provider schemas have format version %d, but this version of Terraform "+
+				"supports only format version %d. Regenerate them from the provider plugins.",
+			file.FormatVersion, providerSchemasFormatVersion,
+		)
+	}
+	if file.Providers == nil {
+		file.Providers = make(ProviderSchemas)
+	}
+	return file.Providers, nil
+}
+
+// ReadProviderSchemasFile reads provider schemas from the file at the given
+// path, as written by WriteProviderSchemas.
+func ReadProviderSchemasFile(path string) (ProviderSchemas, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadProviderSchemas(f)
+}
+
+// WriteProviderSchemas writes the given provider schemas as JSON, so that
+// they can be read back with ReadProviderSchemas. The output is
+// deterministic and so is suitable for committing to version control as a
+// snapshot, which can be regenerated using Context.ExportProviderSchemas.
+func WriteProviderSchemas(schemas ProviderSchemas, dst io.Writer) error {
+	file := &providerSchemasFile{
+		FormatVersion: providerSchemasFormatVersion,
+		Providers:     schemas,
+	}
+	data, err := json.MarshalIndent(file, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode provider schemas: %s", err)
+	}
+	data = append(data, '\n')
+
+	if _, err := dst.Write(data); err != nil {
+		return fmt.Errorf("Failed to write provider schemas: %v", err)
+	}
+	return nil
+}
@@ -2,11 +2,14 @@ package terraform
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // GraphSemanticChecker is the interface that semantic checks across
@@ -54,26 +57,67 @@ type SemanticChecker interface {
 func smcUserVariables(c *config.Config, vs map[string]interface{}) []error {
 	var errs []error
 
-	cvs := make(map[string]*config.Variable)
-	for _, v := range c.Variables {
-		cvs[v.Name] = v
+	// Check that all required variables are present
+	for _, v := range smcMissingVariables(c, vs) {
+		errs = append(errs, fmt.Errorf(
+			"Required variable not set: %s", v.Name))
 	}
 
-	// Check that all required variables are present
-	required := make(map[string]struct{})
+	errs = append(errs, smcUserVariableTypes(c, vs)...)
+	return errs
+}
+
+// smcMissingVariables returns the required variables of the given
+// configuration that have no value in the given variables, sorted by name.
+func smcMissingVariables(c *config.Config, vs map[string]interface{}) []*config.Variable {
+	var missing []*config.Variable
 	for _, v := range c.Variables {
-		if v.Required() {
-			required[v.Name] = struct{}{}
+		if _, ok := vs[v.Name]; !ok && v.Required() {
+			missing = append(missing, v)
 		}
 	}
-	for k, _ := range vs {
-		delete(required, k)
-	}
-	if len(required) > 0 {
-		for k, _ := range required {
-			errs = append(errs, fmt.Errorf(
-				"Required variable not set: %s", k))
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Name < missing[j].Name })
+	return missing
+}
+
+// smcMissingVariableDiags returns an error diagnostic for each variable
+// returned by smcMissingVariables, with the source range of its declaration.
+// If nonInteractive is set then the diagnostics explain that there will be
+// no prompt for the value.
+func smcMissingVariableDiags(c *config.Config, vs map[string]interface{}, nonInteractive bool) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	for _, v := range smcMissingVariables(c, vs) {
+		detail := fmt.Sprintf("The variable %q has no default value, and no value was given for it.", v.Name)
+		if nonInteractive {
+			detail = fmt.Sprintf(
+				"The variable %q has no default value, and no value was given for it. "+
+					"Terraform is running non-interactively and so can't prompt for a value. "+
+					"Set it using the -var or -var-file options, or the TF_VAR_%s environment variable.",
+				v.Name, v.Name,
+			)
 		}
+
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Required variable not set: %s", v.Name),
+			Detail:   detail,
+		}
+		if v.DeclRange.Filename != "" {
+			diag.Subject = v.DeclRange.ToHCL().Ptr()
+		}
+		diags = diags.Append(diag)
+	}
+	return diags
+}
+
+// smcUserVariableTypes checks that the given variables have the types
+// declared in the configuration.
+func smcUserVariableTypes(c *config.Config, vs map[string]interface{}) []error {
+	var errs []error
+
+	cvs := make(map[string]*config.Variable)
+	for _, v := range c.Variables {
+		cvs[v.Name] = v
 	}
 
 	// Check that types match up
@@ -0,0 +1,6 @@
+provider "foo" {
+  alias   = "bar"
+  version = "<2.0.0"
+}
+
+resource "foo_thing" "test" {}
@@ -0,0 +1,9 @@
+provider "foo" {
+  version = ">=1.0.0"
+}
+
+resource "baz_thing" "test" {}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,5 @@
+resource "aws_instance" "db" {}
+
+output "id" {
+  value = "${aws_instance.db.id}"
+}
@@ -0,0 +1,11 @@
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_instance" "web" {
+  count = 2
+}
+
+module "child" {
+  source = "./child"
+}
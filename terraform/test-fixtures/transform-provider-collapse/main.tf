@@ -0,0 +1,32 @@
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "same"
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "other"
+  region = "us-west-2"
+}
+
+provider "aws" {
+  alias  = "input"
+  region = "us-east-1"
+}
+
+resource "aws_instance" "a" {}
+
+resource "aws_instance" "b" {
+  provider = "aws.same"
+}
+
+resource "aws_instance" "c" {
+  provider = "aws.other"
+}
+
+resource "aws_instance" "d" {
+  provider = "aws.input"
+}
@@ -0,0 +1,15 @@
+variable "ami" {}
+
+resource "aws_instance" "full" {
+  ami           = "${var.ami}"
+  instance_type = "t2.micro"
+  tags          = {}
+}
+
+resource "aws_instance" "partial" {
+  ami = "ami-abc123"
+}
+
+data "aws_ami" "ubuntu" {
+  name = "ubuntu"
+}
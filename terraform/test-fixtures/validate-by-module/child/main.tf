@@ -0,0 +1,7 @@
+resource "aws_instance" "bar" {
+  type = "warn"
+}
+
+module "grandchild" {
+  source = "./grandchild"
+}
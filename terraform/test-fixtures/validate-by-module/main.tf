@@ -0,0 +1,11 @@
+resource "aws_instance" "foo" {
+  type = "bad"
+}
+
+module "child" {
+  source = "./child"
+}
+
+module "other" {
+  source = "./other"
+}
@@ -0,0 +1,13 @@
+resource "aws_instance" "web" {}
+
+resource "aws_instance" "app" {
+  foo = "${aws_instance.web.id}"
+
+  nested {
+    deeper {
+      value = 1
+    }
+  }
+
+  depends_on = ["aws_instance.web"]
+}
@@ -0,0 +1,3 @@
+resource "aws_instance" "app" {
+  foo = "${aws_instance.web.id}"
+}
@@ -0,0 +1,5 @@
+variable "web_id" {}
+
+resource "aws_instance" "leaf" {
+  foo = "${var.web_id}"
+}
@@ -0,0 +1,3 @@
+resource "aws_instance" "db" {
+  foo = "${file("${path.module}/templates/db.tpl")}"
+}
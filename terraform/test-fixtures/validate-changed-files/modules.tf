@@ -0,0 +1,4 @@
+module "child" {
+  source = "./child"
+  web_id = "${aws_instance.web.id}"
+}
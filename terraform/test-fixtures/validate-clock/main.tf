@@ -0,0 +1,6 @@
+resource "aws_instance" "foo" {
+  tags {
+    created = "${timestamp()}"
+    expires = "${timeadd(timestamp(), "24h")}"
+  }
+}
@@ -0,0 +1,13 @@
+resource "aws_instance" "web" {
+  count = 2
+}
+
+resource "aws_instance" "app" {
+  ami             = "${aws_instance.web.0.security_groups}"
+  security_groups = ["${aws_instance.web.*.id}"]
+  tags            = "${aws_instance.web.0.tags}"
+
+  network {
+    name = "${aws_instance.web.*.id}"
+  }
+}
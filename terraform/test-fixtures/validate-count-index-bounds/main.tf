@@ -0,0 +1,23 @@
+resource "aws_instance" "web" {
+  count = 3
+}
+
+resource "aws_instance" "db" {
+  count = "${var.db_count}"
+}
+
+variable "db_count" {
+  default = 2
+}
+
+output "in_bounds" {
+  value = "${aws_instance.web.2.id}"
+}
+
+output "out_of_bounds" {
+  value = "${aws_instance.web.5.id}"
+}
+
+output "unknown_count" {
+  value = "${aws_instance.db.5.id}"
+}
@@ -0,0 +1,19 @@
+resource "aws_instance" "a" {
+  foo = "bar"
+}
+
+locals {
+  zones = "${aws_instance.a.zones}"
+}
+
+resource "aws_instance" "by_id" {
+  count = "${length(aws_instance.a.id)}"
+}
+
+resource "aws_instance" "by_local" {
+  count = "${length(local.zones)}"
+}
+
+resource "aws_instance" "by_config" {
+  count = "${length(aws_instance.a.foo)}"
+}
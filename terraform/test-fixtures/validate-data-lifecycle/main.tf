@@ -0,0 +1,15 @@
+data "aws_ami" "ubuntu" {
+  name = "ubuntu"
+
+  lifecycle {
+    ignore_changes = ["name"]
+  }
+}
+
+resource "aws_instance" "web" {
+  ami = "${data.aws_ami.ubuntu.id}"
+
+  lifecycle {
+    ignore_changes = ["ami"]
+  }
+}
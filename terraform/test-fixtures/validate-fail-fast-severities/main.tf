@@ -0,0 +1,9 @@
+data "aws_ami" "ubuntu" {
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+
+resource "aws_instance" "web" {
+  ami = "${data.aws_ami.ubuntu.id}"
+}
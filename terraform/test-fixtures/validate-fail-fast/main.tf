@@ -0,0 +1,7 @@
+resource "aws_instance" "a" {
+  foo = "bad"
+}
+
+resource "aws_instance" "b" {
+  foo = "${aws_instance.a.id}"
+}
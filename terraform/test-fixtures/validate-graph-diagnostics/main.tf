@@ -0,0 +1,11 @@
+resource "aws_instance" "warn" {
+  warn = "yes"
+}
+
+resource "aws_instance" "fail" {
+  fail = "yes"
+}
+
+resource "aws_instance" "ok" {
+  depends_on = ["aws_instance.warn"]
+}
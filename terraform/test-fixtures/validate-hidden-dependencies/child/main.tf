@@ -0,0 +1,7 @@
+resource "aws_instance" "app" {
+  subnet_id = "subnet-4a1b2c3d"
+}
+
+resource "aws_route_table_association" "app" {
+  subnet_id = "subnet-4a1b2c3d"
+}
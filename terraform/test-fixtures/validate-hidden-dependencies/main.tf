@@ -0,0 +1,68 @@
+resource "aws_instance" "web" {
+  subnet_id = "subnet-0a1b2c3d"
+}
+
+resource "aws_route" "web" {
+  subnet_id = "subnet-0a1b2c3d"
+}
+
+# Resources of the same type are siblings sharing the subnet.
+resource "aws_instance" "a" {
+  subnet_id = "subnet-1a1b2c3d"
+}
+
+resource "aws_instance" "b" {
+  subnet_id = "subnet-1a1b2c3d"
+}
+
+# A value shared by more than two resources is likely to identify an
+# object that isn't managed here.
+resource "aws_instance" "c" {
+  vpc_id = "vpc-0a1b2c3d"
+}
+
+resource "aws_route" "c" {
+  vpc_id = "vpc-0a1b2c3d"
+}
+
+resource "aws_security_group" "c" {
+  vpc_id = "vpc-0a1b2c3d"
+}
+
+# The route already depends on the instance through the network interface.
+resource "aws_instance" "d" {
+  subnet_id = "subnet-2a1b2c3d"
+}
+
+resource "aws_network_interface" "d" {
+  instance = "${aws_instance.d.id}"
+}
+
+resource "aws_route" "d" {
+  subnet_id            = "subnet-2a1b2c3d"
+  network_interface_id = "${aws_network_interface.d.id}"
+}
+
+resource "aws_instance" "e" {
+  subnet_id = "subnet-3a1b2c3d"
+}
+
+resource "aws_route" "e" {
+  subnet_id  = "subnet-3a1b2c3d"
+  depends_on = ["aws_instance.e"]
+}
+
+# Short values and arguments that aren't identifiers are ignored.
+resource "aws_instance" "f" {
+  zone_id = "default"
+  name    = "web-server-1"
+}
+
+resource "aws_route" "f" {
+  zone_id = "default"
+  name    = "web-server-1"
+}
+
+module "child" {
+  source = "./child"
+}
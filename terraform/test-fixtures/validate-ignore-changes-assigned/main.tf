@@ -0,0 +1,24 @@
+resource "aws_instance" "ignored" {
+  ami  = "ami-abc123"
+  tags = {
+    Name = "example"
+  }
+
+  lifecycle {
+    ignore_changes = ["ami", "tags.Name", "user_data"]
+  }
+}
+
+resource "aws_instance" "all" {
+  ami = "ami-abc123"
+
+  lifecycle {
+    ignore_changes = ["*"]
+  }
+}
+
+resource "aws_instance" "unset" {
+  lifecycle {
+    ignore_changes = ["ami"]
+  }
+}
@@ -0,0 +1,13 @@
+terraform {
+  required_providers {
+    aws = "~> 1.0"
+  }
+}
+
+resource "aws_instance" "foo" {}
+
+resource "null_resource" "bar" {}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,23 @@
+variable "replicas" {
+  default = 4
+}
+
+resource "aws_eip" "web" {
+  count = 6
+}
+
+resource "aws_eip" "db" {
+  count = "${var.replicas}"
+}
+
+resource "aws_eip" "lb" {
+  count = 5
+}
+
+resource "aws_eip" "app" {
+  count = "${aws_instance.web.count}"
+}
+
+resource "aws_instance" "web" {
+  count = 10
+}
@@ -0,0 +1,27 @@
+resource "aws_instance" "web" {}
+
+resource "aws_instance" "app" {
+  ami  = "${aws_instance.web.id}"
+  tags = {
+    State = "state-${aws_instance.web.internal_state}"
+  }
+}
+
+resource "aws_instance" "db" {
+  ami = "${aws_instance.web.private_ip}"
+}
+
+resource "aws_instance" "bastion" {
+  provisioner "local-exec" {
+    command = "echo ${aws_instance.web.internal_state}"
+  }
+}
+
+locals {
+  state = "${aws_instance.web.internal_state}"
+  ip    = "${aws_instance.web.private_ip}"
+}
+
+output "state" {
+  value = "${aws_instance.web.internal_state}"
+}
@@ -0,0 +1,7 @@
+output "present" {
+  value = "${file("${path.module}/present.txt")}"
+}
+
+output "missing" {
+  value = "${file("${path.root}/child.txt")}"
+}
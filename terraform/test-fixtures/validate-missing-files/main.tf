@@ -0,0 +1,16 @@
+resource "aws_instance" "web" {
+  user_data = "${file("${path.module}/present.txt")}"
+  ami       = "${file("${path.module}/missing.txt")}"
+}
+
+resource "aws_instance" "db" {
+  user_data = "${file(var.path)}"
+}
+
+variable "path" {
+  default = "missing.txt"
+}
+
+module "child" {
+  source = "./child"
+}
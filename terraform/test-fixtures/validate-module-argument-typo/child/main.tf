@@ -0,0 +1,5 @@
+variable "instance_type" {
+  default = "t2.small"
+}
+
+variable "ami" {}
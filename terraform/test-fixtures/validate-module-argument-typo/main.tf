@@ -0,0 +1,5 @@
+module "child" {
+  source        = "./child"
+  instance_tpye = "t2.micro"
+  ami           = "ami-123"
+}
@@ -0,0 +1,9 @@
+module "a" {
+  source = "./child"
+  in     = "${module.b.out}"
+}
+
+module "b" {
+  source = "./child"
+  in     = "${module.a.out}"
+}
@@ -0,0 +1,7 @@
+module "a" {
+  source = "./a"
+}
+
+module "x" {
+  source = "./x"
+}
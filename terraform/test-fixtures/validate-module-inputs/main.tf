@@ -0,0 +1,11 @@
+variable "ami" {}
+
+variable "zones" {
+  type    = "list"
+  default = []
+}
+
+resource "aws_instance" "web" {
+  ami               = "${var.ami}"
+  availability_zone = "${join(",", var.zones)}"
+}
@@ -0,0 +1,7 @@
+variable "zones" {
+  type = "list"
+}
+
+resource "aws_instance" "web" {
+  availability_zone = "${join(",", var.zones)}"
+}
@@ -0,0 +1,4 @@
+module "child" {
+  source = "./child"
+  zones  = "us-west-2a"
+}
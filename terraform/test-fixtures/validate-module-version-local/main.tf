@@ -0,0 +1,4 @@
+module "child" {
+  source  = "./child"
+  version = "1.0.0"
+}
@@ -0,0 +1,15 @@
+module "network" {
+  source = "./network"
+}
+
+resource "aws_instance" "web" {
+  foo = "${module.network.module.vpc.id}"
+}
+
+output "subnet_id" {
+  value = "${module.network.module.subnets.id}"
+}
+
+output "vpc_id" {
+  value = "${module.network.vpc_id}"
+}
@@ -0,0 +1,11 @@
+module "vpc" {
+  source = "./leaf"
+}
+
+module "subnets" {
+  source = "./leaf"
+}
+
+output "vpc_id" {
+  value = "${module.vpc.id}"
+}
@@ -0,0 +1,9 @@
+variable "id" {}
+
+resource "aws_instance" "app" {
+  foo = "${var.id}"
+}
+
+output "id" {
+  value = "${aws_instance.app.id}"
+}
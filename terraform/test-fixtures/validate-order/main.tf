@@ -0,0 +1,17 @@
+data "aws_ami" "base" {}
+
+resource "aws_instance" "db" {}
+
+resource "aws_instance" "web" {
+  ami = "${data.aws_ami.base.id}"
+}
+
+module "child" {
+  source = "./child"
+  id     = "${aws_instance.db.id}"
+}
+
+resource "aws_instance" "lb" {
+  foo = "${module.child.id}"
+  bar = "${aws_instance.web.id}"
+}
@@ -0,0 +1,7 @@
+resource "aws_instance" "foo" {
+  ami = "ami-abc123"
+}
+
+output "token" {
+  value = "${aws_instance.foo.password}"
+}
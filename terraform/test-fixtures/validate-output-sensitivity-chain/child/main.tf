@@ -0,0 +1,7 @@
+module "inner" {
+  source = "./inner"
+}
+
+output "token" {
+  value = "${module.inner.token}"
+}
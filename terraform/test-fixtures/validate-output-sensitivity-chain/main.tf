@@ -0,0 +1,12 @@
+module "child" {
+  source = "./child"
+}
+
+output "token" {
+  value = "${module.child.token}"
+}
+
+output "token_sensitive" {
+  value     = "${module.child.token}"
+  sensitive = true
+}
@@ -0,0 +1,24 @@
+resource "aws_instance" "foo" {
+  ami = "ami-abc123"
+}
+
+module "child" {
+  source = "./child"
+}
+
+output "password" {
+  value = "${aws_instance.foo.password}"
+}
+
+output "password_sensitive" {
+  value     = "${aws_instance.foo.password}"
+  sensitive = true
+}
+
+output "ami" {
+  value = "${aws_instance.foo.ami}"
+}
+
+output "child_secret" {
+  value = "${module.child.secret}"
+}
@@ -0,0 +1,5 @@
+variable "ami" {}
+
+resource "aws_instance" "foo" {
+  ami = "${var.ami}"
+}
@@ -0,0 +1,5 @@
+resource "aws_instance" "baz" {
+  lifecycle {
+    prevent_destroy = true
+  }
+}
@@ -0,0 +1,18 @@
+resource "aws_instance" "foo" {
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+
+resource "aws_instance" "bar" {
+}
+
+resource "aws_instance" "new" {
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,20 @@
+provider "aws" {
+  alias = "aws"
+}
+
+provider "aws" {
+  region = "us-west-2"
+}
+
+provider "aws" {
+  alias  = "east"
+  region = "us-east-1"
+}
+
+resource "aws_instance" "web" {
+  provider = "aws.west"
+}
+
+resource "aws_instance" "db" {
+  provider = "aws.east"
+}
@@ -0,0 +1,18 @@
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+resource "aws_instance" "foo" {
+  provider = "aws.west"
+}
+
+resource "null_resource" "bar" {}
+
+module "child" {
+  source = "./child"
+}
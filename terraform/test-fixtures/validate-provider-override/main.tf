@@ -0,0 +1,16 @@
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+resource "aws_instance" "foo" {
+  ami = "ami-abc123"
+}
+
+resource "aws_instance" "bar" {
+  ami = "ami-abc123"
+}
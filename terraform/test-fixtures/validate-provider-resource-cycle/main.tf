@@ -0,0 +1,10 @@
+provider "aws" {
+  foo = "${aws_instance.web.id}"
+}
+
+provider "aws" {
+  alias = "west"
+  foo   = "${aws_instance.web.id}"
+}
+
+resource "aws_instance" "web" {}
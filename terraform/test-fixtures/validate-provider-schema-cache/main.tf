@@ -0,0 +1,5 @@
+resource "aws_instance" "web" {
+  count = 3
+}
+
+resource "aws_instance" "db" {}
@@ -0,0 +1,7 @@
+resource "aws_instance" "web" {}
+
+resource "aws_instance" "db" {}
+
+data "aws_data_source" "ami" {}
+
+resource "do_droplet" "web" {}
@@ -0,0 +1,15 @@
+variable "name" {}
+
+resource "aws_instance" "web" {
+  foo = "bar"
+
+  provisioner "local-exec" {
+    command = "echo ${var.name}"
+
+    environment {
+      ADDR = "${self.nope}"
+      NAME = "${local.missing}"
+      FOO  = "${self.foo}"
+    }
+  }
+}
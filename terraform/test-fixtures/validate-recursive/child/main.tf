@@ -0,0 +1,14 @@
+variable "ami" {}
+
+variable "type" {
+  default = "bad"
+}
+
+resource "aws_instance" "web" {
+  ami  = "${var.ami}"
+  type = "${var.type}"
+}
+
+module "grandchild" {
+  source = "./grandchild"
+}
@@ -0,0 +1,5 @@
+module "child" {
+  source = "./child"
+  ami    = "ami-123"
+  type   = "good"
+}
@@ -0,0 +1,6 @@
+resource "aws_instance" "a" {}
+
+resource "aws_instance" "b" {
+  foo        = "${aws_instance.a.id}"
+  depends_on = ["aws_instance.a"]
+}
@@ -0,0 +1,12 @@
+resource "aws_instance" "web" {}
+
+resource "aws_instance" "db" {}
+
+resource "aws_instance" "app" {
+  foo        = "${aws_instance.web.id}"
+  depends_on = ["aws_instance.web", "aws_instance.db"]
+}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,20 @@
+variable "region" {}
+
+provider "aws" {
+  region = "${var.region}"
+}
+
+resource "aws_instance" "db" {}
+
+resource "aws_instance" "web" {
+  foo = "${aws_instance.db.id}"
+}
+
+module "child" {
+  source = "./child"
+  id     = "${aws_instance.web.id}"
+}
+
+output "id" {
+  value = "${module.child.id}"
+}
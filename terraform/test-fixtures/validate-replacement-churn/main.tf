@@ -0,0 +1,13 @@
+resource "aws_instance" "volatile" {
+  ami  = "ami-${uuid()}"
+  name = "web-${timestamp()}"
+}
+
+resource "aws_instance" "stable" {
+  ami  = "ami-12345"
+  name = "web-${timestamp()}"
+}
+
+resource "aws_instance" "unknown" {
+  ami = "${aws_instance.stable.id}"
+}
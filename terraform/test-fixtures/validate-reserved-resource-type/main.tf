@@ -0,0 +1,3 @@
+resource "var" "web" {}
+
+resource "aws_instance" "var" {}
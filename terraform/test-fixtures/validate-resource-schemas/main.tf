@@ -0,0 +1,15 @@
+provider "aws" {
+  alias = "west"
+}
+
+resource "aws_instance" "foo" {
+  count    = 2
+  provider = "aws.west"
+}
+
+data "aws_ami" "ami" {
+}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,15 @@
+provider "google" {
+  alias = "west"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-abc123"
+}
+
+resource "google_compute_instance" "app" {}
+
+resource "google_compute_instance" "west" {
+  provider = "google.west"
+}
+
+resource "azurerm_virtual_machine" "untargeted" {}
@@ -0,0 +1,8 @@
+resource "aws_instance" "foo" {
+  ami   = "ami-abc123"
+  bogus = "yes"
+
+  ebs_block_device {
+    size = 10
+  }
+}
@@ -0,0 +1,13 @@
+locals {
+  name   = "${local.prefix}-x"
+  prefix = "${aws_instance.foo.id}"
+  other  = "${aws_instance.bar.id}"
+}
+
+resource "aws_instance" "foo" {
+  name = "${local.name}"
+  tags = "${local.other}"
+}
+
+resource "aws_instance" "bar" {
+}
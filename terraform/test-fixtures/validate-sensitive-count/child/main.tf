@@ -0,0 +1,8 @@
+output "secret_count" {
+  value     = "2"
+  sensitive = true
+}
+
+output "public_count" {
+  value = "2"
+}
@@ -0,0 +1,11 @@
+module "child" {
+  source = "./child"
+}
+
+resource "aws_instance" "web" {
+  count = "${module.child.secret_count}"
+}
+
+resource "aws_instance" "db" {
+  count = "${module.child.public_count}"
+}
@@ -0,0 +1,8 @@
+output "password" {
+  value     = "secret"
+  sensitive = true
+}
+
+output "ami" {
+  value = "ami-123"
+}
@@ -0,0 +1,13 @@
+module "child" {
+  source = "./child"
+}
+
+resource "aws_instance" "foo" {
+  name     = "web-${module.child.password}"
+  password = "${module.child.password}"
+  ami      = "${module.child.ami}"
+}
+
+resource "aws_instance" "bar" {
+  description = "${module.child.password}"
+}
@@ -0,0 +1,9 @@
+resource "aws_account_settings" "main" {
+  count = 2
+}
+
+resource "aws_account_settings" "other" {}
+
+resource "aws_instance" "web" {
+  count = 2
+}
@@ -0,0 +1,7 @@
+provider "aws" {}
+
+resource "aws_instance" "web" {
+  count = 3
+}
+
+resource "aws_instance" "db" {}
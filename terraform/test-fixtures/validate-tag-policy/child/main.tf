@@ -0,0 +1,7 @@
+resource "aws_instance" "web" {
+  tags = {
+    owner = "web"
+  }
+}
+
+resource "aws_eip" "untaggable" {}
@@ -0,0 +1,33 @@
+variable "tags" {
+  type = "map"
+}
+
+resource "aws_instance" "compliant" {
+  tags = {
+    owner = "platform"
+    env   = "prod"
+  }
+}
+
+resource "aws_instance" "missing" {
+  tags = {
+    env = "staging"
+  }
+}
+
+resource "aws_instance" "untagged" {}
+
+resource "aws_instance" "interpolated" {
+  tags = "${var.tags}"
+}
+
+resource "aws_instance" "interpolated_value" {
+  tags = {
+    owner = "platform"
+    env   = "${var.tags["env"]}"
+  }
+}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,7 @@
+resource "aws_instance" "child" {
+  ami = "${var.ami != "" ? var.ami : "ami-789"}"
+}
+
+variable "ami" {
+  default = ""
+}
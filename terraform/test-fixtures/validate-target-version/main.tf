@@ -0,0 +1,27 @@
+terraform {
+  required_version = ">= 0.8.0"
+}
+
+provider "aws" {
+  version = "~> 1.0"
+}
+
+locals {
+  name = "web"
+}
+
+resource "aws_instance" "web" {
+  ami = "${terraform.workspace == "default" ? "ami-123" : "ami-456"}"
+
+  provisioner "local-exec" {
+    when    = "destroy"
+    command = "echo destroyed"
+  }
+}
+
+data "aws_ami" "ubuntu" {
+}
+
+module "child" {
+  source = "./child"
+}
@@ -0,0 +1,7 @@
+terraform {
+  requried_version = ">= 0.10.0"
+
+  backend "local" {}
+}
+
+resource "aws_instance" "foo" {}
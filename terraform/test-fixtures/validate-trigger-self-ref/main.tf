@@ -0,0 +1,14 @@
+resource "aws_instance" "web" {}
+
+resource "null_resource" "ok" {
+  triggers = {
+    instance = "${aws_instance.web.id}"
+  }
+}
+
+resource "null_resource" "self" {
+  triggers = {
+    instance = "${aws_instance.web.id}"
+    previous = "${null_resource.self.id}"
+  }
+}
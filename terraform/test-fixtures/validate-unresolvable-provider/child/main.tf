@@ -0,0 +1,11 @@
+resource "aws_instance" "north" {
+  provider = "aws.north"
+}
+
+resource "aws_instance" "inherited" {
+  provider = "aws.east"
+}
+
+resource "aws_instance" "south" {
+  provider = "aws.south"
+}
@@ -0,0 +1,22 @@
+provider "aws" {
+  alias = "east"
+}
+
+resource "aws_instance" "east" {
+  provider = "aws.east"
+}
+
+resource "aws_instance" "west" {
+  provider = "aws.west"
+}
+
+resource "aws_instance" "default" {
+}
+
+module "child" {
+  source = "./child"
+
+  providers = {
+    "aws.north" = "aws.east"
+  }
+}
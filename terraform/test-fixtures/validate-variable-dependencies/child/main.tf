@@ -0,0 +1,9 @@
+variable "size" {}
+
+resource "aws_instance" "app" {
+  instance_type = "${var.size}"
+}
+
+output "id" {
+  value = "${aws_instance.app.id}"
+}
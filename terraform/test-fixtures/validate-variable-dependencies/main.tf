@@ -0,0 +1,27 @@
+variable "ami" {}
+
+variable "prefix" {}
+
+variable "size" {}
+
+variable "unused" {}
+
+locals {
+  name = "${var.prefix}-web"
+}
+
+resource "aws_instance" "web" {
+  ami  = "${var.ami}"
+  name = "${local.name}"
+}
+
+module "child" {
+  source = "./child"
+  size   = "${var.size}"
+}
+
+resource "aws_instance" "lb" {
+  foo = "${module.child.id}"
+}
+
+resource "aws_instance" "none" {}
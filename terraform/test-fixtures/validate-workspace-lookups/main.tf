@@ -0,0 +1,24 @@
+variable "amis" {
+  default = {
+    default = "ami-default"
+    staging = "ami-staging"
+  }
+}
+
+locals {
+  instance_types = {
+    default = "t2.micro"
+  }
+}
+
+resource "aws_instance" "web" {
+  ami = "${lookup(var.amis, terraform.workspace)}"
+}
+
+resource "aws_instance" "app" {
+  instance_type = "${local.instance_types[terraform.workspace]}"
+}
+
+resource "aws_instance" "db" {
+  ami = "${lookup(var.amis, terraform.workspace, "ami-fallback")}"
+}
@@ -0,0 +1,3 @@
+data "aws_ami" "dead" {
+  count = "0"
+}
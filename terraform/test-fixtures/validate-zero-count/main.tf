@@ -0,0 +1,21 @@
+variable "enabled" {
+  default = 0
+}
+
+resource "aws_instance" "dead" {
+  count = 0
+}
+
+resource "aws_instance" "toggled" {
+  count = "${var.enabled}"
+}
+
+resource "aws_instance" "single" {
+  count = 1
+}
+
+resource "aws_instance" "default" {}
+
+module "child" {
+  source = "./child"
+}
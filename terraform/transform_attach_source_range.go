@@ -0,0 +1,125 @@
+package terraform
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/config/module"
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// GraphNodeAttachSourceRange is an interface that can be implemented by
+// nodes that want the position where their configuration was declared
+// attached.
+type GraphNodeAttachSourceRange interface {
+	AttachSourceRange(tfdiags.SourceRange)
+}
+
+// AttachSourceRangeTransformer goes through the graph and attaches the
+// declaration range from the configuration to each resource, provider and
+// output node that implements GraphNodeAttachSourceRange.
+//
+// This is metadata only, for use in diagnostics and graph output, and so
+// it adds no edges. Nodes whose configuration can't be found, such as
+// orphans and synthetic nodes like the root, are left with an empty range.
+type AttachSourceRangeTransformer struct {
+	Module *module.Tree // Module is the root module for the config
+}
+
+func (t *AttachSourceRangeTransformer) Transform(g *Graph) error {
+	for _, v := range g.Vertices() {
+		an, ok := v.(GraphNodeAttachSourceRange)
+		if !ok {
+			continue
+		}
+
+		var rng tfdiags.SourceRange
+		var found bool
+		switch tv := v.(type) {
+		case GraphNodeResource:
+			rng, found = t.resourceRange(tv.ResourceAddr())
+		case GraphNodeProvider:
+			rng, found = t.providerRange(tv)
+		case *NodeApplyableOutput:
+			rng, found = t.outputRange(tv)
+		}
+		if !found {
+			continue
+		}
+
+		log.Printf("[TRACE] AttachSourceRangeTransformer: %s declared at %s", dag.VertexName(v), rng.StartString())
+		an.AttachSourceRange(rng)
+	}
+
+	return nil
+}
+
+func (t *AttachSourceRangeTransformer) moduleConfig(path []string) *config.Config {
+	tree := t.Module.Child(normalizeModulePath(path)[1:])
+	if tree == nil {
+		return nil
+	}
+	return tree.Config()
+}
+
+func (t *AttachSourceRangeTransformer) resourceRange(addr *ResourceAddress) (tfdiags.SourceRange, bool) {
+	if addr == nil {
+		return tfdiags.SourceRange{}, false
+	}
+	cfg := t.moduleConfig(addr.Path)
+	if cfg == nil {
+		return tfdiags.SourceRange{}, false
+	}
+
+	for _, r := range cfg.Resources {
+		a, err := parseResourceAddressConfig(r)
+		if err != nil {
+			panic(fmt.Sprintf(
+				"Error parsing config address, this is a bug: %#v", r))
+		}
+		a.Path = addr.Path
+		a.Index = addr.Index
+
+		if a.Equals(addr) {
+			return r.DeclRange, true
+		}
+	}
+	return tfdiags.SourceRange{}, false
+}
+
+func (t *AttachSourceRangeTransformer) providerRange(pv GraphNodeProvider) (tfdiags.SourceRange, bool) {
+	var path []string
+	if sp, ok := pv.(GraphNodeSubPath); ok {
+		path = sp.Path()
+	}
+	cfg := t.moduleConfig(path)
+	if cfg == nil {
+		return tfdiags.SourceRange{}, false
+	}
+
+	for _, pc := range cfg.ProviderConfigs {
+		if pc.FullName() == pv.ProviderName() {
+			return pc.DeclRange, true
+		}
+	}
+	return tfdiags.SourceRange{}, false
+}
+
+func (t *AttachSourceRangeTransformer) outputRange(n *NodeApplyableOutput) (tfdiags.SourceRange, bool) {
+	if n.Config == nil {
+		return tfdiags.SourceRange{}, false
+	}
+	cfg := t.moduleConfig(n.PathValue)
+	if cfg == nil {
+		return tfdiags.SourceRange{}, false
+	}
+
+	for _, o := range cfg.Outputs {
+		if o.Name == n.Config.Name {
+			return o.DeclRange, true
+		}
+	}
+	return tfdiags.SourceRange{}, false
+}
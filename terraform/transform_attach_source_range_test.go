@@ -0,0 +1,58 @@
+package terraform
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestAttachSourceRangeTransformer(t *testing.T) {
+	b := &PlanGraphBuilder{
+		Module:    testModule(t, "transform-attach-source-range"),
+		Providers: []string{"aws"},
+	}
+
+	g, err := b.Build(RootModulePath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var got []string
+	for _, v := range g.Vertices() {
+		var rng tfdiags.SourceRange
+		switch tv := v.(type) {
+		case *NodePlannableResource:
+			rng = tv.DeclRange
+		case *NodeApplyableProvider:
+			rng = tv.DeclRange
+		case *NodeApplyableOutput:
+			rng = tv.DeclRange
+		default:
+			continue
+		}
+
+		pos := "-"
+		if rng.Filename != "" {
+			// Child modules are loaded from a copy in the module storage
+			// directory, so we can only compare the base name.
+			pos = fmt.Sprintf("%s:%d", filepath.Base(rng.Filename), rng.Start.Line)
+		}
+		got = append(got, fmt.Sprintf("%s %s", dag.VertexName(v), pos))
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"aws_instance.web main.tf:5",
+		"module.child.aws_instance.db main.tf:1",
+		"module.child.output.id main.tf:3",
+		"provider.aws main.tf:1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
@@ -12,8 +12,12 @@ import (
 	"github.com/hashicorp/terraform/dag"
 )
 
-func TransformProviders(providers []string, concrete ConcreteProviderNodeFunc, mod *module.Tree) GraphTransformer {
-	return GraphTransformMulti(
+// TransformProviders returns a GraphTransformer that adds the providers to
+// the graph and connects them to the resources that use them. Any consumers
+// given run once the providers have been connected, and before the unused
+// providers are pruned, so that they can connect other nodes to providers.
+func TransformProviders(providers []string, concrete ConcreteProviderNodeFunc, mod *module.Tree, consumers ...GraphTransformer) GraphTransformer {
+	steps := []GraphTransformer{
 		// Add providers from the config
 		&ProviderConfigTransformer{
 			Module:    mod,
@@ -27,11 +31,19 @@ func TransformProviders(providers []string, concrete ConcreteProviderNodeFunc, m
 		},
 		// Connect the providers
 		&ProviderTransformer{},
+	}
+	for _, t := range consumers {
+		if t != nil {
+			steps = append(steps, t)
+		}
+	}
+	steps = append(steps,
 		// Remove unused providers and proxies
 		&PruneProviderTransformer{},
 		// Connect provider to their parent provider nodes
 		&ParentProviderTransformer{},
 	)
+	return GraphTransformMulti(steps...)
 }
 
 // GraphNodeProvider is an interface that nodes that can be a provider
@@ -0,0 +1,134 @@
+package terraform
+
+import (
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/dag"
+)
+
+// CollapseEquivalentProviderTransformer is a GraphTransformer that finds
+// provider nodes whose configurations are identical and makes all of the
+// consumers of the duplicates depend on a single one of them, removing the
+// others. The consumers keep their resolved providers, so each resource
+// still belongs to the same provider configuration: only the plugin
+// instance is shared, as recorded in Shared.
+//
+// This is an optimization for the validate walk only, where a separate
+// plugin instance would otherwise be started for each provider
+// configuration even though they would all validate in exactly the same
+// way. It must run after ProviderTransformer has resolved providers and
+// before CloseProviderTransformer adds the close nodes.
+//
+// Two provider nodes are only considered equivalent if they are in the same
+// module, are for the same provider type and version constraint, have no
+// interpolations in their configuration, have identical configuration
+// values (ignoring the alias), and have no input values recorded for them
+// in ProviderInput.
+type CollapseEquivalentProviderTransformer struct {
+	// ProviderInput is the provider configuration gathered by the input walk,
+	// keyed as for EvalContext.SetProviderInput. Any provider that has input
+	// is never collapsed, since its effective configuration is not
+	// determined by its configuration block alone.
+	ProviderInput map[string]map[string]interface{}
+
+	// Shared, if non-nil, records the name of each provider node that was
+	// removed, mapped to the name of the provider node whose instance its
+	// consumers use instead, as for BuiltinEvalContext.SharedProviders.
+	Shared map[string]string
+}
+
+func (t *CollapseEquivalentProviderTransformer) Transform(g *Graph) error {
+	// Group the candidates by module path and provider type, sorting by name
+	// so that the shared node chosen for each set is predictable.
+	groups := make(map[string][]*NodeApplyableProvider)
+	var keys []string
+	for _, v := range g.Vertices() {
+		pv, ok := v.(*NodeApplyableProvider)
+		if !ok || !t.collapsible(pv) {
+			continue
+		}
+
+		typeName := strings.SplitN(pv.NameValue, ".", 2)[0]
+		key := PathCacheKey(normalizeModulePath(pv.PathValue)) + "|" + typeName
+		if _, exists := groups[key]; !exists {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], pv)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		group := groups[key]
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Name() < group[j].Name()
+		})
+
+		var shared []*NodeApplyableProvider
+	GROUP:
+		for _, pv := range group {
+			for _, target := range shared {
+				if providerConfigsEquivalent(pv, target) {
+					t.collapse(g, pv, target)
+					continue GROUP
+				}
+			}
+			shared = append(shared, pv)
+		}
+	}
+
+	return nil
+}
+
+// collapsible returns true if the given provider could possibly be collapsed
+// with another.
+func (t *CollapseEquivalentProviderTransformer) collapsible(pv *NodeApplyableProvider) bool {
+	cfg := pv.ProviderConfig()
+	if cfg == nil || cfg.RawConfig == nil || cfg.RawConfig.Body != nil {
+		return false
+	}
+	if len(cfg.RawConfig.Variables) > 0 {
+		return false
+	}
+
+	// Same lookup as BuiltinEvalContext.ProviderInput, which walks up the
+	// module tree from the provider's own module.
+	path := normalizeModulePath(pv.PathValue)
+	for i := len(path) - 1; i >= 0; i-- {
+		k := make([]string, i+2)
+		copy(k, path[:i+1])
+		k[i+1] = pv.NameValue
+		if _, ok := t.ProviderInput[PathCacheKey(k)]; ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// collapse makes everything that depends on the provider dup depend on the
+// provider target instead, records that dup shares the instance of target,
+// and then removes dup from the graph.
+func (t *CollapseEquivalentProviderTransformer) collapse(g *Graph, dup, target *NodeApplyableProvider) {
+	log.Printf("[DEBUG] provider %s has the same configuration as %s; validating with %s", dup.Name(), target.Name(), target.Name())
+
+	for _, raw := range g.UpEdges(dup).List() {
+		g.Connect(dag.BasicEdge(raw.(dag.Vertex), target))
+	}
+	if t.Shared != nil {
+		t.Shared[dup.Name()] = target.Name()
+	}
+	g.Remove(dup)
+}
+
+// providerConfigsEquivalent returns true if the two given providers, which
+// must both be collapsible, will validate identically.
+func providerConfigsEquivalent(a, b *NodeApplyableProvider) bool {
+	ac, bc := a.ProviderConfig(), b.ProviderConfig()
+	if ac.Version != bc.Version {
+		return false
+	}
+	return reflect.DeepEqual(ac.RawConfig.Raw, bc.RawConfig.Raw)
+}
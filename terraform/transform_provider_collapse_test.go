@@ -0,0 +1,72 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/dag"
+)
+
+func TestCollapseEquivalentProviderTransformer(t *testing.T) {
+	shared := make(map[string]string)
+	b := &PlanGraphBuilder{
+		Module:            testModule(t, "transform-provider-collapse"),
+		Providers:         []string{"aws"},
+		CollapseProviders: true,
+		ProviderInput: map[string]map[string]interface{}{
+			"root|aws.input": map[string]interface{}{"region": "us-east-1"},
+		},
+		SharedProviders: shared,
+	}
+
+	g, err := b.Build(RootModulePath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	testGraphContains(t, g, "provider.aws")
+	testGraphNotContains(t, g, "provider.aws.same")
+	testGraphContains(t, g, "provider.aws.other")
+	testGraphContains(t, g, "provider.aws.input")
+
+	wantShared := map[string]string{"provider.aws.same": "provider.aws"}
+	if !reflect.DeepEqual(shared, wantShared) {
+		t.Errorf("wrong shared providers %#v; want %#v", shared, wantShared)
+	}
+
+	// Resources keep their own provider configurations.
+	expected := map[string]string{
+		"aws_instance.a": "provider.aws",
+		"aws_instance.b": "provider.aws.same",
+		"aws_instance.c": "provider.aws.other",
+		"aws_instance.d": "provider.aws.input",
+	}
+	for _, v := range g.Vertices() {
+		rn, ok := v.(*NodePlannableResource)
+		if !ok {
+			continue
+		}
+		name := dag.VertexName(v)
+		if rn.ResolvedProvider != expected[name] {
+			t.Errorf("%s has provider %q; want %q", name, rn.ResolvedProvider, expected[name])
+		}
+		delete(expected, name)
+	}
+	for name := range expected {
+		t.Errorf("%s not in graph", name)
+	}
+}
+
+func TestCollapseEquivalentProviderTransformer_disabled(t *testing.T) {
+	b := &PlanGraphBuilder{
+		Module:    testModule(t, "transform-provider-collapse"),
+		Providers: []string{"aws"},
+	}
+
+	g, err := b.Build(RootModulePath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	testGraphContains(t, g, "provider.aws.same")
+}
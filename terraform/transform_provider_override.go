@@ -0,0 +1,100 @@
+package terraform
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/dag"
+)
+
+// ProviderOverrideTransformer is a GraphTransformer that connects each
+// resource whose provider is overridden to the provider configuration that
+// overrides it, so that the provider is initialized before the resource is
+// evaluated and isn't pruned from the graph. The override itself is applied
+// during evaluation, by EvalContext.ProviderOverride.
+//
+// It must run after ProviderTransformer and before PruneProviderTransformer,
+// which TransformProviders arranges. An override to a provider configuration
+// that isn't in the graph is an error.
+type ProviderOverrideTransformer struct {
+	// Overrides are the names of the provider configurations, such as
+	// "aws.west", keyed by the addresses of the resources that they
+	// override the providers of. Names are resolved in the module of the
+	// resource, unless they are already resolved, such as "provider.aws".
+	Overrides map[string]string
+}
+
+func (t *ProviderOverrideTransformer) Transform(g *Graph) error {
+	var err error
+	pm := providerVertexMap(g)
+
+	keys := make([]string, 0, len(t.Overrides))
+	for k := range t.Overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		oAddr, parseErr := ParseResourceAddress(k)
+		if parseErr != nil || !oAddr.HasResourceSpec() {
+			err = multierror.Append(err, fmt.Errorf(
+				"invalid resource address %q in provider overrides", k))
+			continue
+		}
+
+		for _, v := range g.Vertices() {
+			rn, ok := v.(GraphNodeResource)
+			if !ok {
+				continue
+			}
+			if _, ok := v.(GraphNodeProviderConsumer); !ok {
+				continue
+			}
+			addr := rn.ResourceAddr()
+			if addr == nil || !oAddr.Equals(addr) {
+				continue
+			}
+
+			name := ResolveProviderName(t.Overrides[k], addr.Path)
+			target, ok := pm[name]
+			if !ok {
+				err = multierror.Append(err, fmt.Errorf(
+					"%s: can't be validated with provider %s, which is not in the configuration",
+					dag.VertexName(v), name))
+				continue
+			}
+			if proxy, ok := target.(*graphNodeProxyProvider); ok {
+				err = multierror.Append(err, fmt.Errorf(
+					"%s: can't be validated with provider %s, which is passed to the module; use %s instead",
+					dag.VertexName(v), name, proxy.Target().Name()))
+				continue
+			}
+
+			log.Printf("[DEBUG] %s will be validated with provider %s", dag.VertexName(v), name)
+			g.Connect(dag.BasicEdge(v, target))
+		}
+	}
+
+	return err
+}
+
+// providerOverride returns the resolved name of the provider configuration
+// that the given overrides, as for ProviderOverrideTransformer, give for the
+// resource instance at addr, or an empty string if they don't override its
+// provider. An override for the instance takes precedence over one for the
+// whole resource.
+func providerOverride(overrides map[string]string, addr *ResourceAddress) string {
+	var name string
+	for k, v := range overrides {
+		oAddr, err := ParseResourceAddress(k)
+		if err != nil || !oAddr.HasResourceSpec() || !oAddr.Equals(addr) {
+			continue
+		}
+		if oAddr.Index != -1 || name == "" {
+			name = ResolveProviderName(v, addr.Path)
+		}
+	}
+	return name
+}
@@ -0,0 +1,73 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// DuplicateResourceInstanceTransformer is a GraphTransformer that checks
+// that no two resource instances being validated have the same instance
+// address. It doesn't modify the graph.
+//
+// Such duplicates can only be produced by a bug in the expansion of
+// resources, and so this is a safety net that must run after the
+// transformers that expand resources into their instances, such as
+// ResourceCountTransformer.
+type DuplicateResourceInstanceTransformer struct{}
+
+func (t *DuplicateResourceInstanceTransformer) Transform(g *Graph) error {
+	origins := make(map[string][]string)
+	for _, v := range g.Vertices() {
+		n, ok := v.(*NodeValidatableResourceInstance)
+		if !ok {
+			continue
+		}
+
+		key := n.Addr.String()
+		origins[key] = append(origins[key], duplicateInstanceOrigin(n))
+	}
+
+	keys := make([]string, 0, len(origins))
+	for key := range origins {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var err error
+	for _, key := range keys {
+		if len(origins[key]) < 2 {
+			continue
+		}
+
+		sort.Strings(origins[key])
+		for _, origin := range origins[key][1:] {
+			err = multierror.Append(err, fmt.Errorf(
+				"duplicate resource instance address %s, produced by both %s and %s",
+				key, origins[key][0], origin,
+			))
+		}
+	}
+	return err
+}
+
+// duplicateInstanceOrigin describes the configuration that the given
+// resource instance was produced from, for error messages.
+func duplicateInstanceOrigin(n *NodeValidatableResourceInstance) string {
+	if n.Config == nil {
+		return "an unknown configuration"
+	}
+
+	addr := &ResourceAddress{
+		Path:  n.Addr.Path,
+		Mode:  n.Config.Mode,
+		Type:  n.Config.Type,
+		Name:  n.Config.Name,
+		Index: -1,
+	}
+	if n.DeclRange.Filename == "" {
+		return addr.String()
+	}
+	return fmt.Sprintf("%s (declared at %s)", addr, n.DeclRange.StartString())
+}
@@ -0,0 +1,95 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+func TestDuplicateResourceInstanceTransformer(t *testing.T) {
+	g := Graph{Path: RootModulePath}
+	concrete := func(a *NodeAbstractResource) *NodeValidatableResourceInstance {
+		return &NodeValidatableResourceInstance{NodeAbstractResource: a}
+	}
+	addr := func(name string, index int) *ResourceAddress {
+		return &ResourceAddress{
+			Path:         []string{"child"},
+			Mode:         config.ManagedResourceMode,
+			Type:         "aws_instance",
+			Name:         name,
+			Index:        index,
+			InstanceType: TypePrimary,
+		}
+	}
+
+	g.Add(concrete(&NodeAbstractResource{
+		Addr: addr("foo", 1),
+		Config: &config.Resource{
+			Mode: config.ManagedResourceMode,
+			Type: "aws_instance",
+			Name: "foo",
+		},
+		DeclRange: tfdiags.SourceRange{
+			Filename: "main.tf",
+			Start:    tfdiags.SourcePos{Line: 1, Column: 1},
+		},
+	}))
+	g.Add(concrete(&NodeAbstractResource{
+		Addr: addr("foo", 1),
+		Config: &config.Resource{
+			Mode: config.ManagedResourceMode,
+			Type: "aws_instance",
+			Name: "bar",
+		},
+	}))
+	g.Add(concrete(&NodeAbstractResource{
+		Addr: addr("foo", 0),
+		Config: &config.Resource{
+			Mode: config.ManagedResourceMode,
+			Type: "aws_instance",
+			Name: "foo",
+		},
+	}))
+
+	tf := &DuplicateResourceInstanceTransformer{}
+	err := tf.Transform(&g)
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+
+	want := "duplicate resource instance address module.child.aws_instance.foo[1], produced by both module.child.aws_instance.bar and module.child.aws_instance.foo (declared at main.tf:1,1)"
+	merr, ok := err.(*multierror.Error)
+	if !ok {
+		t.Fatalf("wrong error type %T", err)
+	}
+	if len(merr.Errors) != 1 || merr.Errors[0].Error() != want {
+		t.Fatalf("wrong errors\ngot:  %s\nwant: %s", merr.Errors, want)
+	}
+}
+
+func TestDuplicateResourceInstanceTransformer_count(t *testing.T) {
+	g := Graph{Path: RootModulePath}
+	steps := []GraphTransformer{
+		&ResourceCountTransformer{
+			Concrete: func(a *NodeAbstractResource) dag.Vertex {
+				return &NodeValidatableResourceInstance{NodeAbstractResource: a}
+			},
+			Count: 3,
+			Addr: &ResourceAddress{
+				Mode:  config.ManagedResourceMode,
+				Type:  "aws_instance",
+				Name:  "foo",
+				Index: -1,
+			},
+		},
+		&DuplicateResourceInstanceTransformer{},
+	}
+	for _, step := range steps {
+		if err := step.Transform(&g); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+}
@@ -18,6 +18,16 @@ import (
 func Variables(
 	m *module.Tree,
 	override map[string]interface{}) (map[string]interface{}, error) {
+	return variables(m, os.Environ(), override)
+}
+
+// variables is the implementation of Variables, taking the environment
+// variables to load values from as a separate argument so that callers can
+// exclude them.
+func variables(
+	m *module.Tree,
+	environ []string,
+	override map[string]interface{}) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
 	// Variables are loaded in the following sequence. Each additional step
@@ -64,7 +74,7 @@ func Variables(
 	}
 
 	// Load from env vars
-	for _, v := range os.Environ() {
+	for _, v := range environ {
 		if !strings.HasPrefix(v, VarEnvPrefix) {
 			continue
 		}
@@ -10,6 +10,7 @@ type rpcFriendlyDiag struct {
 	Detail_   string
 	Subject_  *SourceRange
 	Context_  *SourceRange
+	Rule_     string
 }
 
 // rpcFriendlyDiag transforms a given diagnostic so that is more friendly to
@@ -27,6 +28,7 @@ func makeRPCFriendlyDiag(diag Diagnostic) Diagnostic {
 		Detail_:   desc.Detail,
 		Subject_:  source.Subject,
 		Context_:  source.Context,
+		Rule_:     Rule(diag),
 	}
 }
 
@@ -48,6 +50,10 @@ func (d *rpcFriendlyDiag) Source() Source {
 	}
 }
 
+func (d *rpcFriendlyDiag) Rule() string {
+	return d.Rule_
+}
+
 func init() {
 	gob.Register((*rpcFriendlyDiag)(nil))
 }
@@ -27,6 +27,7 @@ func TestDiagnosticsForRPC(t *testing.T) {
 			Filename: "bar",
 		},
 	})
+	diags = diags.Append(WithRule("bad_rule", fmt.Errorf("ruled bad")))
 
 	buf := bytes.Buffer{}
 	enc := gob.NewEncoder(&buf)
@@ -64,6 +65,11 @@ func TestDiagnosticsForRPC(t *testing.T) {
 				Filename: "bar",
 			},
 		},
+		&rpcFriendlyDiag{
+			Severity_: Error,
+			Summary_:  "ruled bad",
+			Rule_:     "bad_rule",
+		},
 	}
 
 	if !reflect.DeepEqual(got, want) {
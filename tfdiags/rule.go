@@ -0,0 +1,43 @@
+package tfdiags
+
+import (
+	"fmt"
+)
+
+// ruleDiagnostic is a Diagnostic implementation that annotates another
+// diagnostic with the identifier of the rule that produced it.
+type ruleDiagnostic struct {
+	Diagnostic
+	rule string
+}
+
+// WithRule constructs a diagnostic from the given diagnostic-like value,
+// which may be any of the single-diagnostic types accepted by Append, and
+// annotates it with the given rule identifier for Rule to return. It panics
+// if given anything else, including a value that Append would expand into
+// several diagnostics.
+func WithRule(rule string, item interface{}) Diagnostic {
+	diags := Diagnostics(nil).Append(item)
+	if len(diags) != 1 {
+		panic(fmt.Errorf("can't construct a single diagnostic from %T", item))
+	}
+	return ruleDiagnostic{
+		Diagnostic: diags[0],
+		rule:       rule,
+	}
+}
+
+// Rule returns the rule identifier that the given diagnostic was annotated
+// with by WithRule, or an empty string if it has none.
+func Rule(diag Diagnostic) string {
+	if d, ok := diag.(interface {
+		Rule() string
+	}); ok {
+		return d.Rule()
+	}
+	return ""
+}
+
+func (d ruleDiagnostic) Rule() string {
+	return d.rule
+}
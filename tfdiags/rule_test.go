@@ -0,0 +1,49 @@
+package tfdiags
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+)
+
+func TestRule(t *testing.T) {
+	var diags Diagnostics
+	diags = diags.Append(WithRule("native_rule", fmt.Errorf("bad")))
+	diags = diags.Append(WithRule("hcl_rule", &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "less bad",
+		Subject: &hcl.Range{
+			Filename: "foo",
+		},
+	}))
+	diags = diags.Append(fmt.Errorf("unruly"))
+
+	tests := []struct {
+		Severity Severity
+		Summary  string
+		Rule     string
+	}{
+		{Error, "bad", "native_rule"},
+		{Warning, "less bad", "hcl_rule"},
+		{Error, "unruly", ""},
+	}
+	if len(diags) != len(tests) {
+		t.Fatalf("got %d diagnostics; want %d", len(diags), len(tests))
+	}
+	for i, test := range tests {
+		diag := diags[i]
+		if got := diag.Severity(); got != test.Severity {
+			t.Errorf("%d: wrong severity %s; want %s", i, got, test.Severity)
+		}
+		if got := diag.Description().Summary; got != test.Summary {
+			t.Errorf("%d: wrong summary %q; want %q", i, got, test.Summary)
+		}
+		if got := Rule(diag); got != test.Rule {
+			t.Errorf("%d: wrong rule %q; want %q", i, got, test.Rule)
+		}
+	}
+	if diags[1].Source().Subject == nil {
+		t.Errorf("source of the HCL diagnostic was lost")
+	}
+}
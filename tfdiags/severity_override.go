@@ -0,0 +1,32 @@
+package tfdiags
+
+// severityDiagnostic is a Diagnostic implementation that overrides the
+// severity of another diagnostic.
+type severityDiagnostic struct {
+	Diagnostic
+	severity Severity
+}
+
+// WithSeverity returns the given diagnostic with its severity replaced by
+// the given severity. The rule identifier of the diagnostic, if any, is
+// kept, as are its description and source.
+func WithSeverity(diag Diagnostic, severity Severity) Diagnostic {
+	if d, ok := diag.(severityDiagnostic); ok {
+		diag = d.Diagnostic
+	}
+	if diag.Severity() == severity {
+		return diag
+	}
+	return severityDiagnostic{
+		Diagnostic: diag,
+		severity:   severity,
+	}
+}
+
+func (d severityDiagnostic) Severity() Severity {
+	return d.severity
+}
+
+func (d severityDiagnostic) Rule() string {
+	return Rule(d.Diagnostic)
+}
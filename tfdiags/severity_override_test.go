@@ -0,0 +1,44 @@
+package tfdiags
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestWithSeverity(t *testing.T) {
+	var diags Diagnostics
+	diags = diags.Append(WithSeverity(WithRule("native_rule", fmt.Errorf("bad")), Warning))
+	diags = diags.Append(WithSeverity(SimpleWarning("less bad"), Error))
+	diags = diags.Append(WithSeverity(WithSeverity(SimpleWarning("unchanged"), Error), Warning))
+
+	tests := []struct {
+		Severity Severity
+		Summary  string
+		Rule     string
+	}{
+		{Warning, "bad", "native_rule"},
+		{Error, "less bad", ""},
+		{Warning, "unchanged", ""},
+	}
+	if len(diags) != len(tests) {
+		t.Fatalf("got %d diagnostics; want %d", len(diags), len(tests))
+	}
+	for i, test := range tests {
+		diag := diags[i]
+		if got := diag.Severity(); got != test.Severity {
+			t.Errorf("%d: wrong severity %s; want %s", i, got, test.Severity)
+		}
+		if got := diag.Description().Summary; got != test.Summary {
+			t.Errorf("%d: wrong summary %q; want %q", i, got, test.Summary)
+		}
+		if got := Rule(diag); got != test.Rule {
+			t.Errorf("%d: wrong rule %q; want %q", i, got, test.Rule)
+		}
+	}
+	if _, ok := diags[2].(severityDiagnostic); ok {
+		t.Errorf("restoring the original severity should unwrap the diagnostic")
+	}
+	if !diags.HasErrors() {
+		t.Errorf("HasErrors should report the overridden error")
+	}
+}